@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"soarca/pkg/core/secrets"
+	"soarca/pkg/utils"
+)
+
+const usage = `Manage the encrypted keystore of SOARCA secrets
+
+Usage:
+  soarca-keystore [-keystore path] set <name>     read the secret from stdin and store it
+  soarca-keystore [-keystore path] delete <name>  remove a secret
+  soarca-keystore [-keystore path] list           list the names of the secrets
+
+The passphrase is read from SECRETS_KEYSTORE_PASSPHRASE, the keystore path
+defaults to SECRETS_KEYSTORE.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	path := flag.String("keystore", utils.GetEnv("SECRETS_KEYSTORE", "./keystore.json"), "path of the keystore")
+	flag.Parse()
+
+	if err := run(*path, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(path string, args []string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		return fmt.Errorf("no command given")
+	}
+	keystore, err := secrets.OpenKeystore(path, os.Getenv("SECRETS_KEYSTORE_PASSPHRASE"))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, name := range keystore.Names() {
+			fmt.Println(name)
+		}
+		return nil
+	case args[0] == "set" && len(args) == 2:
+		// Read from stdin so the secret does not end up in the shell history
+		secret, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && secret == "" {
+			return fmt.Errorf("could not read the secret from stdin: %w", err)
+		}
+		keystore.Set(args[1], strings.TrimRight(secret, "\r\n"))
+		return keystore.Save()
+	case args[0] == "delete" && len(args) == 2:
+		if !keystore.Delete(args[1]) {
+			return fmt.Errorf("secret %s not found", args[1])
+		}
+		return keystore.Save()
+	}
+	flag.Usage()
+	return fmt.Errorf("unknown command %s", strings.Join(args, " "))
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
+	"soarca/internal/controller/decomposer_controller"
 	"soarca/internal/database/memory"
 	"soarca/internal/logger"
 
@@ -15,11 +17,17 @@ import (
 	"soarca/pkg/core/capability/manual/interaction"
 	"soarca/pkg/core/capability/openc2"
 	"soarca/pkg/core/capability/powershell"
+	"soarca/pkg/core/capability/simulator"
 	"soarca/pkg/core/capability/ssh"
 	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/execution_manager"
 	"soarca/pkg/core/executors/action"
 	"soarca/pkg/core/executors/condition"
 	"soarca/pkg/core/executors/playbook_action"
+	"soarca/pkg/core/journal"
+	"soarca/pkg/core/marking"
+	"soarca/pkg/core/scheduler"
+	"soarca/pkg/core/secrets"
 	"soarca/pkg/reporting/cases"
 	"soarca/pkg/reporting/reporter"
 	"soarca/pkg/utils"
@@ -27,6 +35,7 @@ import (
 	"soarca/pkg/utils/stix/expression/comparison"
 	"strconv"
 	"strings"
+	"sync"
 
 	finExecutor "soarca/pkg/core/capability/fin"
 	finChannelController "soarca/pkg/core/capability/fin/controller"
@@ -46,6 +55,7 @@ import (
 	"github.com/COSSAS/gauth"
 	"github.com/gin-gonic/gin"
 
+	journalrepository "soarca/internal/database/journal"
 	mongo "soarca/internal/database/mongodb"
 	playbookrepository "soarca/internal/database/playbook"
 	routes "soarca/pkg/api"
@@ -62,6 +72,17 @@ func init() {
 type Controller struct {
 	finController finChannelController.IFinController
 	playbookRepo  playbookrepository.IPlaybookRepository
+	journalRepo   journalrepository.IJournalRepository
+
+	// Executions share the decomposers below, together with their
+	// capabilities and reporters
+	mutex                sync.Mutex
+	decomposer           *decomposer.Decomposer
+	simulationDecomposer *decomposer.Decomposer
+	reporter             *reporter.Reporter
+	simulationReporter   *reporter.Reporter
+	caseManager          cases.ICasesManager
+	fins                 []string
 }
 
 var mainController = Controller{}
@@ -73,7 +94,124 @@ const defaultCacheSize int = 10
 // One manual interaction per SOARCA instance
 var mainInteraction = interaction.New(registerManualIntegration())
 
+// Running executions of this SOARCA instance, controlled through the execution API
+var mainExecutionManager = execution_manager.New()
+
+// Queue of the executions started through the trigger API
+var mainScheduler *scheduler.Scheduler
+
+const (
+	defaultMaxConcurrentExecutions int = 10
+	defaultMaxQueuedExecutions     int = 1000
+)
+
+// Persisted execution state, nil when the journal is disabled
+var mainJournal *journal.Journal
+
+// Resolves the secrets authentication information refers to
+var mainSecrets *secrets.Resolver
+
 func (controller *Controller) NewDecomposer() decomposer.IDecomposer {
+	return controller.getDecomposer()
+}
+
+// Decomposer for dry runs, every capability is replaced by a simulator
+func (controller *Controller) NewSimulationDecomposer() decomposer.IDecomposer {
+	return controller.getSimulationDecomposer()
+}
+
+func (controller *Controller) getDecomposer() *decomposer.Decomposer {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	controller.refreshCapabilities()
+	if controller.decomposer == nil {
+		controller.decomposer = controller.newDecomposer()
+	}
+	return controller.decomposer
+}
+
+func (controller *Controller) getSimulationDecomposer() *decomposer.Decomposer {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	controller.refreshCapabilities()
+	if controller.simulationDecomposer == nil {
+		controller.simulationDecomposer = controller.newSimulationDecomposer()
+	}
+	return controller.simulationDecomposer
+}
+
+// Fins register at runtime, the decomposers are rebuilt when the registered
+// fins change. Running executions keep the decomposer they started with.
+func (controller *Controller) refreshCapabilities() {
+	fins := []string{}
+	enableFins, _ := strconv.ParseBool(utils.GetEnv("ENABLE_FINS", "false"))
+	if enableFins && controller.finController != nil {
+		for key := range controller.finController.GetRegisteredCapabilities() {
+			fins = append(fins, key)
+		}
+		slices.Sort(fins)
+	}
+	if slices.Equal(fins, controller.fins) {
+		return
+	}
+	controller.fins = fins
+	controller.decomposer = nil
+	controller.simulationDecomposer = nil
+}
+
+func (controller *Controller) newDecomposer() *decomposer.Decomposer {
+	if controller.reporter == nil {
+		// NOTE: Enrolling mainCache by default as reporter
+		controller.reporter = reporter.New([]downstreamReporter.IDownStreamReporter{})
+		downstreamReporters := []downstreamReporter.IDownStreamReporter{&mainCache}
+		if mainJournal != nil {
+			downstreamReporters = append(downstreamReporters, mainJournal)
+		}
+
+		// Reporter integrations
+
+		thehive_reporter, theHiveCaseManager := initializeIntegrationTheHiveReporting()
+		if thehive_reporter != nil {
+			downstreamReporters = append(downstreamReporters, thehive_reporter)
+		}
+		controller.caseManager = theHiveCaseManager
+
+		controller.reporter.RegisterReporters(downstreamReporters)
+	}
+
+	var secretProvider secrets.ISecretProvider
+	if mainSecrets != nil {
+		secretProvider = mainSecrets
+	}
+	decompose := controller.assembleDecomposer(controller.capabilities(), controller, controller.reporter, secretProvider)
+	if controller.caseManager != nil {
+		decompose.SetCaseManager(controller.caseManager)
+	}
+	if mainJournal != nil {
+		decompose.SetJournal(mainJournal)
+	}
+	return decompose
+}
+
+// Simulated executions are only reported to the cache, they are not journaled
+// and do not open cases. Child playbooks are simulated as well. Secrets are
+// replaced by placeholders, a dry run never reads the real ones.
+func (controller *Controller) newSimulationDecomposer() *decomposer.Decomposer {
+	capabilities := map[string]capability.ICapability{}
+	for name := range controller.capabilities() {
+		capabilities[name] = simulator.New(name)
+	}
+
+	if controller.simulationReporter == nil {
+		controller.simulationReporter = reporter.New([]downstreamReporter.IDownStreamReporter{&mainCache})
+	}
+	return controller.assembleDecomposer(capabilities,
+		simulationController{controller},
+		controller.simulationReporter,
+		secrets.NewPlaceholderProvider())
+}
+
+func (controller *Controller) capabilities() map[string]capability.ICapability {
 	ssh := new(ssh.SshCapability)
 	capabilities := map[string]capability.ICapability{ssh.GetType(): ssh}
 
@@ -105,24 +243,20 @@ func (controller *Controller) NewDecomposer() decomposer.IDecomposer {
 			capabilities[key] = fin
 		}
 	}
+	return capabilities
+}
 
-	// NOTE: Enrolling mainCache by default as reporter
-	reporter := reporter.New([]downstreamReporter.IDownStreamReporter{})
-	downstreamReporters := []downstreamReporter.IDownStreamReporter{&mainCache}
-
-	// Reporter integrations
-
-	thehive_reporter, theHiveCaseManager := initializeIntegrationTheHiveReporting()
-	if thehive_reporter != nil {
-		downstreamReporters = append(downstreamReporters, thehive_reporter)
-	}
-
-	reporter.RegisterReporters(downstreamReporters)
-
+func (controller *Controller) assembleDecomposer(capabilities map[string]capability.ICapability,
+	decomposerController decomposer_controller.IController,
+	reporter *reporter.Reporter,
+	secretProvider secrets.ISecretProvider) *decomposer.Decomposer {
 	soarcaTime := new(timeUtil.Time)
-	actionExecutor := action.New(capabilities, reporter, soarcaTime)
-	playbookActionExecutor := playbook_action.New(controller, controller, reporter, soarcaTime)
 	stixComparison := comparison.New()
+	actionExecutor := action.New(capabilities, stixComparison, reporter, soarcaTime)
+	if secretProvider != nil {
+		actionExecutor.SetSecretProvider(secretProvider)
+	}
+	playbookActionExecutor := playbook_action.New(decomposerController, controller, reporter, soarcaTime)
 	conditionExecutor := condition.New(stixComparison, reporter, soarcaTime)
 	guid := new(guid.Guid)
 	decompose := decomposer.New(actionExecutor,
@@ -131,12 +265,52 @@ func (controller *Controller) NewDecomposer() decomposer.IDecomposer {
 		guid,
 		reporter,
 		soarcaTime)
-	if theHiveCaseManager != nil {
-		decompose.SetCaseManager(theHiveCaseManager)
-	}
+	decompose.SetExecutionManager(mainExecutionManager)
+	decompose.SetLoopLimits(loopLimits())
 	return decompose
 }
 
+// Secret providers of authentication information. Environment variables are
+// always available, the secrets directory and the keystore when configured.
+func newSecretResolver() (*secrets.Resolver, error) {
+	resolver := secrets.NewResolver()
+	resolver.Register(secrets.EnvProviderName, secrets.NewEnvProvider(utils.GetEnv("SECRETS_ENV_PREFIX", "SOARCA_SECRET_")))
+	if directory := utils.GetEnv("SECRETS_DIRECTORY", ""); directory != "" {
+		resolver.Register(secrets.FileProviderName, secrets.NewFileProvider(directory))
+	}
+	if path := utils.GetEnv("SECRETS_KEYSTORE", ""); path != "" {
+		keystore, err := secrets.OpenKeystore(path, os.Getenv("SECRETS_KEYSTORE_PASSPHRASE"))
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register(secrets.KeystoreProviderName, keystore)
+	}
+	resolver.SetDefaultProvider(utils.GetEnv("SECRETS_KMS_PROVIDER", secrets.KeystoreProviderName))
+	return resolver, nil
+}
+
+// Limits of while-condition steps, steps can override them with the loop extension
+func loopLimits() decomposer.LoopLimits {
+	maxIterations, err := strconv.Atoi(utils.GetEnv("LOOP_MAX_ITERATIONS", strconv.Itoa(decomposer.DefaultLoopMaxIterations)))
+	if err != nil || maxIterations < 1 {
+		maxIterations = decomposer.DefaultLoopMaxIterations
+	}
+	maxDuration, err := strconv.Atoi(utils.GetEnv("LOOP_MAX_DURATION", strconv.Itoa(decomposer.DefaultLoopMaxDuration)))
+	if err != nil || maxDuration < 1 {
+		maxDuration = decomposer.DefaultLoopMaxDuration
+	}
+	return decomposer.LoopLimits{MaxIterations: maxIterations, MaxDuration: maxDuration}
+}
+
+// Hands out simulation decomposers to playbook actions of a simulated execution
+type simulationController struct {
+	*Controller
+}
+
+func (controller simulationController) NewDecomposer() decomposer.IDecomposer {
+	return controller.getSimulationDecomposer()
+}
+
 func (controller *Controller) setupDatabase() error {
 	initMongoDatabase, _ := strconv.ParseBool(utils.GetEnv("DATABASE", "false"))
 
@@ -158,6 +332,7 @@ func (controller *Controller) setupDatabase() error {
 			return err
 		}
 		controller.playbookRepo = playbookrepository.SetupPlaybookRepository(mongo.GetCacaoRepo(), mongo.DefaultLimitOpts())
+		controller.journalRepo = journalrepository.SetupJournalRepository(mongo.GetJournalRepo(), mongo.DefaultLimitOpts())
 	} else {
 		// Use in memory database
 		controller.playbookRepo = memory.New()
@@ -166,6 +341,53 @@ func (controller *Controller) setupDatabase() error {
 	return nil
 }
 
+// Journal executions in MongoDB when the database is enabled, otherwise in local files
+func (controller *Controller) setupJournal() error {
+	enableJournal, _ := strconv.ParseBool(utils.GetEnv("EXECUTION_JOURNAL", "false"))
+	if !enableJournal {
+		return nil
+	}
+
+	if controller.journalRepo == nil {
+		path := utils.GetEnv("EXECUTION_JOURNAL_PATH", "./journal")
+		fileRepo, err := journalrepository.NewFileJournalRepository(path)
+		if err != nil {
+			return err
+		}
+		controller.journalRepo = fileRepo
+	}
+	mainJournal = journal.New(controller.journalRepo, &timeUtil.Time{})
+	return nil
+}
+
+// Resume the executions that did not finish before the last shutdown, or mark
+// them as failed when resuming is disabled
+func (controller *Controller) resumeExecutions() error {
+	if mainJournal == nil {
+		return nil
+	}
+	records, err := mainJournal.Load()
+	if err != nil {
+		return err
+	}
+
+	resume, _ := strconv.ParseBool(utils.GetEnv("EXECUTION_JOURNAL_RESUME", "true"))
+	decompose := controller.getDecomposer()
+	for _, record := range records {
+		if !resume {
+			if err := decompose.Interrupt(record); err != nil {
+				log.Error(err)
+			}
+			continue
+		}
+		// Resumed executions share the limits of the scheduler with new ones
+		if _, err := mainScheduler.Resume(decompose, record); err != nil {
+			log.Error(err)
+		}
+	}
+	return nil
+}
+
 func (controller *Controller) GetDatabaseInstance() playbookrepository.IPlaybookRepository {
 	return controller.playbookRepo
 }
@@ -185,8 +407,20 @@ func Initialize() error {
 
 	cacheSize, _ := strconv.Atoi(utils.GetEnv("MAX_EXECUTIONS", strconv.Itoa(defaultCacheSize)))
 	mainCache = *cache.New(&timeUtil.Time{}, cacheSize)
+	mainExecutionManager.SetStateReporter(&mainCache)
+
+	mainScheduler = newScheduler()
+	mainScheduler.SetQueueReporter(&mainCache)
+	mainScheduler.SetExecutionManager(mainExecutionManager)
+
+	secretResolver, err := newSecretResolver()
+	if err != nil {
+		log.Error("Failed to init secret providers: ", err)
+		return err
+	}
+	mainSecrets = secretResolver
 
-	err := initializeCore(app)
+	err = initializeCore(app)
 	if err != nil {
 		log.Error("Failed to init core")
 		return err
@@ -249,7 +483,13 @@ func initializeCore(app *gin.Engine) error {
 		return err
 	}
 
-	err = routes.Api(app, &mainController, &mainController)
+	err = mainController.setupJournal()
+	if err != nil {
+		log.Error("Failed to setup execution journal:", err)
+		return err
+	}
+
+	err = routes.Api(app, &mainController, &mainController, mainScheduler, &mainCache)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -272,12 +512,40 @@ func initializeCore(app *gin.Engine) error {
 	// Manual capability native routes
 	routes.Manual(app, mainInteraction)
 
+	routes.Execution(app, mainExecutionManager)
+
 	routes.Logging(app)
 	routes.Swagger(app)
 
+	err = mainController.resumeExecutions()
+	if err != nil {
+		log.Error("Failed to resume executions:", err)
+		return err
+	}
+
 	return err
 }
 
+func newScheduler() *scheduler.Scheduler {
+	maxRunning, err := strconv.Atoi(utils.GetEnv("MAX_CONCURRENT_EXECUTIONS", strconv.Itoa(defaultMaxConcurrentExecutions)))
+	if err != nil || maxRunning < 1 {
+		maxRunning = defaultMaxConcurrentExecutions
+	}
+	maxQueued, err := strconv.Atoi(utils.GetEnv("MAX_QUEUED_EXECUTIONS", strconv.Itoa(defaultMaxQueuedExecutions)))
+	if err != nil || maxQueued < 0 {
+		maxQueued = defaultMaxQueuedExecutions
+	}
+	maxPerPlaybook, err := strconv.Atoi(utils.GetEnv("MAX_CONCURRENT_PLAYBOOK_EXECUTIONS", "0"))
+	if err != nil || maxPerPlaybook < 0 {
+		maxPerPlaybook = 0
+	}
+	log.Info(fmt.Sprintf("running up to %d executions at the same time", maxRunning))
+
+	return scheduler.New(&guid.Guid{}, &timeUtil.Time{}, scheduler.Limits{MaxRunning: maxRunning,
+		MaxQueued:             maxQueued,
+		MaxRunningPerPlaybook: maxPerPlaybook})
+}
+
 func (controller *Controller) setupAndRunMqtt() error {
 	broker, port := getMqttDetails()
 	mqttClient := finChannelController.NewClient(protocol.Broker(broker), port)
@@ -318,16 +586,29 @@ func initializeIntegrationTheHiveReporting() (downstreamReporter.IDownStreamRepo
 
 	log.Info(fmt.Sprintf("creating new The hive connector with API base url at : %s", thehiveApiBaseUrl))
 	theHiveConnector := connector.NewConnector(thehiveApiBaseUrl, thehiveApiToken, theHiveInsecureConnection)
+	maxMarking := theHiveMaxMarking()
 	caseReporting, _ := strconv.ParseBool(utils.GetEnv("THEHIVE_REPORTER", "false"))
 	if caseReporting {
 		log.Info("enabling the hive reporter")
 		theHiveCases := thehiveCases.NewCaseManager(theHiveConnector)
+		theHiveCases.SetMaxMarking(maxMarking)
 		return theHiveCases, theHiveCases
 	}
 	theHiveReporter := thehive.NewReporter(theHiveConnector)
+	theHiveReporter.SetMaxMarking(maxMarking)
 	return theHiveReporter, nil
 }
 
+// Data marked higher than the configured TLP level is redacted before it is pushed to The Hive
+func theHiveMaxMarking() marking.Level {
+	maxMarking, err := marking.ParseLevel(utils.GetEnv("THEHIVE_MAX_MARKING", marking.Amber.String()))
+	if err != nil {
+		log.Warning("invalid THEHIVE_MAX_MARKING, using ", marking.Amber, ": ", err)
+		return marking.Amber
+	}
+	return maxMarking
+}
+
 func intializeAuthenticationMiddleware(app *gin.Engine) error {
 	authEnabled, _ := strconv.ParseBool(utils.GetEnv("AUTH_ENABLED", "false"))
 	if authEnabled {
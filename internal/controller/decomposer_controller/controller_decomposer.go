@@ -6,4 +6,5 @@ import (
 
 type IController interface {
 	NewDecomposer() decomposer.IDecomposer
+	NewSimulationDecomposer() decomposer.IDecomposer
 }
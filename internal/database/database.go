@@ -10,4 +10,5 @@ type Database interface {
 type FindOptions interface {
 	GetIds() interface{}
 	GetProjectionByType(interface{}) interface{}
+	GetAll() interface{}
 }
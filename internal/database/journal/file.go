@@ -0,0 +1,117 @@
+package journalrepository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"soarca/pkg/models/journal"
+
+	"github.com/google/uuid"
+)
+
+const fileExtension = ".json"
+
+// Journal store keeping one json file per execution in a local directory
+type FileJournalRepository struct {
+	path string
+}
+
+func NewFileJournalRepository(path string) (*FileJournalRepository, error) {
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileJournalRepository{path: path}, nil
+}
+
+func (fileRepo *FileJournalRepository) GetExecutions() ([]journal.ExecutionRecord, error) {
+	entries, err := os.ReadDir(fileRepo.path)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []journal.ExecutionRecord{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExtension) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(fileRepo.path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		record := journal.ExecutionRecord{}
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (fileRepo *FileJournalRepository) Create(record journal.ExecutionRecord) error {
+	filename, err := fileRepo.filename(record.ExecutionId)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filename); err == nil {
+		return errors.New("duplicate")
+	}
+	return fileRepo.write(filename, record)
+}
+
+func (fileRepo *FileJournalRepository) Update(record journal.ExecutionRecord) error {
+	filename, err := fileRepo.filename(record.ExecutionId)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filename); err != nil {
+		return err
+	}
+	return fileRepo.write(filename, record)
+}
+
+func (fileRepo *FileJournalRepository) Delete(executionId string) error {
+	filename, err := fileRepo.filename(executionId)
+	if err != nil {
+		return err
+	}
+	return os.Remove(filename)
+}
+
+// Only execution ids are accepted as file names, to stay inside the journal directory
+func (fileRepo *FileJournalRepository) filename(executionId string) (string, error) {
+	id, err := uuid.Parse(executionId)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(fileRepo.path, id.String()+fileExtension), nil
+}
+
+// Write to a temporary file first, so a crash never leaves a partial record.
+// Records hold the scope variables in plain text, the temporary file is always
+// newly created and only readable by the owner.
+func (fileRepo *FileJournalRepository) write(filename string, record journal.ExecutionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	temporary, err := os.CreateTemp(fileRepo.path, filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	if err := temporary.Chmod(0o600); err != nil {
+		temporary.Close()
+		return err
+	}
+	if _, err := temporary.Write(data); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), filename)
+}
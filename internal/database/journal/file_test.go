@@ -0,0 +1,71 @@
+package journalrepository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/journal"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestFileJournalCreateUpdateDelete(t *testing.T) {
+	repository, err := NewFileJournalRepository(filepath.Join(t.TempDir(), "journal"))
+	assert.Equal(t, err, nil)
+
+	record := journal.ExecutionRecord{
+		ExecutionId: "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		Playbook:    cacao.Playbook{ID: "playbook--test", WorkflowStart: "action--test"},
+		StepId:      "action--test",
+		Variables: cacao.NewVariables(
+			cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}),
+	}
+	err = repository.Create(record)
+	assert.Equal(t, err, nil)
+	err = repository.Create(record)
+	assert.NotEqual(t, err, nil)
+
+	record.StepId = "action--test2"
+	err = repository.Update(record)
+	assert.Equal(t, err, nil)
+
+	records, err := repository.GetExecutions()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].StepId, "action--test2")
+	assert.Equal(t, records[0].Variables, record.Variables)
+
+	// Only the record itself is left, readable by the owner only
+	entries, _ := os.ReadDir(repository.path)
+	assert.Equal(t, len(entries), 1)
+	info, err := os.Stat(filepath.Join(repository.path, record.ExecutionId+fileExtension))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+	err = repository.Delete(record.ExecutionId)
+	assert.Equal(t, err, nil)
+	records, err = repository.GetExecutions()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 0)
+}
+
+func TestFileJournalUpdateUnknownRecord(t *testing.T) {
+	repository, err := NewFileJournalRepository(t.TempDir())
+	assert.Equal(t, err, nil)
+
+	err = repository.Update(journal.ExecutionRecord{ExecutionId: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"})
+	assert.Equal(t, os.IsNotExist(err), true)
+}
+
+func TestFileJournalRejectsInvalidExecutionId(t *testing.T) {
+	path := t.TempDir()
+	repository, err := NewFileJournalRepository(path)
+	assert.Equal(t, err, nil)
+
+	err = repository.Create(journal.ExecutionRecord{ExecutionId: "../playbook"})
+	assert.NotEqual(t, err, nil)
+	entries, _ := os.ReadDir(path)
+	assert.Equal(t, len(entries), 0)
+}
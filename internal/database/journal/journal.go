@@ -0,0 +1,54 @@
+package journalrepository
+
+import (
+	"errors"
+
+	database "soarca/internal/database"
+	"soarca/pkg/models/journal"
+)
+
+type IJournalRepository interface {
+	GetExecutions() ([]journal.ExecutionRecord, error)
+	Create(record journal.ExecutionRecord) error
+	Update(record journal.ExecutionRecord) error
+	Delete(executionId string) error
+}
+
+type JournalRepository struct {
+	db      database.Database
+	options database.FindOptions
+}
+
+func SetupJournalRepository(db database.Database, options database.FindOptions) *JournalRepository {
+	return &JournalRepository{db: db, options: options}
+}
+
+// All unfinished executions are returned, none may be left behind on a restart
+func (journalRepo *JournalRepository) GetExecutions() ([]journal.ExecutionRecord, error) {
+	records, err := journalRepo.db.Find(nil, journalRepo.options.GetAll())
+	if err != nil {
+		return nil, err
+	}
+
+	returnRecords := []journal.ExecutionRecord{}
+	for _, record := range records {
+		record, ok := record.(journal.ExecutionRecord)
+		if !ok {
+			return nil, errors.New("type assertion failed for journal.ExecutionRecord type")
+		}
+		returnRecords = append(returnRecords, record)
+	}
+	return returnRecords, nil
+}
+
+func (journalRepo *JournalRepository) Create(record journal.ExecutionRecord) error {
+	return journalRepo.db.Create(record)
+}
+
+func (journalRepo *JournalRepository) Update(record journal.ExecutionRecord) error {
+	return journalRepo.db.Update(record.ExecutionId, record)
+}
+
+func (journalRepo *JournalRepository) Delete(executionId string) error {
+	return journalRepo.db.Delete(executionId)
+}
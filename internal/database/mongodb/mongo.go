@@ -8,6 +8,7 @@ import (
 
 	"soarca/internal/database/projections"
 	cacao "soarca/pkg/models/cacao"
+	journal "soarca/pkg/models/journal"
 
 	"go.mongodb.org/mongo-driver/bson"
 	mongo "go.mongodb.org/mongo-driver/mongo"
@@ -18,11 +19,12 @@ const writeErrorDuplicationCode = 11000
 
 var (
 	cacaoPlayBookRepo *mongoCollection[cacao.Playbook]
+	journalRepo       *mongoCollection[journal.ExecutionRecord]
 	mongoclient       *mongo.Client
 )
 
 type dbtypes interface {
-	cacao.Playbook // | for other supported types
+	cacao.Playbook | journal.ExecutionRecord // | for other supported types
 }
 
 type mongoCollection[T dbtypes] struct {
@@ -54,10 +56,21 @@ func (mongoOpts mongoFindOptions) GetProjectionByType(interface{}) interface{} {
 	}
 }
 
+// Lift the default limit, for queries that must return every document
+func (mongoOpts mongoFindOptions) GetAll() interface{} {
+	return func(lo *mongoFindOptions) {
+		lo.findOptions.SetLimit(0)
+	}
+}
+
 func GetCacaoRepo() *mongoCollection[cacao.Playbook] {
 	return cacaoPlayBookRepo
 }
 
+func GetJournalRepo() *mongoCollection[journal.ExecutionRecord] {
+	return journalRepo
+}
+
 // func GetMongoClient() *mongodbClient {
 // 	return mongoclient
 // }
@@ -77,6 +90,10 @@ func SetupMongodb(uri string, username string, password string) error {
 	}
 
 	cacaoPlayBookRepo, err = NewMongoCollection[cacao.Playbook](mongoclient, "soarca", "cacoa_playbook_collection")
+	if err != nil {
+		return err
+	}
+	journalRepo, err = NewMongoCollection[journal.ExecutionRecord](mongoclient, "soarca", "execution_journal_collection")
 	return err
 }
 
@@ -7,10 +7,14 @@ import (
 	"soarca/internal/controller/decomposer_controller"
 	"soarca/internal/controller/informer"
 	"soarca/internal/logger"
+	execution_handler "soarca/pkg/api/execution"
 	playbook_handler "soarca/pkg/api/playbook"
 	reporter_handler "soarca/pkg/api/reporter"
 	status_handler "soarca/pkg/api/status"
 	"soarca/pkg/core/capability/manual/interaction"
+	"soarca/pkg/core/execution_manager"
+	"soarca/pkg/core/scheduler"
+	timeUtil "soarca/pkg/utils/time"
 
 	manual_handler "soarca/pkg/api/manual"
 
@@ -34,7 +38,7 @@ func Database(app *gin.Engine,
 	controller database.IController,
 ) error {
 	log.Trace("Setting up playbook routes")
-	PlaybookRoutes(app, controller)
+	PlaybookRoutes(app, controller, new(timeUtil.Time))
 	return nil
 }
 
@@ -54,14 +58,24 @@ func Manual(app *gin.Engine, interaction interaction.IInteractionStorage) {
 	ManualRoutes(app, manualHandler)
 }
 
+func Execution(app *gin.Engine, executionManager execution_manager.IExecutionManager) {
+	log.Trace("Setting up execution routes")
+	executionHandler := execution_handler.NewExecutionHandler(executionManager)
+	ExecutionRoutes(app, executionHandler)
+}
+
 func Api(app *gin.Engine,
 	controller decomposer_controller.IController,
 	database database.IController,
+	scheduler scheduler.IScheduler,
+	informer informer.IExecutionInformer,
 ) error {
 	log.Trace("Trying to setup all Routes")
 	// gin.SetMode(gin.ReleaseMode)
-	triggerHandler := trigger_handler.NewTriggerHandler(controller, database)
+	triggerHandler := trigger_handler.NewTriggerHandler(controller, database, scheduler, new(timeUtil.Time))
+	triggerHandler.SetInformer(informer)
 	TriggerRoutes(app, triggerHandler)
+	status_handler.SetScheduler(scheduler)
 	StatusRoutes(app)
 
 	return nil
@@ -91,8 +105,8 @@ func swaggerRoutes(route *gin.Engine) {
 // GET     /playbook/playbook-id
 // PUT     /playbook/playbook-id
 // DELETE  /playbook/playbook-id
-func PlaybookRoutes(route *gin.Engine, controller database.IController) {
-	playbookHandler := playbook_handler.NewPlaybookHandler(controller)
+func PlaybookRoutes(route *gin.Engine, controller database.IController, time timeUtil.ITime) {
+	playbookHandler := playbook_handler.NewPlaybookHandler(controller, time)
 	playbookRoutes := route.Group("/playbook")
 	{
 		playbookRoutes.GET("/", playbookHandler.GetAllPlaybooks)
@@ -136,6 +150,18 @@ func TriggerRoutes(route *gin.Engine, triggerHandler *trigger_handler.TriggerHan
 	}
 }
 
+// POST    /execution/execution-id/cancel
+func ExecutionRoutes(route *gin.Engine, executionHandler *execution_handler.ExecutionHandler) {
+	executionRoutes := route.Group("/execution")
+	{
+		executionRoutes.POST("/:id/cancel", executionHandler.Cancel)
+		executionRoutes.POST("/:id/pause", executionHandler.Pause)
+		executionRoutes.POST("/:id/resume", executionHandler.Resume)
+		executionRoutes.GET("/:id/variables", executionHandler.GetVariables)
+		executionRoutes.PATCH("/:id/variables", executionHandler.UpdateVariables)
+	}
+}
+
 func ManualRoutes(route *gin.Engine, manualHandler *manual_handler.ManualHandler) {
 	manualRoutes := route.Group("/manual")
 	{
@@ -0,0 +1,229 @@
+package execution
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"soarca/internal/logger"
+	"soarca/pkg/core/execution_manager"
+	"soarca/pkg/models/api"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	apiError "soarca/pkg/api/error"
+)
+
+var log *logger.Log
+
+type Empty struct{}
+
+func init() {
+	log = logger.Logger(reflect.TypeOf(Empty{}).PkgPath(), logger.Info, "", logger.Json)
+}
+
+type ExecutionHandler struct {
+	executionManager execution_manager.IExecutionManager
+}
+
+func NewExecutionHandler(executionManager execution_manager.IExecutionManager) *ExecutionHandler {
+	return &ExecutionHandler{executionManager: executionManager}
+}
+
+// execution
+//
+//	@Summary	cancel a running execution
+//	@Schemes
+//	@Description	cancel a running playbook execution, including running steps and child executions
+//	@Tags			execution
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"execution ID"
+//	@Success		200	{object}	api.ExecutionStatus
+//	@failure		400	{object}	api.Error
+//	@failure		404	{object}	api.Error
+//	@Router			/execution/{id}/cancel [POST]
+func (handler *ExecutionHandler) Cancel(g *gin.Context) {
+	executionId, ok := parseExecutionId(g, "POST", "cancel")
+	if !ok {
+		return
+	}
+
+	err := handler.executionManager.Cancel(executionId)
+	if err != nil {
+		log.Error(err)
+		sendExecutionError(g, "Failed to cancel execution", "POST", "cancel", err)
+		return
+	}
+
+	g.JSON(http.StatusOK,
+		api.ExecutionStatus{ExecutionId: executionId, Status: api.Cancelled})
+}
+
+// execution
+//
+//	@Summary	pause a running execution
+//	@Schemes
+//	@Description	pause a running playbook execution before its next step, running steps are completed
+//	@Tags			execution
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"execution ID"
+//	@Success		200	{object}	api.ExecutionStatus
+//	@failure		400	{object}	api.Error
+//	@failure		404	{object}	api.Error
+//	@failure		409	{object}	api.Error
+//	@Router			/execution/{id}/pause [POST]
+func (handler *ExecutionHandler) Pause(g *gin.Context) {
+	executionId, ok := parseExecutionId(g, "POST", "pause")
+	if !ok {
+		return
+	}
+
+	err := handler.executionManager.Pause(executionId)
+	if err != nil {
+		log.Error(err)
+		sendExecutionError(g, "Failed to pause execution", "POST", "pause", err)
+		return
+	}
+
+	g.JSON(http.StatusOK,
+		api.ExecutionStatus{ExecutionId: executionId, Status: api.Paused})
+}
+
+// execution
+//
+//	@Summary	resume a paused execution
+//	@Schemes
+//	@Description	resume a paused playbook execution with its (updated) scope variables
+//	@Tags			execution
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"execution ID"
+//	@Success		200	{object}	api.ExecutionStatus
+//	@failure		400	{object}	api.Error
+//	@failure		404	{object}	api.Error
+//	@failure		409	{object}	api.Error
+//	@Router			/execution/{id}/resume [POST]
+func (handler *ExecutionHandler) Resume(g *gin.Context) {
+	executionId, ok := parseExecutionId(g, "POST", "resume")
+	if !ok {
+		return
+	}
+
+	err := handler.executionManager.Resume(executionId)
+	if err != nil {
+		log.Error(err)
+		sendExecutionError(g, "Failed to resume execution", "POST", "resume", err)
+		return
+	}
+
+	g.JSON(http.StatusOK,
+		api.ExecutionStatus{ExecutionId: executionId, Status: api.Ongoing})
+}
+
+// execution
+//
+//	@Summary	get the scope variables of a paused execution
+//	@Schemes
+//	@Description	get the scope variables of a paused playbook execution at the step it is paused on
+//	@Tags			execution
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"execution ID"
+//	@Success		200	{object}	cacao.Variables
+//	@failure		400	{object}	api.Error
+//	@failure		404	{object}	api.Error
+//	@failure		409	{object}	api.Error
+//	@Router			/execution/{id}/variables [GET]
+func (handler *ExecutionHandler) GetVariables(g *gin.Context) {
+	executionId, ok := parseExecutionId(g, "GET", "variables")
+	if !ok {
+		return
+	}
+
+	variables, err := handler.executionManager.GetVariables(executionId)
+	if err != nil {
+		log.Error(err)
+		sendExecutionError(g, "Failed to get execution variables", "GET", "variables", err)
+		return
+	}
+
+	g.JSON(http.StatusOK, variables)
+}
+
+// execution
+//
+//	@Summary	update the scope variables of a paused execution
+//	@Schemes
+//	@Description	update the values of the scope variables of a paused playbook execution, constant variables cannot be updated
+//	@Tags			execution
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"execution ID"
+//	@Param			data	body		cacao.Variables	true	"variables"
+//	@Success		200		{object}	cacao.Variables
+//	@failure		400		{object}	api.Error
+//	@failure		404		{object}	api.Error
+//	@failure		409		{object}	api.Error
+//	@Router			/execution/{id}/variables [PATCH]
+func (handler *ExecutionHandler) UpdateVariables(g *gin.Context) {
+	executionId, ok := parseExecutionId(g, "PATCH", "variables")
+	if !ok {
+		return
+	}
+
+	jsonData, err := io.ReadAll(g.Request.Body)
+	if err != nil {
+		log.Error(err)
+		apiError.SendErrorResponse(g, http.StatusBadRequest,
+			"Failed to read json",
+			"PATCH /execution/"+executionId.String()+"/variables", "")
+		return
+	}
+	variables := cacao.NewVariables()
+	if err := json.Unmarshal(jsonData, &variables); err != nil {
+		log.Error(err)
+		apiError.SendErrorResponse(g, http.StatusBadRequest,
+			"Failed to parse variables",
+			"PATCH /execution/"+executionId.String()+"/variables", err.Error())
+		return
+	}
+
+	variables, err = handler.executionManager.UpdateVariables(executionId, variables)
+	if err != nil {
+		log.Error(err)
+		sendExecutionError(g, "Failed to update execution variables", "PATCH", "variables", err)
+		return
+	}
+
+	g.JSON(http.StatusOK, variables)
+}
+
+func parseExecutionId(g *gin.Context, method string, action string) (uuid.UUID, bool) {
+	id := g.Param("id")
+	executionId, err := uuid.Parse(id)
+	if err != nil {
+		log.Error(err)
+		apiError.SendErrorResponse(g, http.StatusBadRequest,
+			"Failed to parse execution ID",
+			method+" /execution/"+id+"/"+action, "")
+		return uuid.UUID{}, false
+	}
+	return executionId, true
+}
+
+func sendExecutionError(g *gin.Context, msg string, method string, action string, err error) {
+	code := http.StatusBadRequest
+	if errors.As(err, &execution.ErrorExecutionNotFound{}) {
+		code = http.StatusNotFound
+	} else if errors.As(err, &execution.ErrorExecutionState{}) {
+		code = http.StatusConflict
+	}
+	apiError.SendErrorResponse(g, code, msg,
+		method+" /execution/"+g.Param("id")+"/"+action, err.Error())
+}
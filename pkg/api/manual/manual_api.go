@@ -11,6 +11,7 @@ import (
 	"soarca/internal/logger"
 	"soarca/pkg/core/capability/manual/interaction"
 	"soarca/pkg/models/api"
+	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 	"soarca/pkg/models/manual"
 
@@ -170,16 +171,21 @@ func (manualHandler *ManualHandler) PostContinue(g *gin.Context) {
 		log.Error(err)
 		code := http.StatusBadRequest
 		msg := "Failed to post the continue request"
+		details := ""
 		if errors.Is(err, manual.ErrorPendingCommandNotFound{}) {
 			code = http.StatusNotFound
 			msg = "Pending command not found"
 		} else if errors.Is(err, manual.ErrorNonMatchingOutArgs{}) {
 			code = http.StatusBadRequest
 			msg = "Provided out args don't match with expected"
+		} else if errors.As(err, &cacao.ErrorVariableValue{}) {
+			code = http.StatusBadRequest
+			msg = "Provided out args don't match their variable type"
+			details = err.Error()
 		}
 		apiError.SendErrorResponse(g, code,
 			msg,
-			"POST /manual/continue", "")
+			"POST /manual/continue", details)
 		return
 	}
 	executionId, err := uuid.Parse(outArgsUpdate.ExecutionId)
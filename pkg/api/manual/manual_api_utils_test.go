@@ -125,6 +125,23 @@ func TestParseCommandInfoToResponse(t *testing.T) {
 	assert.Equal(t, reflect.DeepEqual(returnInteractionCommandData, expectedInteractionCommand), true)
 }
 
+func TestParseBase64CommandInfoToResponse(t *testing.T) {
+	manualHandler := NewManualHandler(&mock_interaction_storage.MockInteractionStorage{})
+
+	testExecId := "50b6d52c-6efc-4516-a242-dfbc5c89d421"
+	encoded := "cGxlYXNlIGRvIGEgdGVzdCB0aGFua3M="
+	command := cacao.Command{Type: "manual", Command: "please do a test thanks", CommandB64: encoded}
+
+	commandInfo := manual.CommandInfo{
+		Metadata: execution.Metadata{ExecutionId: uuid.MustParse(testExecId)},
+		Context:  capability.Context{Command: command},
+	}
+
+	response := manualHandler.parseCommandInfoToResponse(commandInfo)
+	assert.Equal(t, response.Command, encoded)
+	assert.Equal(t, response.CommandIsBase64, true)
+}
+
 func TestParseManualOutArgsToInteractionResponse(t *testing.T) {
 	manualHandler := NewManualHandler(&mock_interaction_storage.MockInteractionStorage{})
 
@@ -6,7 +6,10 @@ import (
 	"reflect"
 	"soarca/internal/controller/database"
 	"soarca/internal/logger"
+	"soarca/pkg/models/api"
+	timeUtil "soarca/pkg/utils/time"
 	"strconv"
+	"time"
 
 	playbookrepository "soarca/internal/database/playbook"
 
@@ -24,11 +27,12 @@ func init() {
 // a playbookHandler implements the playbook api endpoints is dependent on a database.
 type playbookHandler struct {
 	playbookRepo playbookrepository.IPlaybookRepository
+	time         timeUtil.ITime
 }
 
 // NewPlaybookHandler makes a new instance of NewPlaybookHandler
-func NewPlaybookHandler(controller database.IController) *playbookHandler {
-	return &playbookHandler{playbookRepo: controller.GetDatabaseInstance()}
+func NewPlaybookHandler(controller database.IController, time timeUtil.ITime) *playbookHandler {
+	return &playbookHandler{playbookRepo: controller.GetDatabaseInstance(), time: time}
 }
 
 // GetAllPlaybooks GET handler for obtaining all the playbooks in the database and return this to the gin context in json format
@@ -62,8 +66,9 @@ func (handler *playbookHandler) GetAllPlaybooks(g *gin.Context) {
 //	@Description	get playbook meta information for playbook
 //	@Tags			playbook
 //	@Produce		json
-//	@success		200	{array}		api.PlaybookMeta
-//	@failure		400	{object}	api.Error
+//	@Param			expiring_within	query		string	false	"only playbooks whose valid_until passes within this duration, e.g. 168h"
+//	@success		200				{array}		api.PlaybookMeta
+//	@failure		400				{object}	api.Error
 //	@Router			/playbook/meta [GET]
 func (handler *playbookHandler) GetAllPlaybookMetas(g *gin.Context) {
 	log.Trace("Trying to obtain all playbook IDs")
@@ -75,9 +80,32 @@ func (handler *playbookHandler) GetAllPlaybookMetas(g *gin.Context) {
 		return
 	}
 
+	if within := g.Query("expiring_within"); within != "" {
+		duration, err := time.ParseDuration(within)
+		if err != nil || duration <= 0 {
+			SendErrorResponse(g, http.StatusBadRequest, "Invalid expiring_within duration "+within, "GET /playbook/meta")
+			return
+		}
+		returnListIDs = expiringWithin(returnListIDs, handler.time.Now(), duration)
+	}
+
 	g.JSON(http.StatusOK, returnListIDs)
 }
 
+// Playbooks that are still valid at the given time, but expire within the duration
+func expiringWithin(metas []api.PlaybookMeta, at time.Time, duration time.Duration) []api.PlaybookMeta {
+	expiring := []api.PlaybookMeta{}
+	for _, meta := range metas {
+		if meta.ValidUntil.IsZero() || meta.ValidUntil.Before(at) {
+			continue
+		}
+		if !meta.ValidUntil.After(at.Add(duration)) {
+			expiring = append(expiring, meta)
+		}
+	}
+	return expiring
+}
+
 // SubmitPlaybook POST handler for creating playbooks.
 //
 //	@Summary	submit playbook via the api
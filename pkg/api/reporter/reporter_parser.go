@@ -54,6 +54,11 @@ func parseCacheStepEntries(cacheStepEntries map[string]cache_model.StepResult) (
 			stepStatusText = stepStatusText + " - error: " + stepEntry.Error.Error()
 		}
 
+		attempts, err := parseCacheStepAttempts(stepEntry.Attempts)
+		if err != nil {
+			return map[string]api_model.StepExecutionReport{}, err
+		}
+
 		parsedEntries[stepId] = api_model.StepExecutionReport{
 			ExecutionId:        stepEntry.ExecutionId.String(),
 			StepId:             stepEntry.StepId,
@@ -67,7 +72,47 @@ func parseCacheStepEntries(cacheStepEntries map[string]cache_model.StepResult) (
 			CommandsB64:        stepEntry.CommandsB64,
 			Variables:          stepEntry.Variables,
 			AutomatedExecution: stepEntry.IsAutomated,
+			Attempts:           attempts,
+			Iterations:         parseCacheStepIterations(stepEntry.Iterations),
 		}
 	}
 	return parsedEntries, nil
 }
+
+func parseCacheStepAttempts(cacheAttempts []cache_model.StepAttempt) ([]api_model.StepAttemptReport, error) {
+	if len(cacheAttempts) == 0 {
+		return nil, nil
+	}
+	attempts := []api_model.StepAttemptReport{}
+	for _, attempt := range cacheAttempts {
+		status := api_model.CacheStatusEnum2String(attempt.Status)
+		statusText, err := api_model.GetCacheStatusText(status, api_model.ReportLevelStep)
+		if err != nil {
+			return nil, err
+		}
+		if attempt.Error != nil {
+			statusText = statusText + " - error: " + attempt.Error.Error()
+		}
+		attempts = append(attempts, api_model.StepAttemptReport{
+			Attempt:    attempt.Attempt,
+			Ended:      attempt.Ended,
+			Status:     status,
+			StatusText: statusText,
+		})
+	}
+	return attempts, nil
+}
+
+func parseCacheStepIterations(cacheIterations []cache_model.StepIteration) []api_model.StepIterationReport {
+	if len(cacheIterations) == 0 {
+		return nil
+	}
+	iterations := []api_model.StepIterationReport{}
+	for _, iteration := range cacheIterations {
+		iterations = append(iterations, api_model.StepIterationReport{
+			Iteration: iteration.Iteration,
+			Started:   iteration.Started,
+		})
+	}
+	return iterations
+}
@@ -3,6 +3,7 @@ package status
 import (
 	"net/http"
 	"runtime"
+	"soarca/pkg/core/scheduler"
 	"soarca/pkg/models/api"
 	"soarca/pkg/utils"
 	"time"
@@ -16,10 +17,17 @@ var status = api.Status{
 	Runtime: runtime.GOOS,
 }
 
+// Queue of the executions started through the trigger API
+var executionScheduler scheduler.IScheduler
+
 func SetVersion(version string) {
 	status.Version = version
 }
 
+func SetScheduler(scheduler scheduler.IScheduler) {
+	executionScheduler = scheduler
+}
+
 // /Status/ping GET handler for handling status api calls
 // Returns the status model object for SOARCA
 //
@@ -48,6 +56,13 @@ func GetPong(g *gin.Context) {
 func GetApi(g *gin.Context) {
 	status.Uptime.Milliseconds = uint64(time.Since(status.Uptime.Since).Milliseconds())
 	status.Time = time.Now()
+	if executionScheduler != nil {
+		queue := executionScheduler.Status()
+		status.Executions = &api.ExecutionQueue{Running: queue.Running,
+			Queued:     queue.Queued,
+			MaxRunning: queue.MaxRunning,
+			MaxQueued:  queue.MaxQueued}
+	}
 
 	g.JSON(http.StatusOK, status)
 }
@@ -1,6 +1,7 @@
 package trigger
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,20 +10,33 @@ import (
 	"reflect"
 	"soarca/internal/controller/database"
 	"soarca/internal/controller/decomposer_controller"
+	"soarca/internal/controller/informer"
 	"soarca/internal/logger"
 	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/scheduler"
 	"soarca/pkg/models/api"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/decoder"
+	"soarca/pkg/models/execution"
+	timeUtil "soarca/pkg/utils/time"
 	"time"
 
 	apiError "soarca/pkg/api/error"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type Empty struct{}
 
+const (
+	ModeExecute  = "execute"
+	ModeSimulate = "simulate"
+)
+
+// Longest a trigger waits for an execution to end, longer waits are cut short
+const MaxWait = 5 * time.Minute
+
 var log *logger.Log
 
 type ITrigger interface {
@@ -34,20 +48,31 @@ func init() {
 }
 
 type TriggerHandler struct {
-	controller        decomposer_controller.IController
-	database          database.IController
-	ExecutionsChannel chan decomposer.ExecutionDetails
+	controller decomposer_controller.IController
+	database   database.IController
+	scheduler  scheduler.IScheduler
+	informer   informer.IExecutionInformer
+	time       timeUtil.ITime
 }
 
-func NewTriggerHandler(controller decomposer_controller.IController, database database.IController) *TriggerHandler {
+func NewTriggerHandler(controller decomposer_controller.IController,
+	database database.IController,
+	scheduler scheduler.IScheduler,
+	time timeUtil.ITime) *TriggerHandler {
 	instance := TriggerHandler{}
 	instance.controller = controller
 	instance.database = database
-	// Channel to get back execution details
-	instance.ExecutionsChannel = make(chan decomposer.ExecutionDetails)
+	instance.scheduler = scheduler
+	instance.time = time
 	return &instance
 }
 
+// Set where the status of an execution is looked up when a wait expires.
+// Without an informer such executions are reported as ongoing.
+func (handler *TriggerHandler) SetInformer(informer informer.IExecutionInformer) {
+	handler.informer = informer
+}
+
 // trigger
 //
 //	@Summary	trigger a playbook by id that is stored in SOARCA
@@ -57,9 +82,14 @@ func NewTriggerHandler(controller decomposer_controller.IController, database da
 //	@Accept			json
 //	@Produce		json
 //	@Param			id		path		string			true	"playbook ID"
+//	@Param			mode	query		string			false	"execution mode"	Enums(execute, simulate)
+//	@Param			wait	query		string			false	"wait for the execution to end, e.g. 30s"
 //	@Param			data	body		cacao.Variables	true	"playbook"
 //	@Success		200		{object}	api.Execution
+//	@Success		202		{object}	api.ExecutionResult
 //	@failure		400		{object}	api.Error
+//	@failure		422		{object}	api.Error
+//	@failure		503		{object}	api.Error
 //	@Router			/trigger/playbook/{id} [POST]
 func (handler *TriggerHandler) ExecuteById(context *gin.Context) {
 	log.Trace("received execute by ID")
@@ -98,9 +128,14 @@ func (handler *TriggerHandler) ExecuteById(context *gin.Context) {
 //	@Tags			trigger
 //	@Accept			json
 //	@Produce		json
+//	@Param			mode		query		string			false	"execution mode"	Enums(execute, simulate)
+//	@Param			wait		query		string			false	"wait for the execution to end, e.g. 30s"
 //	@Param			playbook	body		cacao.Playbook	true	"execute playbook by payload"
 //	@Success		200			{object}	api.Execution
+//	@Success		202			{object}	api.ExecutionResult
 //	@failure		400			{object}	api.Error
+//	@failure		422			{object}	api.Error
+//	@failure		503			{object}	api.Error
 //	@Router			/trigger/playbook [POST]
 func (handler *TriggerHandler) Execute(context *gin.Context) {
 	log.Trace("received execute with body")
@@ -120,37 +155,141 @@ func (handler *TriggerHandler) Execute(context *gin.Context) {
 			"POST /trigger/playbook", "")
 		return
 	}
+	if err := playbook.PlaybookVariables.Validate(); err != nil {
+		log.Error(err)
+		apiError.SendErrorResponse(context, http.StatusBadRequest,
+			fmt.Sprintf("Cannot execute. reason: %s", err),
+			"POST /trigger/playbook", "")
+		return
+	}
 
 	handler.executePlaybook(playbook, context)
 }
 
-func (handler *TriggerHandler) executePlaybook(playbook *cacao.Playbook, context *gin.Context) {
-	decomposer := handler.controller.NewDecomposer()
-	go decomposer.ExecuteAsync(*playbook, handler.ExecutionsChannel)
-	timer := time.NewTimer(time.Duration(3) * time.Second)
-	for {
-		select {
-		case <-timer.C:
-			log.Error("async execution timed out for playbook ", playbook.ID)
-
-			apiError.SendErrorResponse(context,
-				http.StatusRequestTimeout,
-				"async execution timed out for playbook "+playbook.ID,
-				"POST "+context.Request.URL.Path, "")
-			return
+func (handler *TriggerHandler) executePlaybook(playbook *cacao.Playbook, g *gin.Context) {
+	// Retired playbooks and playbooks that are not valid yet are refused
+	if err := execution.CheckPlaybookValidity(*playbook, handler.time.Now()); err != nil {
+		log.Warning(err)
+		apiError.SendErrorResponse(g, http.StatusUnprocessableEntity,
+			err.Error(),
+			"POST "+g.Request.URL.Path, "")
+		return
+	}
+	decomposer, err := handler.newDecomposer(g.Query("mode"))
+	if err == nil {
+		err = validateWait(g.Query("wait"))
+	}
+	if err != nil {
+		log.Error(err)
+		apiError.SendErrorResponse(g, http.StatusBadRequest,
+			err.Error(),
+			"POST "+g.Request.URL.Path, "")
+		return
+	}
+	executionId, result, err := handler.scheduler.Schedule(decomposer, *playbook)
+	if err != nil {
+		log.Error(err)
+		status := http.StatusBadRequest
+		if errors.As(err, &execution.ErrorQueueFull{}) {
+			status = http.StatusServiceUnavailable
+		}
+		apiError.SendErrorResponse(g, status,
+			err.Error(),
+			"POST "+g.Request.URL.Path, "")
+		return
+	}
+	if g.Query("wait") != "" {
+		handler.awaitExecution(executionId, playbook.ID, result, g)
+		return
+	}
+	g.JSON(http.StatusOK,
+		api.Execution{
+			ExecutionId: executionId,
+			PlaybookId:  playbook.ID,
+		})
+}
+
+// Respond with the outcome of the execution once it has ended. When the wait
+// expires first, 202 is returned and the execution continues.
+func (handler *TriggerHandler) awaitExecution(executionId uuid.UUID,
+	playbookId string,
+	result <-chan scheduler.Result,
+	g *gin.Context) {
+	wait, _ := time.ParseDuration(g.Query("wait"))
+	expired := handler.time.After(min(wait, MaxWait))
 
-		case executionsDetail := <-handler.ExecutionsChannel:
-			playbookId := executionsDetail.PlaybookId
-			executionId := executionsDetail.ExecutionId
-			if playbookId == playbook.ID {
-				context.JSON(http.StatusOK,
-					api.Execution{
-						ExecutionId: executionId,
-						PlaybookId:  playbookId,
-					})
-				return
-			}
+	select {
+	case <-g.Request.Context().Done():
+		log.Debug("client stopped waiting for execution ", executionId)
+	case <-expired:
+		g.JSON(http.StatusAccepted,
+			api.ExecutionResult{
+				ExecutionId: executionId,
+				PlaybookId:  playbookId,
+				Status:      handler.currentStatus(executionId),
+			})
+	case ended := <-result:
+		response := api.ExecutionResult{
+			ExecutionId: executionId,
+			PlaybookId:  playbookId,
+			Status:      executionStatus(ended.Err),
+			Variables:   ended.Details.Variables,
 		}
+		if ended.Err != nil {
+			response.Error = ended.Err.Error()
+		}
+		g.JSON(http.StatusOK, response)
+	}
+}
+
+// Status of an execution that has not ended yet, it may still be queued
+func (handler *TriggerHandler) currentStatus(executionId uuid.UUID) string {
+	if handler.informer == nil {
+		return api.Ongoing
+	}
+	entry, err := handler.informer.GetExecutionReport(executionId)
+	if err != nil {
+		log.Warning(err)
+		return api.Ongoing
+	}
+	return api.CacheStatusEnum2String(entry.Status)
+}
+
+func validateWait(wait string) error {
+	if wait == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(wait)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("invalid wait duration %s", wait)
+	}
+	return nil
+}
+
+// Status of an ended execution, as reported by the reporter API
+func executionStatus(err error) string {
+	switch {
+	case err == nil:
+		return api.SuccessfullyExecuted
+	case errors.Is(err, context.Canceled):
+		return api.Cancelled
+	case errors.As(err, &execution.ErrorExceptionCondition{}):
+		return api.ExceptionConditionError
+	default:
+		return api.Failed
+	}
+}
+
+// A simulation runs the full decomposer, with every capability replaced by a simulator
+func (handler *TriggerHandler) newDecomposer(mode string) (decomposer.IDecomposer, error) {
+	switch mode {
+	case "", ModeExecute:
+		return handler.controller.NewDecomposer(), nil
+	case ModeSimulate:
+		log.Info("simulating playbook execution")
+		return handler.controller.NewSimulationDecomposer(), nil
+	default:
+		return nil, fmt.Errorf("unknown execution mode %s", mode)
 	}
 }
 
@@ -186,6 +325,10 @@ func MergeVariablesInPlaybook(playbook *cacao.Playbook, body []byte) error {
 			Constant:    playbook.PlaybookVariables[name].Constant,
 			External:    playbook.PlaybookVariables[name].External,
 		}
+		// Values are checked here, so bad input does not fail deep inside a condition
+		if err := updatedVariable.Validate(); err != nil {
+			return err
+		}
 		playbook.PlaybookVariables[name] = updatedVariable
 	}
 	return nil
@@ -1,6 +1,7 @@
 package capability
 
 import (
+	"context"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 )
@@ -13,8 +14,11 @@ type Context struct {
 	Variables      cacao.Variables
 }
 
+// Capabilities must abort their work and return when ctx is done. The action
+// executor sets the deadline of ctx from the step timeout.
 type ICapability interface {
-	Execute(metadata execution.Metadata,
-		context Context) (cacao.Variables, error)
+	Execute(ctx context.Context,
+		metadata execution.Metadata,
+		capabilityContext Context) (cacao.Variables, error)
 	GetType() string
 }
@@ -1,6 +1,8 @@
 package fin
 
 import (
+	"context"
+	"math"
 	"reflect"
 	"soarca/internal/logger"
 	"soarca/pkg/core/capability"
@@ -8,6 +10,7 @@ import (
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 	finModel "soarca/pkg/models/fin"
+	"time"
 )
 
 type FinCapability struct {
@@ -30,17 +33,24 @@ func (FinCapability *FinCapability) GetType() string {
 }
 
 func (finCapability *FinCapability) Execute(
+	ctx context.Context,
 	metadata execution.Metadata,
-	context capability.Context) (cacao.Variables, error) {
+	capabilityContext capability.Context) (cacao.Variables, error) {
 
 	finCommand := finModel.NewCommand()
-	finCommand.CommandSubstructure.Command = context.Command.Command
-	finCommand.CommandSubstructure.Authentication = context.Authentication
-	finCommand.CommandSubstructure.Variables = context.Variables
+	finCommand.CommandSubstructure.Command = capabilityContext.Command.Command
+	finCommand.CommandSubstructure.Authentication = capabilityContext.Authentication
+	finCommand.CommandSubstructure.Variables = capabilityContext.Variables
 	finCommand.CommandSubstructure.Context.ExecutionId = metadata.ExecutionId.String()
 	finCommand.CommandSubstructure.Context.PlaybookId = metadata.PlaybookId
 	finCommand.CommandSubstructure.Context.StepId = metadata.StepId
 
+	// Let the fin know how long it has, the timeout is expressed in whole seconds
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := math.Ceil(time.Until(deadline).Seconds())
+		finCommand.CommandSubstructure.Context.Timeout = int(math.Max(remaining, 1))
+	}
+
 	log.Trace("created command ", finCommand)
-	return finCapability.finProtocol.SendCommand(finCommand)
+	return finCapability.finProtocol.SendCommand(ctx, finCommand)
 }
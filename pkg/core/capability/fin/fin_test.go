@@ -1,6 +1,7 @@
 package fin
 
 import (
+	"context"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestFinExecution(t *testing.T) {
@@ -59,8 +61,8 @@ func TestFinExecution(t *testing.T) {
 	}
 
 	//mockGuid.On("New").Return(id)
-	mockFinProtocol.On("SendCommand", expectedCommand).Return(expectedVariableMap, nil)
-	result, err := finCapability.Execute(metadata, data)
+	mockFinProtocol.On("SendCommand", mock.Anything, expectedCommand).Return(expectedVariableMap, nil)
+	result, err := finCapability.Execute(context.Background(), metadata, data)
 
 	assert.Equal(t, err, nil)
 	assert.Equal(t, result, expectedVariableMap)
@@ -1,9 +1,11 @@
 package protocol
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"soarca/pkg/core/execution_manager"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/fin"
 	"soarca/pkg/utils/guid"
@@ -12,6 +14,7 @@ import (
 	"time"
 
 	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -42,7 +45,7 @@ func TestTimeoutAndCallbackTimerElaspsed(t *testing.T) {
 	expectedCommand := fin.NewCommand()
 	expectedCommand.CommandSubstructure.Context.Timeout = 1
 
-	result, err := prot.AwaitResultOrTimeout(expectedCommand, &mock_client)
+	result, err := prot.AwaitResultOrTimeout(context.Background(), expectedCommand, &mock_client)
 
 	assert.Equal(t, err, errors.New("no message received from fin while it was expected"))
 	assert.Equal(t, result, cacao.NewVariables())
@@ -70,7 +73,7 @@ func TestTimeoutAndCallbackHandlerCalled(t *testing.T) {
 
 	fmt.Println("calling await")
 	go helper(&prot)
-	result, err := prot.AwaitResultOrTimeout(expectedCommand, &mock_client)
+	result, err := prot.AwaitResultOrTimeout(context.Background(), expectedCommand, &mock_client)
 	fmt.Println("done waiting")
 
 	assert.Equal(t, err, nil)
@@ -118,3 +121,51 @@ func helper(prot *FinProtocol) {
 	fmt.Println("called handler")
 
 }
+
+func TestPauseAndResumeForwardedToFin(t *testing.T) {
+	mock_client := mock_mqtt.Mock_MqttClient{}
+	mock_token := mock_mqtt.Mock_MqttToken{}
+	mock_token_publish := mock_mqtt.Mock_MqttToken{}
+
+	guid := new(guid.Guid)
+	prot := New(guid, "testing", "localhost", 1883)
+	mock_token.On("Wait").Return(true)
+	mock_client.On("Subscribe", "testing", uint8(1), mock.Anything).Return(&mock_token)
+	prot.Subscribe(&mock_client)
+
+	isControl := func(messageType string) func(payload []byte) bool {
+		return func(payload []byte) bool {
+			control := fin.Control{}
+			err := fin.Decode(payload, &control)
+			return err == nil && control.Type == messageType && control.CapabilityId == "testing"
+		}
+	}
+	mock_token_publish.On("Wait").Return(true)
+	mock_client.On("Publish", "testing", uint8(1), false, mock.MatchedBy(isControl(fin.MessageTypePause))).
+		Return(&mock_token_publish).Once()
+	mock_client.On("Publish", "testing", uint8(1), false, mock.MatchedBy(isControl(fin.MessageTypeResume))).
+		Return(&mock_token_publish).Once()
+	mock_client.On("Publish", "testing", uint8(1), false, mock.Anything).Return(&mock_token_publish)
+
+	manager := execution_manager.New()
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = manager.Register(ctx, executionId, cancel)
+	assert.Equal(t, manager.Pause(executionId), nil)
+
+	expectedCommand := fin.NewCommand()
+	expectedCommand.CommandSubstructure.Context.Timeout = 1
+
+	// The timeout does not elapse while the execution is paused
+	go func() {
+		time.Sleep(1500 * time.Millisecond)
+		_ = manager.Resume(executionId)
+		helper(&prot)
+	}()
+	result, err := prot.AwaitResultOrTimeout(ctx, expectedCommand, &mock_client)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, cacao.NewVariables(cacao.Variable{Name: "test"}))
+	mock_client.AssertExpectations(t)
+}
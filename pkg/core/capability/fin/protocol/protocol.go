@@ -1,10 +1,12 @@
 package protocol
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"soarca/internal/logger"
+	"soarca/pkg/core/execution_manager"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/fin"
 	"soarca/pkg/utils/guid"
@@ -38,7 +40,7 @@ func init() {
 }
 
 type IFinProtocol interface {
-	SendCommand(fin.Command) (cacao.Variables, error)
+	SendCommand(context.Context, fin.Command) (cacao.Variables, error)
 }
 
 type FinProtocol struct {
@@ -71,7 +73,18 @@ func (protocol *FinProtocol) SendNack(result fin.Result, client mqttlib.Client)
 	token.Wait()
 }
 
-func (protocol *FinProtocol) SendCommand(command fin.Command) (cacao.Variables, error) {
+// Send a pause, resume or stop message to the fin executing the current command
+func (protocol *FinProtocol) SendControl(messageType string, client mqttlib.Client) {
+	control := fin.Control{Type: messageType,
+		MessageId:    protocol.Guid.New().String(),
+		CapabilityId: string(protocol.Topic)}
+	json, _ := fin.Encode(control)
+	log.Trace("Sending ", messageType, " to fin: ", protocol.Topic)
+	token := client.Publish(string(protocol.Topic), defaultQos, false, json)
+	token.Wait()
+}
+
+func (protocol *FinProtocol) SendCommand(ctx context.Context, command fin.Command) (cacao.Variables, error) {
 
 	client, err := protocol.Connect(command.CommandSubstructure.Authentication)
 	if err != nil {
@@ -85,29 +98,63 @@ func (protocol *FinProtocol) SendCommand(command fin.Command) (cacao.Variables,
 		protocol.Disconnect(client)
 		return cacao.NewVariables(), err
 	}
-	result, err := protocol.AwaitResultOrTimeout(command, client)
+	result, err := protocol.AwaitResultOrTimeout(ctx, command, client)
 	protocol.Disconnect(client)
 
 	return result, err
 }
 
-func (protocol *FinProtocol) AwaitResultOrTimeout(command fin.Command, client mqttlib.Client) (cacao.Variables, error) {
+func (protocol *FinProtocol) AwaitResultOrTimeout(ctx context.Context, command fin.Command, client mqttlib.Client) (cacao.Variables, error) {
 	timeout := command.CommandSubstructure.Context.Timeout
 
 	if command.CommandSubstructure.Context.Timeout == 0 {
 		log.Warning("no valid timeout will set 1 second")
 		timeout = defaultTimeout
 	}
-	timer := time.NewTimer(time.Duration(timeout) * time.Second)
+	remaining := time.Duration(timeout) * time.Second
+	deadline := time.Now().Add(remaining)
+	timer := time.NewTimer(remaining)
+
+	// Pausing the execution is forwarded to the fin, the timeout does not
+	// elapse while paused
+	var stateChanged <-chan struct{}
+	paused := false
+	state, hasState := execution_manager.StateFromContext(ctx)
+	if hasState {
+		paused, stateChanged = state.Paused()
+		if paused {
+			timer.Stop()
+			protocol.SendControl(fin.MessageTypePause, client)
+		}
+	}
 
 	// Wait in a loop for the timer to elapse or a message on the channel
 	ackReceived := false
 
 	for {
 		select {
+		case <-stateChanged:
+			var isPaused bool
+			isPaused, stateChanged = state.Paused()
+			if isPaused == paused {
+				break
+			}
+			paused = isPaused
+			if paused {
+				timer.Stop()
+				remaining = time.Until(deadline)
+				protocol.SendControl(fin.MessageTypePause, client)
+			} else {
+				deadline = time.Now().Add(remaining)
+				timer.Reset(remaining)
+				protocol.SendControl(fin.MessageTypeResume, client)
+			}
 		case <-timer.C:
 			err := errors.New("no message received from fin while it was expected")
 			return cacao.NewVariables(), err
+		case <-ctx.Done():
+			log.Error("fin command aborted: ", ctx.Err())
+			return cacao.NewVariables(), ctx.Err()
 		case result := <-protocol.channel:
 			finMessage := fin.Message{}
 			err := fin.Decode(result, &finMessage)
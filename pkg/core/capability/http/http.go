@@ -1,12 +1,16 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	gohttp "net/http"
 	"reflect"
 	"soarca/internal/logger"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 	"soarca/pkg/utils/http"
+	"strconv"
 )
 
 // Receive HTTP API command data from decomposer/executer
@@ -19,6 +23,17 @@ const (
 	httpApiCapabilityName     = "soarca-http-api"
 )
 
+// The status code and the headers of the response, headers are keyed by
+// their canonical name and hold all their values
+const (
+	HttpApiStatusVariableName  = "__soarca_http_api_status__"
+	HttpApiHeadersVariableName = "__soarca_http_api_headers__"
+)
+
+// Response headers that carry credentials are never copied into the headers
+// variable, it is passed on to reporters and the execution journal
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
 type HttpCapability struct {
 	soarca_http_request http.IHttpRequest
 }
@@ -41,25 +56,45 @@ func (httpCapability *HttpCapability) GetType() string {
 }
 
 func (httpCapability *HttpCapability) Execute(
+	ctx context.Context,
 	metadata execution.Metadata,
-	context capability.Context) (cacao.Variables, error) {
+	capabilityContext capability.Context) (cacao.Variables, error) {
 
 	soarca_http_options := http.HttpOptions{
-		Target:  &context.Target,
-		Command: &context.Command,
-		Auth:    &context.Authentication,
+		Target:  &capabilityContext.Target,
+		Command: &capabilityContext.Command,
+		Auth:    &capabilityContext.Authentication,
 	}
 
-	responseBytes, err := httpCapability.soarca_http_request.Request(soarca_http_options)
+	response, err := httpCapability.soarca_http_request.RequestResponse(ctx, soarca_http_options)
 	if err != nil {
 		log.Error(err)
 		return cacao.NewVariables(), err
 	}
-	respString := string(responseBytes)
+	respString := string(response.Body)
 	variable := cacao.Variable{Type: cacao.VariableTypeString,
 		Name:  httpApiResultVariableName,
 		Value: respString}
+	status := cacao.Variable{Type: cacao.VariableTypeInt,
+		Name:  HttpApiStatusVariableName,
+		Value: strconv.Itoa(response.StatusCode)}
+
+	header := gohttp.Header{}
+	for key, values := range response.Header {
+		header[gohttp.CanonicalHeaderKey(key)] = values
+	}
+	for _, key := range sensitiveHeaders {
+		header.Del(key)
+	}
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		log.Error(err)
+		return cacao.NewVariables(), err
+	}
+	headers := cacao.Variable{Type: cacao.VariableTypeDictionary,
+		Name:  HttpApiHeadersVariableName,
+		Value: string(headerBytes)}
 
-	return cacao.NewVariables(variable), nil
+	return cacao.NewVariables(variable, status, headers), nil
 
 }
@@ -4,6 +4,7 @@ package http
 // test correct parsing of HttpOptions fields and errors handling
 
 import (
+	"context"
 	"errors"
 
 	"soarca/pkg/core/capability"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestHTTPOptionsCorrectlyGenerated(t *testing.T) {
@@ -55,7 +57,10 @@ func TestHTTPOptionsCorrectlyGenerated(t *testing.T) {
 
 	payload := "payload test"
 	payload_byte := []byte(payload)
-	mock_http_request.On("Request", httpOptions).Return(payload_byte, nil)
+	response := http_request.Response{StatusCode: 201,
+		Header: map[string][]string{"Location": {"/cases/42"}, "Set-Cookie": {"session=secret"}},
+		Body:   payload_byte}
+	mock_http_request.On("RequestResponse", mock.Anything, httpOptions).Return(response, nil)
 
 	data := capability.Context{
 		Command:        command,
@@ -65,6 +70,7 @@ func TestHTTPOptionsCorrectlyGenerated(t *testing.T) {
 	}
 
 	results, err := httpCapability.Execute(
+		context.Background(),
 		metadata,
 		data)
 	if err != nil {
@@ -72,6 +78,8 @@ func TestHTTPOptionsCorrectlyGenerated(t *testing.T) {
 		t.Fail()
 	}
 	assert.Equal(t, results["__soarca_http_api_result__"].Value, payload)
+	assert.Equal(t, results["__soarca_http_api_status__"].Value, "201")
+	assert.Equal(t, results["__soarca_http_api_headers__"].Value, `{"Location":["/cases/42"]}`)
 	t.Log(results)
 
 	mock_http_request.AssertExpectations(t)
@@ -110,7 +118,7 @@ func TestHTTPOptionsEmptyAuth(t *testing.T) {
 
 	payload := "payload test"
 	payload_byte := []byte(payload)
-	mock_http_request.On("Request", httpOptions).Return(payload_byte, nil)
+	mock_http_request.On("RequestResponse", mock.Anything, httpOptions).Return(http_request.Response{StatusCode: 200, Body: payload_byte}, nil)
 
 	data := capability.Context{
 		Command:        command,
@@ -120,6 +128,7 @@ func TestHTTPOptionsEmptyAuth(t *testing.T) {
 	}
 
 	results, err := httpCapability.Execute(
+		context.Background(),
 		metadata,
 		data)
 	if err != nil {
@@ -165,7 +174,7 @@ func TestHTTPOptionsEmptyCommand(t *testing.T) {
 	}
 
 	expected_error := errors.New("command pointer is empty")
-	mock_http_request.On("Request", httpOptions).Return([]byte{}, expected_error)
+	mock_http_request.On("RequestResponse", mock.Anything, httpOptions).Return(http_request.Response{Body: []byte{}}, expected_error)
 
 	data := capability.Context{
 		Command:        *empty_command,
@@ -175,6 +184,7 @@ func TestHTTPOptionsEmptyCommand(t *testing.T) {
 	}
 
 	results, err := httpCapability.Execute(
+		context.Background(),
 		metadata,
 		data)
 	if err == nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"soarca/internal/logger"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 	"soarca/pkg/models/manual"
@@ -62,13 +63,13 @@ func (manualController *InteractionController) Queue(command manual.CommandInfo,
 		return errors.New("manual command does not have a deadline")
 	}
 
-	// Copy and type conversion
-	integrationCommand := manual.InteractionIntegrationCommand(command)
-
 	// One response channel for all integrations
 	integrationChannel := make(chan manual.InteractionResponse)
 
+	// The timeout context derives from the execution, so it carries its markings
+	policy := marking.PolicyFrom(manualComms.TimeoutContext)
 	for _, notifier := range manualController.Notifiers {
+		integrationCommand := redactCommand(command, policy, marking.MaxMarkingOf(notifier))
 		go notifier.Notify(integrationCommand, integrationChannel)
 	}
 
@@ -78,6 +79,16 @@ func (manualController *InteractionController) Queue(command manual.CommandInfo,
 	return nil
 }
 
+// Copy and type conversion, with the variables redacted to the marking the
+// integration may receive
+func redactCommand(command manual.CommandInfo, policy marking.Policy, maxMarking marking.Level) manual.InteractionIntegrationCommand {
+	integrationCommand := manual.InteractionIntegrationCommand(command)
+	integrationCommand.Context.Step = policy.RedactStep(command.Context.Step, maxMarking)
+	integrationCommand.Context.Variables = policy.Redact(command.Context.Variables, maxMarking)
+	integrationCommand.OutArgsVariables = policy.Redact(command.OutArgsVariables, maxMarking)
+	return integrationCommand
+}
+
 func (manualController *InteractionController) handleManualCommandResponse(command manual.CommandInfo, manualComms manual.ManualCapabilityCommunication) {
 	log.Trace(
 		fmt.Sprintf(
@@ -91,7 +102,7 @@ func (manualController *InteractionController) handleManualCommandResponse(comma
 	if manualComms.TimeoutContext.Err() == context.DeadlineExceeded {
 		log.Info("manual command timed out. deregistering associated pending command")
 	} else if manualComms.TimeoutContext.Err() == context.Canceled {
-		log.Info("manual command completed. deregistering associated pending command")
+		log.Info("manual command completed or execution cancelled. deregistering associated pending command")
 	}
 	err := manualController.removeInteractionFromPending(command.Metadata)
 	if err != nil {
@@ -258,6 +269,12 @@ func (manualController *InteractionController) validateMatchingOutArgs(pendingEn
 			if variable.Type != pending.Type {
 				warns = append(warns, fmt.Sprintf("provided out arg %s has different value for 'Type' property of intended out arg. This different value is ignored.", varName))
 			}
+			// The value must match the type of the intended out arg
+			expected := pending
+			expected.Value = variable.Value
+			if err := expected.Validate(); err != nil {
+				return warns, err
+			}
 		}
 	}
 	return warns, err
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"reflect"
 	"soarca/pkg/core/capability"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 	manualModel "soarca/pkg/models/manual"
@@ -359,6 +360,33 @@ func TestPostContinueFailOnNonexistingVariable(t *testing.T) {
 	assert.Equal(t, err, expectedErr)
 }
 
+func TestPostContinueFailOnInvalidValue(t *testing.T) {
+	interaction := New([]IInteractionIntegrationNotifier{})
+	testCtx, testCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer testCancel()
+
+	testCapComms := manualModel.ManualCapabilityCommunication{
+		Channel:        make(chan manualModel.InteractionResponse),
+		TimeoutContext: testCtx,
+	}
+	defer close(testCapComms.Channel)
+
+	command := testInteractionCommand
+	command.OutArgsVariables = cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeInt, Name: "var2"})
+	err := interaction.Queue(command, testCapComms)
+	assert.Equal(t, err, nil)
+
+	// The type of the intended out arg is used, whatever type is provided
+	outArg := cacao.Variable{Type: cacao.VariableTypeString, Name: "var2", Value: "twelve"}
+	err = interaction.PostContinue(manualModel.InteractionResponse{
+		Metadata:         testMetadata,
+		ResponseStatus:   "success",
+		OutArgsVariables: cacao.NewVariables(outArg),
+	})
+
+	assert.Equal(t, err, cacao.ErrorVariableValue{Name: "var2", Type: cacao.VariableTypeInt, Reason: "invalid syntax"})
+}
+
 func TestRegisterRetrieveNewExecutionNewPendingInteraction(t *testing.T) {
 	interaction := New([]IInteractionIntegrationNotifier{})
 	testChan := make(chan manualModel.InteractionResponse)
@@ -520,6 +548,30 @@ func TestRemovePendingInteraciton(t *testing.T) {
 	assert.Equal(t, err, expectedErr)
 }
 
+func TestQueueRedactsCommandForNotifiers(t *testing.T) {
+	restricted := &capturingNotifier{commands: make(chan manualModel.InteractionIntegrationCommand, 1)}
+	unrestricted := &capturingNotifier{commands: make(chan manualModel.InteractionIntegrationCommand, 1), maxMarking: marking.Red}
+	interaction := New([]IInteractionIntegrationNotifier{restricted, unrestricted})
+
+	policy := marking.Policy{Level: marking.Amber}
+	testCtx, testCancel := context.WithTimeout(marking.WithPolicy(context.Background(), policy), 10*time.Millisecond)
+	defer testCancel()
+
+	err := interaction.Queue(testInteractionCommand, manualModel.ManualCapabilityCommunication{
+		Channel:        make(chan manualModel.InteractionResponse),
+		TimeoutContext: testCtx,
+	})
+	assert.Equal(t, err, nil)
+
+	redacted := <-restricted.commands
+	assert.Equal(t, redacted.Context.Variables["var2"].Value, marking.RedactedValue)
+	assert.Equal(t, redacted.Context.Step.StepVariables["var1"].Value, marking.RedactedValue)
+	assert.Equal(t, redacted.Metadata, testInteractionCommand.Metadata)
+	assert.Equal(t, testInteractionCommand.Context.Variables["var2"].Value, "test_value_2")
+
+	assert.Equal(t, <-unrestricted.commands, manualModel.InteractionIntegrationCommand(testInteractionCommand))
+}
+
 // ############################################################################
 // Utils
 // ############################################################################
@@ -541,6 +593,19 @@ func NewTestLogHook() *TestHook {
 	return &TestHook{}
 }
 
+type capturingNotifier struct {
+	commands   chan manualModel.InteractionIntegrationCommand
+	maxMarking marking.Level
+}
+
+func (notifier *capturingNotifier) Notify(command manualModel.InteractionIntegrationCommand, channel chan manualModel.InteractionResponse) {
+	notifier.commands <- command
+}
+
+func (notifier *capturingNotifier) MaxMarking() marking.Level {
+	return notifier.maxMarking
+}
+
 var testUUIDStr string = "61a6c41e-6efc-4516-a242-dfbc5c89d562"
 var testMetadata = execution.Metadata{
 	ExecutionId: uuid.MustParse(testUUIDStr),
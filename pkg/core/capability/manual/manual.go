@@ -2,7 +2,7 @@ package manual
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"reflect"
 	"soarca/internal/logger"
 	"soarca/pkg/core/capability"
@@ -41,6 +41,7 @@ func (manual *ManualCapability) GetType() string {
 }
 
 func (manual *ManualCapability) Execute(
+	ctx context.Context,
 	metadata execution.Metadata,
 	commandContext capability.Context) (cacao.Variables, error) {
 
@@ -50,9 +51,10 @@ func (manual *ManualCapability) Execute(
 		OutArgsVariables: commandContext.Variables.Select(commandContext.Step.OutArgs),
 	}
 
+	// The step deadline on ctx takes precedence when it is earlier than the timeout
 	timeout := manual.getTimeoutValue(commandContext.Step.Timeout)
 	log.Trace("timeout is set to: ", timeout)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// One channel per Execute() invocation. Async manual capability Execute() invocations can thus
@@ -82,7 +84,7 @@ func (manual *ManualCapability) awaitUserInput(channel chan manualModel.Interact
 	for {
 		select {
 		case <-ctx.Done():
-			err := errors.New("manual response timed-out, no response received on time")
+			err := fmt.Errorf("manual response timed-out, no response received on time: %w", ctx.Err())
 			log.Error(err)
 			return cacao.NewVariables(), err
 		case response := <-channel:
@@ -1,6 +1,7 @@
 package manual
 
 import (
+	"context"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
@@ -41,7 +42,7 @@ func TestManualExecution(t *testing.T) {
 
 	go func() {
 		defer wg.Done()
-		vars, err := manual.Execute(meta, commandContext)
+		vars, err := manual.Execute(context.Background(), meta, commandContext)
 		assert.Equal(t, err, nil)
 		assert.NotEqual(t, vars, nil)
 	}()
@@ -1,6 +1,7 @@
 package openc2
 
 import (
+	"context"
 	"reflect"
 
 	"soarca/internal/logger"
@@ -39,17 +40,18 @@ func (OpenC2Capability *OpenC2Capability) GetType() string {
 }
 
 func (OpenC2Capability *OpenC2Capability) Execute(
+	ctx context.Context,
 	metadata execution.Metadata,
-	context capability.Context,
+	capabilityContext capability.Context,
 ) (cacao.Variables, error) {
 	log.Trace(metadata.ExecutionId)
 
 	httpOptions := http.HttpOptions{
-		Command: &context.Command,
-		Target:  &context.Target,
-		Auth:    &context.Authentication,
+		Command: &capabilityContext.Command,
+		Target:  &capabilityContext.Target,
+		Auth:    &capabilityContext.Authentication,
 	}
-	response, err := OpenC2Capability.httpRequest.Request(httpOptions)
+	response, err := OpenC2Capability.httpRequest.Request(ctx, httpOptions)
 	if err != nil {
 		log.Error(err)
 		return cacao.NewVariables(), err
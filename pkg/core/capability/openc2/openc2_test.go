@@ -1,6 +1,7 @@
 package openc2
 
 import (
+	"context"
 	"testing"
 
 	"soarca/pkg/core/capability"
@@ -11,6 +12,7 @@ import (
 
 	assert "github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestOpenC2Request(t *testing.T) {
@@ -63,7 +65,7 @@ func TestOpenC2Request(t *testing.T) {
 
 	payloadBytes := []byte(payload)
 
-	mockHttp.On("Request", httpOptions).Return(payloadBytes, nil)
+	mockHttp.On("Request", mock.Anything, httpOptions).Return(payloadBytes, nil)
 
 	data := capability.Context{Command: command,
 		Authentication: auth,
@@ -71,6 +73,7 @@ func TestOpenC2Request(t *testing.T) {
 		Variables:      cacao.NewVariables(cacaoVariable)}
 
 	results, err := openc2.Execute(
+		context.Background(),
 		metadata,
 		data)
 	if err != nil {
@@ -2,7 +2,6 @@ package powershell
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"reflect"
 	"strconv"
@@ -45,6 +44,7 @@ func (capability *PowershellCapability) GetType() string {
 }
 
 func (capability *PowershellCapability) Execute(
+	ctx context.Context,
 	metadata execution.Metadata,
 	capabilityContext capability.Context,
 ) (cacao.Variables, error) {
@@ -69,21 +69,8 @@ func (capability *PowershellCapability) Execute(
 		return cacao.NewVariables(), err
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	effectiveCommand := ""
-	if capabilityContext.Command.CommandB64 != "" {
-		bytes, err := base64.StdEncoding.DecodeString(capabilityContext.Command.CommandB64)
-		if err != nil {
-			return cacao.NewVariables(), err
-		}
-		effectiveCommand = string(bytes)
-	} else {
-		effectiveCommand = capabilityContext.Command.Command
-	}
-
-	result, stdErr, _, err := client.RunPSWithContext(ctx, effectiveCommand)
+	// command_b64 is decoded by the action executor
+	result, stdErr, _, err := client.RunPSWithContext(ctx, capabilityContext.Command.Command)
 	if err != nil {
 		log.Error("failed to complete command")
 		if strings.Contains(err.Error(), "401") {
@@ -0,0 +1,109 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"soarca/internal/logger"
+	"soarca/pkg/core/capability"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+	"time"
+)
+
+var (
+	component = reflect.TypeOf(Simulator{}).PkgPath()
+	log       *logger.Log
+)
+
+func init() {
+	log = logger.Logger(component, logger.Info, "", logger.Json)
+}
+
+const (
+	// Step extension holding the mock output of a step in simulations (not part of CACAO spec)
+	SimulationExtensionId = "extension-definition--3e6b2f4a-9d1c-4b8e-a5f7-6c2d8e1b0a94"
+
+	simulatorResultVariableName = "__soarca_simulator_result__"
+)
+
+// Mock output of a step, declared in the playbook
+type MockOutput struct {
+	Variables cacao.Variables `json:"variables,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	// Simulated duration of the command in milliseconds
+	Delay int `json:"delay,omitempty"`
+}
+
+// Takes the place of a capability when a playbook is simulated. Nothing is
+// executed, the step returns its mock output or the defaults of its out_args.
+type Simulator struct {
+	capabilityType string
+}
+
+func New(capabilityType string) *Simulator {
+	return &Simulator{capabilityType: capabilityType}
+}
+
+func (simulator *Simulator) GetType() string {
+	return simulator.capabilityType
+}
+
+func (simulator *Simulator) Execute(ctx context.Context,
+	metadata execution.Metadata,
+	capabilityContext capability.Context) (cacao.Variables, error) {
+	log.Trace(fmt.Sprintf("simulating %s command of step %s", simulator.capabilityType, capabilityContext.Step.ID))
+
+	mock, found, err := mockOutput(capabilityContext.Step)
+	if err != nil {
+		log.Error(err)
+		return cacao.NewVariables(), err
+	}
+
+	if found && mock.Delay > 0 {
+		timer := time.NewTimer(time.Duration(mock.Delay) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return cacao.NewVariables(), ctx.Err()
+		case <-timer.C:
+		}
+	} else if ctx.Err() != nil {
+		return cacao.NewVariables(), ctx.Err()
+	}
+
+	if found {
+		variables := cacao.NewVariables()
+		for name, variable := range mock.Variables {
+			variable.Name = name
+			variables.InsertOrReplace(variable)
+		}
+		if mock.Error != "" {
+			return variables, errors.New(mock.Error)
+		}
+		return variables, nil
+	}
+
+	// Without mock output, out_args keep their current value as if a manual
+	// step was completed with the defaults
+	variables := capabilityContext.Variables.Select(capabilityContext.Step.OutArgs)
+	variables.InsertOrReplace(cacao.Variable{
+		Type:  cacao.VariableTypeString,
+		Name:  simulatorResultVariableName,
+		Value: capabilityContext.Command.Command,
+	})
+	return variables, nil
+}
+
+func mockOutput(step cacao.Step) (MockOutput, bool, error) {
+	mock := MockOutput{}
+	found, err := cacao.DecodeExtension(step.StepExtensions, SimulationExtensionId, &mock)
+	if !found {
+		return MockOutput{}, false, nil
+	}
+	if err != nil {
+		return MockOutput{}, false, fmt.Errorf("invalid mock output of step [ %s ]: %w", step.ID, err)
+	}
+	return mock, true, nil
+}
@@ -0,0 +1,70 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"soarca/pkg/core/capability"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+)
+
+func TestSimulateWithOutArgsDefaults(t *testing.T) {
+	simulator := New("soarca-manual")
+	assert.Equal(t, simulator.GetType(), "soarca-manual")
+
+	approved := cacao.Variable{Type: cacao.VariableTypeBool, Name: "__approved__", Value: "false"}
+	other := cacao.Variable{Type: cacao.VariableTypeString, Name: "__other__", Value: "value"}
+	capabilityContext := capability.Context{
+		Command:   cacao.Command{Type: cacao.CommandTypeManual, Command: "approve the block"},
+		Step:      cacao.Step{ID: "action--1", OutArgs: []string{"__approved__"}},
+		Variables: cacao.NewVariables(approved, other),
+	}
+
+	metadata := execution.Metadata{ExecutionId: uuid.New()}
+	result, err := simulator.Execute(context.Background(), metadata, capabilityContext)
+
+	expected := cacao.NewVariables(approved, cacao.Variable{
+		Type:  cacao.VariableTypeString,
+		Name:  "__soarca_simulator_result__",
+		Value: "approve the block",
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, expected)
+}
+
+func TestSimulateWithMockOutput(t *testing.T) {
+	simulator := New("http-api")
+
+	step := cacao.Step{ID: "action--1",
+		StepExtensions: cacao.Extensions{SimulationExtensionId: map[string]interface{}{
+			"variables": map[string]interface{}{
+				"__status__": map[string]interface{}{"type": "string", "value": "500"},
+			},
+			"error": "internal server error",
+		}}}
+	capabilityContext := capability.Context{Step: step, Variables: cacao.NewVariables()}
+
+	metadata := execution.Metadata{ExecutionId: uuid.New()}
+	result, err := simulator.Execute(context.Background(), metadata, capabilityContext)
+
+	expected := cacao.NewVariables(cacao.Variable{Type: "string", Name: "__status__", Value: "500"})
+	assert.Equal(t, err, errors.New("internal server error"))
+	assert.Equal(t, result, expected)
+}
+
+func TestSimulateCancelled(t *testing.T) {
+	simulator := New("ssh")
+
+	step := cacao.Step{ID: "action--1",
+		StepExtensions: cacao.Extensions{SimulationExtensionId: map[string]interface{}{"delay": 60000}}}
+	capabilityContext := capability.Context{Step: step, Variables: cacao.NewVariables()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := simulator.Execute(ctx, execution.Metadata{ExecutionId: uuid.New()}, capabilityContext)
+	assert.Equal(t, errors.Is(err, context.Canceled), true)
+}
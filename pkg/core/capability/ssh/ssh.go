@@ -1,7 +1,9 @@
 package ssh
 
 import (
+	"context"
 	"errors"
+	"net"
 	"reflect"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/models/cacao"
@@ -17,6 +19,7 @@ import (
 const (
 	sshResultVariableName = "__soarca_ssh_result__"
 	sshCapabilityName     = "soarca-ssh"
+	defaultDialTimeout    = time.Second * 20
 )
 
 type SshCapability struct {
@@ -33,14 +36,16 @@ func (sshCapability *SshCapability) GetType() string {
 	return sshCapabilityName
 }
 
-func (sshCapability *SshCapability) Execute(metadata execution.Metadata,
-	context capability.Context) (cacao.Variables, error) {
+func (sshCapability *SshCapability) Execute(ctx context.Context,
+	metadata execution.Metadata,
+	capabilityContext capability.Context) (cacao.Variables, error) {
 
 	log.Trace(metadata.ExecutionId)
-	return execute(context.Command, context.Authentication, context.Target)
+	return execute(ctx, capabilityContext.Command, capabilityContext.Authentication, capabilityContext.Target)
 }
 
-func execute(command cacao.Command,
+func execute(ctx context.Context,
+	command cacao.Command,
 	authentication cacao.AuthenticationInformation,
 	target cacao.AgentTarget) (cacao.Variables, error) {
 
@@ -54,20 +59,45 @@ func execute(command cacao.Command,
 	if err != nil {
 		return cacao.NewVariables(), err
 	}
-	session, client, err := getSession(config, target)
+	session, client, err := getSession(ctx, config, target)
 	if err != nil {
 		return cacao.NewVariables(), err
 	}
 	defer close(client)
 
-	return executeCommand(session, command)
+	return executeCommand(ctx, session, command)
 }
 
-func executeCommand(session *ssh.Session,
+type commandOutput struct {
+	response []byte
+	err      error
+}
+
+func executeCommand(ctx context.Context,
+	session *ssh.Session,
 	command cacao.Command) (cacao.Variables, error) {
 
-	response, err := session.Output(StripSshPrepend(command.Command))
+	// Run the command in the background so a hanging target can be abandoned
+	// when the context is done. The channel is buffered so the goroutine can
+	// always finish once the session is closed.
+	outputch := make(chan commandOutput, 1)
+	go func() {
+		response, err := session.Output(StripSshPrepend(command.Command))
+		outputch <- commandOutput{response: response, err: err}
+	}()
+
+	var output commandOutput
+	select {
+	case <-ctx.Done():
+		if sessionErr := session.Close(); sessionErr != nil {
+			log.Trace(sessionErr)
+		}
+		log.Error("ssh command aborted: ", ctx.Err())
+		return cacao.NewVariables(), ctx.Err()
+	case output = <-outputch:
+	}
 
+	response, err := output.response, output.err
 	if err != nil {
 		log.Error(err)
 		return cacao.NewVariables(), err
@@ -87,7 +117,7 @@ func executeCommand(session *ssh.Session,
 func getConfig(authentication cacao.AuthenticationInformation) (ssh.ClientConfig, error) {
 	config := ssh.ClientConfig{User: authentication.Username,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         time.Duration(time.Second * 20)}
+		Timeout:         defaultDialTimeout}
 
 	switch authentication.Type {
 	case "user-auth":
@@ -111,9 +141,9 @@ func getConfig(authentication cacao.AuthenticationInformation) (ssh.ClientConfig
 
 }
 
-func getSession(config ssh.ClientConfig, target cacao.AgentTarget) (*ssh.Session, *ssh.Client, error) {
+func getSession(ctx context.Context, config ssh.ClientConfig, target cacao.AgentTarget) (*ssh.Session, *ssh.Client, error) {
 	host := CombinePortAndAddress(target.Address, target.Port)
-	client, err := ssh.Dial("tcp", host, &config)
+	client, err := dial(ctx, host, &config)
 	if err != nil {
 		log.Error(err)
 		return nil, nil, err
@@ -128,6 +158,40 @@ func getSession(config ssh.ClientConfig, target cacao.AgentTarget) (*ssh.Session
 	return session, client, err
 }
 
+// Dial the target honouring both the context deadline and the configured dial timeout
+func dial(ctx context.Context, host string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ssh handshake and session do not take a context, so bound all
+	// connection I/O by the context deadline and close the connection once the
+	// context is done. Closing an already closed client only returns an error.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			log.Trace(err)
+		}
+	}
+	context.AfterFunc(ctx, func() {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Trace(closeErr)
+		}
+	})
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, host, config)
+	if err != nil {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Trace(closeErr)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return ssh.NewClient(clientConn, channels, requests), nil
+}
+
 func CombinePortAndAddress(addresses map[cacao.NetAddressType][]string, port string) string {
 	if port == "" {
 		port = "22"
@@ -1,9 +1,14 @@
 package ssh
 
 import (
+	"context"
 	"errors"
+	"net"
 	"soarca/pkg/models/cacao"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
 
 	"github.com/go-playground/assert/v2"
 )
@@ -103,3 +108,28 @@ func TestAddressAndPortCombinationNoIpv4Address(t *testing.T) {
 	result := CombinePortAndAddress(ipv4, port)
 	assert.Equal(t, result, expectedFqdn)
 }
+
+func TestDialCancelledDuringHandshake(t *testing.T) {
+	// Accepts the connection but never answers the ssh handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			buffer := make([]byte, 1024)
+			for err == nil {
+				_, err = conn.Read(buffer)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	config := ssh.ClientConfig{User: "root", HostKeyCallback: ssh.InsecureIgnoreHostKey()}
+
+	client, err := dial(ctx, listener.Addr().String(), &config)
+	assert.Equal(t, client == nil, true)
+	assert.Equal(t, err, context.Canceled)
+}
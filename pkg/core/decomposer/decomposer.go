@@ -1,14 +1,20 @@
 package decomposer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"soarca/internal/logger"
+	"soarca/pkg/core/execution_manager"
 	"soarca/pkg/core/executors"
+	"soarca/pkg/core/journal"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
+	journal_model "soarca/pkg/models/journal"
 	"soarca/pkg/reporting/cases"
 	"soarca/pkg/reporting/reporter"
 	"soarca/pkg/utils/guid"
@@ -26,6 +32,11 @@ var (
 	log       *logger.Log
 )
 
+const (
+	exceptionStepIdVariableName = "__soarca_exception_step_id__"
+	exceptionErrorVariableName  = "__soarca_exception_error__"
+)
+
 type ExecutionDetails struct {
 	ExecutionId uuid.UUID
 	PlaybookId  string
@@ -33,8 +44,9 @@ type ExecutionDetails struct {
 }
 
 type IDecomposer interface {
-	ExecuteAsync(playbook cacao.Playbook, detailsch chan ExecutionDetails)
-	Execute(playbook cacao.Playbook) (*ExecutionDetails, error)
+	Execute(ctx context.Context, playbook cacao.Playbook) (*ExecutionDetails, error)
+	// Execute under an execution id that was handed out before, e.g. when the execution was queued
+	ExecuteWithId(ctx context.Context, executionId uuid.UUID, playbook cacao.Playbook) (*ExecutionDetails, error)
 }
 
 func init() {
@@ -54,81 +66,202 @@ func New(actionExecutor executors.IActionExecutor,
 		guid:                   guid,
 		reporter:               reporter,
 		time:                   time,
+		loopLimits: LoopLimits{MaxIterations: DefaultLoopMaxIterations,
+			MaxDuration: DefaultLoopMaxDuration},
 	}
 }
 
+// A decomposer is shared by all executions, the state of an execution is
+// carried by its context
 type Decomposer struct {
-	playbook               cacao.Playbook
-	details                ExecutionDetails
 	actionExecutor         executors.IActionExecutor
 	playbookActionExecutor executors.IPlaybookExecuter
 	conditionExecutor      executors.IConditionExecuter
 	guid                   guid.IGuid
 	reporter               reporter.IWorkflowReporter
 	caseManager            cases.ICasesManager
+	executionManager       execution_manager.IExecutionManager
+	journal                journal.IExecutionJournal
 	time                   timeUtil.ITime
+	loopLimits             LoopLimits
+}
+
+// State of a single execution
+type playbookExecution struct {
+	playbook  cacao.Playbook
+	details   ExecutionDetails
+	journaled bool
+}
+
+type playbookExecutionKey struct{}
+
+func withPlaybookExecution(ctx context.Context, current *playbookExecution) context.Context {
+	return context.WithValue(ctx, playbookExecutionKey{}, current)
+}
+
+// Get the execution a context belongs to, steps executed outside of an
+// execution get an empty one
+func playbookExecutionFrom(ctx context.Context) *playbookExecution {
+	if current, ok := ctx.Value(playbookExecutionKey{}).(*playbookExecution); ok {
+		return current
+	}
+	return &playbookExecution{}
+}
+
+func (current *playbookExecution) metadata(stepId string) execution.Metadata {
+	return execution.Metadata{
+		ExecutionId: current.details.ExecutionId,
+		PlaybookId:  current.details.PlaybookId,
+		StepId:      stepId,
+	}
 }
 
 func (decomposer *Decomposer) SetCaseManager(caseManager cases.ICasesManager) {
 	decomposer.caseManager = caseManager
 }
 
+func (decomposer *Decomposer) SetExecutionManager(executionManager execution_manager.IExecutionManager) {
+	decomposer.executionManager = executionManager
+}
+
+func (decomposer *Decomposer) SetJournal(journal journal.IExecutionJournal) {
+	decomposer.journal = journal
+}
+
 // Execute a Playbook
-func (decomposer *Decomposer) ExecuteAsync(playbook cacao.Playbook, detailsch chan ExecutionDetails) {
-	executionId := decomposer.guid.New()
+func (decomposer *Decomposer) Execute(ctx context.Context, playbook cacao.Playbook) (*ExecutionDetails, error) {
+	return decomposer.ExecuteWithId(ctx, decomposer.guid.New(), playbook)
+}
+
+func (decomposer *Decomposer) ExecuteWithId(ctx context.Context,
+	executionId uuid.UUID,
+	playbook cacao.Playbook) (*ExecutionDetails, error) {
 	log.Debugf("Starting execution %s for Playbook %s", executionId, playbook.ID)
+	current := &playbookExecution{details: ExecutionDetails{executionId, playbook.ID, playbook.PlaybookVariables}}
+
+	ctx, cancel := decomposer.startExecution(ctx, current)
+	defer cancel()
+
+	err := decomposer.execute(ctx, playbook)
 
-	details := ExecutionDetails{executionId, playbook.ID, playbook.PlaybookVariables}
-	decomposer.details = details
+	return &current.details, err
 
-	if detailsch != nil {
-		detailsch <- details
+}
+
+// Derive the execution context and make it cancellable through the execution
+// manager when one is set, which also allows to pause it at step boundaries.
+// The returned function ends the execution.
+func (decomposer *Decomposer) startExecution(ctx context.Context,
+	current *playbookExecution) (context.Context, context.CancelFunc) {
+	executionId := current.details.ExecutionId
+	// Executions queued by the scheduler are registered under their own id by
+	// the scheduler, child executions of playbook actions carry the state of
+	// their parent and are resumed through it
+	state, registered := execution_manager.StateFromContext(ctx)
+	owned := registered && state.ExecutionId() == executionId
+	current.journaled = decomposer.journal != nil && (!registered || owned)
+
+	ctx, cancel := context.WithCancel(withPlaybookExecution(ctx, current))
+	if decomposer.executionManager == nil || owned {
+		return ctx, cancel
 	}
 
-	_ = decomposer.execute(playbook)
+	ctx = decomposer.executionManager.Register(ctx, executionId, cancel)
+	return ctx, func() {
+		decomposer.executionManager.Deregister(executionId)
+		cancel()
+	}
+}
 
+func (decomposer *Decomposer) awaitResume(ctx context.Context, scopeVariables cacao.Variables) error {
+	if decomposer.executionManager == nil {
+		return nil
+	}
+	executionId := playbookExecutionFrom(ctx).details.ExecutionId
+	return decomposer.executionManager.AwaitResume(ctx, executionId, scopeVariables)
 }
 
-func (decomposer *Decomposer) Execute(playbook cacao.Playbook) (*ExecutionDetails, error) {
+// Resume an execution recorded in the journal, from the step of the main
+// workflow it was at with the journaled scope variables
+func (decomposer *Decomposer) Resume(ctx context.Context, record journal_model.ExecutionRecord) (*ExecutionDetails, error) {
+	executionId, err := uuid.Parse(record.ExecutionId)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Resuming execution %s for Playbook %s at step %s", executionId, record.Playbook.ID, record.StepId)
+	current := &playbookExecution{details: ExecutionDetails{executionId, record.Playbook.ID, record.Playbook.PlaybookVariables}}
 
-	executionId := decomposer.guid.New()
-	log.Debugf("Starting execution %s for Playbook %s", executionId, playbook.ID)
-	decomposer.details = ExecutionDetails{executionId, playbook.ID, playbook.PlaybookVariables}
+	ctx, cancel := decomposer.startExecution(ctx, current)
+	defer cancel()
 
-	err := decomposer.execute(playbook)
+	variables := cacao.NewVariables()
+	variables.InsertRange(record.Variables)
+	err = decomposer.executeFrom(ctx, record.Playbook, record.StepId, variables)
 
-	return &decomposer.details, err
+	return &current.details, err
+}
 
+// Report an execution recorded in the journal that is not resumed as failed
+func (decomposer *Decomposer) Interrupt(record journal_model.ExecutionRecord) error {
+	executionId, err := uuid.Parse(record.ExecutionId)
+	if err != nil {
+		return err
+	}
+	log.Info("Marking interrupted execution ", executionId, " as failed")
+	err = execution.ErrorExecutionInterrupted{ExecutionId: executionId, StepId: record.StepId}
+	decomposer.reporter.ReportWorkflowStart(executionId, record.Playbook, decomposer.time.Now())
+	decomposer.reporter.ReportWorkflowEnd(executionId, record.Playbook, err, decomposer.time.Now())
+	return nil
 }
 
-func (decomposer *Decomposer) execute(playbook cacao.Playbook) error {
+func (decomposer *Decomposer) execute(ctx context.Context, playbook cacao.Playbook) error {
 
-	decomposer.playbook = playbook
+	current := playbookExecutionFrom(ctx)
 
 	stepId := playbook.WorkflowStart
 
 	// Start case correlation and get case ID to be used in playbook
 	if decomposer.caseManager != nil {
-		startMetadata := execution.Metadata{ExecutionId: decomposer.details.ExecutionId,
-			PlaybookId: decomposer.playbook.ID,
+		startMetadata := execution.Metadata{ExecutionId: current.details.ExecutionId,
+			PlaybookId: playbook.ID,
 			StepId:     stepId}
 
-		caseIdVar := decomposer.caseManager.AddToExistingOrCreateNew(startMetadata, playbook)
+		// Variables marked above what the case manager may receive are left out
+		casePlaybook := playbook
+		casePlaybook.PlaybookVariables = marking.PolicyOf(playbook).Filter(playbook.PlaybookVariables,
+			marking.MaxMarkingOf(decomposer.caseManager))
+		caseIdVar := decomposer.caseManager.AddToExistingOrCreateNew(startMetadata, casePlaybook)
 		playbook.PlaybookVariables.InsertOrReplace(caseIdVar)
 		log.Info("case id is set to: ", caseIdVar.Value)
 	}
 
 	variables := cacao.NewVariables()
-	variables.Merge(playbook.PlaybookVariables)
+	variables.InsertRange(playbook.PlaybookVariables)
+
+	return decomposer.executeFrom(ctx, playbook, stepId, variables)
+}
+
+func (decomposer *Decomposer) executeFrom(ctx context.Context,
+	playbook cacao.Playbook,
+	stepId string,
+	variables cacao.Variables) error {
+
+	current := playbookExecutionFrom(ctx)
+	current.playbook = playbook
+	// Capabilities and child playbooks redact data with the policy of the execution
+	ctx = marking.WithPolicy(ctx, marking.PolicyOf(playbook))
 
 	// Reporting workflow instantiation
-	decomposer.reporter.ReportWorkflowStart(decomposer.details.ExecutionId, playbook, decomposer.time.Now())
+	decomposer.reporter.ReportWorkflowStart(current.details.ExecutionId, playbook, decomposer.time.Now())
 
-	outputVariables, err := decomposer.ExecuteBranch(stepId, variables)
+	outputVariables, err := decomposer.executeBranch(ctx, stepId, variables, current.journaled)
+	if err != nil && playbook.WorkflowException != "" && ctx.Err() == nil {
+		outputVariables, err = decomposer.executeWorkflowException(ctx, err, variables)
+	}
 
-	decomposer.details.Variables = outputVariables
+	current.details.Variables = outputVariables
 	// Reporting workflow end
-	decomposer.reporter.ReportWorkflowEnd(decomposer.details.ExecutionId, playbook, err, decomposer.time.Now())
+	decomposer.reporter.ReportWorkflowEnd(current.details.ExecutionId, playbook, err, decomposer.time.Now())
 
 	return err
 }
@@ -136,8 +269,17 @@ func (decomposer *Decomposer) execute(playbook cacao.Playbook) error {
 // Execute a Workflow branch of a Playbook
 //
 // Runs until it find an End step or returns an error in case there are no valid next step.
-func (decomposer *Decomposer) ExecuteBranch(stepId string, scopeVariables cacao.Variables) (cacao.Variables, error) {
-	playbook := decomposer.playbook
+func (decomposer *Decomposer) ExecuteBranch(ctx context.Context, stepId string, scopeVariables cacao.Variables) (cacao.Variables, error) {
+	return decomposer.executeBranch(ctx, stepId, scopeVariables, false)
+}
+
+// The main workflow branch is journaled at every step boundary
+func (decomposer *Decomposer) executeBranch(ctx context.Context,
+	stepId string,
+	scopeVariables cacao.Variables,
+	journaled bool) (cacao.Variables, error) {
+	current := playbookExecutionFrom(ctx)
+	playbook := current.playbook
 	log.Debug("Executing branch starting from ", stepId)
 
 	returnVariables := cacao.NewVariables()
@@ -154,121 +296,240 @@ func (decomposer *Decomposer) ExecuteBranch(stepId string, scopeVariables cacao.
 			break
 		}
 
-		// Note: likely (but not certainly) on_success and on_faliure will be reworked
-		// to become workflow branching properties, with the addition of a success_condition
-		// boolean evaluation at step level.
-		// Effectively, we should thus only check for existance of on_completion, and
-		// report execution errors as such, not as playbook step failures - which will be handled
-		// with upcoming said on_success, on_failure, and success_condition properties
-		onCompletionStepId := currentStep.OnCompletion
-		if onCompletionStepId == "" {
-			onCompletionStepId = currentStep.OnSuccess
+		if ctx.Err() != nil {
+			log.Info("execution cancelled before step ", stepId)
+			return cacao.NewVariables(), fmt.Errorf("execution cancelled before step [ %s ]: %w", stepId, ctx.Err())
 		}
-		if onCompletionStepId == "" {
-			onCompletionStepId = currentStep.OnFailure
+
+		// Checkpoint, the scope variables may be updated while the execution is paused
+		if err := decomposer.awaitResume(ctx, scopeVariables); err != nil {
+			log.Info("execution cancelled while paused before step ", stepId)
+			return cacao.NewVariables(), fmt.Errorf("execution cancelled before step [ %s ]: %w", stepId, err)
 		}
-		if _, ok := playbook.Workflow[onCompletionStepId]; !ok {
-			return cacao.NewVariables(), errors.New("empty completion step")
+		if journaled {
+			decomposer.journal.Checkpoint(current.details.ExecutionId, playbook, stepId, scopeVariables)
 		}
 
-		outputVariables, err := decomposer.ExecuteStep(currentStep, scopeVariables)
+		// on_success takes precedence over on_completion when the step succeeds.
+		// A failing step follows on_failure when defined, otherwise the branch fails.
+		// A step with only on_failure ends the branch when it succeeds
+		onSuccessStepId := currentStep.OnSuccess
+		if onSuccessStepId == "" {
+			onSuccessStepId = currentStep.OnCompletion
+		}
+		onFailureStepId := currentStep.OnFailure
+		if _, ok := playbook.Workflow[onSuccessStepId]; !ok && (onSuccessStepId != "" || onFailureStepId == "") {
+			return cacao.NewVariables(), errors.New("empty completion step")
+		}
+		if _, ok := playbook.Workflow[onFailureStepId]; onFailureStepId != "" && !ok {
+			return cacao.NewVariables(), fmt.Errorf("on_failure step %s not found", onFailureStepId)
+		}
 
+		outputVariables, err := decomposer.ExecuteStep(ctx, currentStep, scopeVariables)
 		if err == nil {
-			stepId = onCompletionStepId
-			returnVariables.Merge(outputVariables)
-			scopeVariables.Merge(outputVariables)
+			// A step that tries to change a constant variable fails
+			err = mergeStepOutput(returnVariables, scopeVariables, outputVariables)
+		}
+
+		if err == nil && onSuccessStepId == "" {
+			break
+		} else if err == nil {
+			stepId = onSuccessStepId
+		} else if ctx.Err() != nil {
+			// A cancelled execution must not continue on the failure branch
+			return cacao.NewVariables(), fmt.Errorf("execution cancelled: %w: %w", ctx.Err(), execution.ErrorStepFailed{StepId: stepId, Err: err})
+		} else if onFailureStepId != "" {
+			log.Warning("step ", stepId, " failed, continuing with on_failure step ", onFailureStepId, ": ", err)
+			stepId = onFailureStepId
+			// The output of the failed step is available to the on_failure branch
+			if err := mergeStepOutput(returnVariables, scopeVariables, outputVariables); err != nil {
+				log.Warning(err)
+			}
 		} else {
-			return cacao.NewVariables(), fmt.Errorf("playbook execution failed at step [ %s ]. See step log for error information", stepId)
+			return cacao.NewVariables(), execution.ErrorStepFailed{StepId: stepId, Err: err}
 		}
 	}
 
 	return returnVariables, nil
 }
 
+// Merge the output of a step into the scope of its branch. Constant variables
+// of the scope are kept, the returned variables hold the values in the scope.
+func mergeStepOutput(returnVariables cacao.Variables,
+	scopeVariables cacao.Variables,
+	outputVariables cacao.Variables) error {
+	err := scopeVariables.Merge(outputVariables)
+	for name := range outputVariables {
+		if variable, found := scopeVariables.Find(name); found {
+			returnVariables.InsertOrReplace(variable)
+		}
+	}
+	return err
+}
+
+// Execute the workflow_exception branch of the playbook after a failed execution
+//
+// The id of the innermost failing step and its error are made available to the
+// exception branch as variables. When the exception branch completes, the
+// original failure is returned as an ErrorExceptionCondition.
+func (decomposer *Decomposer) executeWorkflowException(ctx context.Context,
+	executionError error,
+	scopeVariables cacao.Variables) (cacao.Variables, error) {
+	playbook := playbookExecutionFrom(ctx).playbook
+	exceptionStepId := playbook.WorkflowException
+	if _, ok := playbook.Workflow[exceptionStepId]; !ok {
+		log.Error("workflow exception step ", exceptionStepId, " not found in workflow")
+		return cacao.NewVariables(), executionError
+	}
+
+	failedStepId, stepError := innermostStepFailure(executionError)
+	log.Info("execution failed at step ", failedStepId, ", executing workflow exception ", exceptionStepId)
+
+	variables := cacao.NewVariables()
+	variables.InsertRange(scopeVariables)
+	variables.InsertOrReplace(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  exceptionStepIdVariableName,
+		Value: failedStepId})
+	variables.InsertOrReplace(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  exceptionErrorVariableName,
+		Value: stepError.Error()})
+
+	outputVariables, err := decomposer.ExecuteBranch(ctx, exceptionStepId, variables)
+	if err != nil {
+		return cacao.NewVariables(), fmt.Errorf("workflow exception failed: %w, after: %w", err, executionError)
+	}
+	return outputVariables, execution.ErrorExceptionCondition{StepId: failedStepId, Err: executionError}
+}
+
+// Unwrap nested step failures down to the step that originally failed
+func innermostStepFailure(err error) (string, error) {
+	stepId := ""
+	stepError := err
+	var failure execution.ErrorStepFailed
+	for errors.As(stepError, &failure) {
+		stepId = failure.StepId
+		stepError = failure.Err
+		if stepError == nil {
+			return stepId, err
+		}
+	}
+	return stepId, stepError
+}
+
 // Execute a single Step within a Workflow
-func (decomposer *Decomposer) ExecuteStep(step cacao.Step, scopeVariables cacao.Variables) (cacao.Variables, error) {
+func (decomposer *Decomposer) ExecuteStep(ctx context.Context, step cacao.Step, scopeVariables cacao.Variables) (cacao.Variables, error) {
 	log.Debug("Executing step type ", step.Type)
 
 	log.Trace("Delay is set to: ", step.Delay)
 	decomposer.time.Sleep(t.Duration(step.Delay) * t.Millisecond)
 
-	// Combine parent scope and Step variables
+	// Combine parent scope and Step variables, a step with in_args only gets
+	// the declared variables of the scope
 	variables := cacao.NewVariables()
-	variables.Merge(scopeVariables)
-	variables.Merge(step.StepVariables)
-
-	metadata := execution.Metadata{
-		ExecutionId: decomposer.details.ExecutionId,
-		PlaybookId:  decomposer.details.PlaybookId,
-		StepId:      step.ID,
+	if len(step.InArgs) > 0 {
+		variables.InsertRange(scopeVariables.Select(step.InArgs))
+	} else {
+		variables.InsertRange(scopeVariables)
+	}
+	if err := variables.Merge(step.StepVariables); err != nil {
+		err = fmt.Errorf("step variables of step %s: %w", step.ID, err)
+		log.Error(err)
+		return cacao.NewVariables(), err
 	}
 
+	current := playbookExecutionFrom(ctx)
+	metadata := current.metadata(step.ID)
+
 	switch step.Type {
 	case cacao.StepTypeAction:
 		actionMetadata := executors.PlaybookStepMetadata{
 			Step:      step,
-			Targets:   decomposer.playbook.TargetDefinitions,
-			Auth:      decomposer.playbook.AuthenticationInfoDefinitions,
-			Agent:     decomposer.playbook.AgentDefinitions[step.Agent],
+			Targets:   current.playbook.TargetDefinitions,
+			Auth:      current.playbook.AuthenticationInfoDefinitions,
+			Agent:     current.playbook.AgentDefinitions[step.Agent],
 			Variables: variables,
 		}
-		return decomposer.actionExecutor.Execute(metadata, actionMetadata)
+		return decomposer.actionExecutor.Execute(ctx, metadata, actionMetadata)
 	case cacao.StepTypePlaybookAction:
-		return decomposer.playbookActionExecutor.Execute(metadata, step, variables)
-	case cacao.StepTypeIfCondition:
-		return decomposer.executeIfCondition(step, variables)
+		return decomposer.playbookActionExecutor.Execute(ctx, metadata, step, variables)
+	case cacao.StepTypeIfCondition, cacao.StepTypeSwitchCondition:
+		return decomposer.executeCondition(ctx, step, variables)
 	case cacao.StepTypeWhileCondition:
-		return decomposer.executeLoop(step, variables)
+		return decomposer.executeLoop(ctx, step, variables)
+	case cacao.StepTypeParallel:
+		return decomposer.executeParallel(ctx, step, variables)
 	default:
 		// NOTE: This currently silently handles unknown step types. Should we return an error instead?
 		return cacao.NewVariables(), nil //errors.ErrUnsupported
 	}
 }
 
-func (decomposer *Decomposer) executeIfCondition(step cacao.Step,
+// Execute the branch selected by an if-condition or switch-condition step
+func (decomposer *Decomposer) executeCondition(ctx context.Context,
+	step cacao.Step,
 	variables cacao.Variables) (cacao.Variables, error) {
-	metadata := execution.Metadata{
-		ExecutionId: decomposer.details.ExecutionId,
-		PlaybookId:  decomposer.details.PlaybookId,
-		StepId:      step.ID,
-	}
+	metadata := playbookExecutionFrom(ctx).metadata(step.ID)
 	stepId, branch, err := decomposer.conditionExecutor.Execute(metadata,
 		executors.Context{Step: step, Variables: variables})
 	if err != nil {
 		return cacao.NewVariables(), err
 	}
 	if branch {
-		return decomposer.ExecuteBranch(stepId, variables)
+		return decomposer.ExecuteBranch(ctx, stepId, variables)
 	}
 	return variables, nil
 }
 
-func (decomposer *Decomposer) executeLoop(step cacao.Step,
+// Execute all next_steps branches of a parallel step concurrently
+//
+// Every branch gets its own copy of the scope variables. Once all branches
+// have finished, their outputs are merged in the order of next_steps, so on
+// conflicting names the branch listed last wins.
+func (decomposer *Decomposer) executeParallel(ctx context.Context,
+	step cacao.Step,
 	variables cacao.Variables) (cacao.Variables, error) {
-	metadata := execution.Metadata{
-		ExecutionId: decomposer.details.ExecutionId,
-		PlaybookId:  decomposer.details.PlaybookId,
-		StepId:      step.ID,
+
+	if len(step.NextSteps) == 0 {
+		return cacao.NewVariables(), fmt.Errorf("parallel step %s has no next_steps", step.ID)
 	}
 
-	loop := true
+	type branchResult struct {
+		variables cacao.Variables
+		err       error
+	}
+	results := make([]branchResult, len(step.NextSteps))
+
+	var wg sync.WaitGroup
+	for index, branchStepId := range step.NextSteps {
+		branchVariables := cacao.NewVariables()
+		branchVariables.InsertRange(variables)
+
+		wg.Add(1)
+		go func(index int, branchStepId string, branchVariables cacao.Variables) {
+			defer wg.Done()
+			outputVariables, err := decomposer.ExecuteBranch(ctx, branchStepId, branchVariables)
+			results[index] = branchResult{variables: outputVariables, err: err}
+		}(index, branchStepId, branchVariables)
+	}
+	wg.Wait()
 
-	for loop {
-		stepId, branch, err := decomposer.conditionExecutor.Execute(metadata,
-			executors.Context{Step: step, Variables: variables})
-		if err != nil {
-			return cacao.NewVariables(), err
+	returnVariables := cacao.NewVariables()
+	branchErrors := []error{}
+	for index, result := range results {
+		if result.err != nil {
+			log.Error("parallel branch ", step.NextSteps[index], " failed: ", result.err)
+			branchErrors = append(branchErrors,
+				fmt.Errorf("parallel branch [ %s ] failed: %w", step.NextSteps[index], result.err))
+			continue
 		}
-		loop = branch
-
-		if loop {
-			branchVariables, err := decomposer.ExecuteBranch(stepId, variables)
-			if err != nil {
-				return variables, err
-			}
-			variables.Merge(branchVariables)
+		if err := returnVariables.Merge(result.variables); err != nil {
+			branchErrors = append(branchErrors,
+				fmt.Errorf("parallel branch [ %s ] failed: %w", step.NextSteps[index], err))
 		}
+	}
 
+	if len(branchErrors) > 0 {
+		return cacao.NewVariables(), errors.Join(branchErrors...)
 	}
-	return variables, nil
+	return returnVariables, nil
 }
@@ -1,23 +1,30 @@
 package decomposer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"soarca/pkg/core/execution_manager"
 	"soarca/pkg/core/executors"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
+	"soarca/pkg/models/journal"
+	"soarca/pkg/utils/guid"
 	"soarca/test/unittest/mocks/mock_executor"
 	mock_condition_executor "soarca/test/unittest/mocks/mock_executor/condition"
 	mock_playbook_action_executor "soarca/test/unittest/mocks/mock_executor/playbook_action"
 	"soarca/test/unittest/mocks/mock_guid"
+	"soarca/test/unittest/mocks/mock_journal"
 	"soarca/test/unittest/mocks/mock_reporter"
 	mock_time "soarca/test/unittest/mocks/mock_utils/time"
 
 	"github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestExecutePlaybook(t *testing.T) {
@@ -114,9 +121,9 @@ func TestExecutePlaybook(t *testing.T) {
 	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
 	mock_time.On("Sleep", time.Millisecond*10).Return()
 	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
-	mock_action_executor.On("Execute", metaStep1, playbookStepMetadata).Return(cacao.NewVariables(cacao.Variable{Name: "return", Value: "value"}), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata).Return(cacao.NewVariables(cacao.Variable{Name: "return", Value: "value"}), nil)
 
-	details, err := decomposer.Execute(playbook)
+	details, err := decomposer.Execute(context.Background(), playbook)
 	uuid_mock.AssertExpectations(t)
 	fmt.Println(err)
 	assert.Equal(t, err, nil)
@@ -257,7 +264,7 @@ func TestExecutePlaybookMultiStep(t *testing.T) {
 	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
 	mock_time.On("Sleep", time.Millisecond*0).Return()
 	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
-	mock_action_executor.On("Execute", metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(firstResult), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(firstResult), nil)
 
 	playbookStepMetadata2 := executors.PlaybookStepMetadata{
 		Step:      step2,
@@ -267,9 +274,9 @@ func TestExecutePlaybookMultiStep(t *testing.T) {
 		Variables: cacao.NewVariables(expectedVariables2, firstResult),
 	}
 
-	mock_action_executor.On("Execute", metaStep2, playbookStepMetadata2).Return(cacao.NewVariables(cacao.Variable{Name: "result", Value: "updated"}), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaStep2, playbookStepMetadata2).Return(cacao.NewVariables(cacao.Variable{Name: "result", Value: "updated"}), nil)
 
-	details, err := decomposer.Execute(playbook)
+	details, err := decomposer.Execute(context.Background(), playbook)
 	uuid_mock.AssertExpectations(t)
 	fmt.Println(err)
 	assert.Equal(t, err, nil)
@@ -354,7 +361,7 @@ func TestExecuteEmptyMultiStep(t *testing.T) {
 	mock_time.On("Sleep", time.Millisecond*0).Return()
 	mock_reporter.On("ReportWorkflowEnd", id, playbook, errors.New("empty completion step"), timeNow).Return()
 
-	returnedId, err := decomposer2.Execute(playbook)
+	returnedId, err := decomposer2.Execute(context.Background(), playbook)
 	uuid_mock2.AssertExpectations(t)
 	fmt.Println(err)
 	assert.Equal(t, err, errors.New("empty completion step"))
@@ -507,7 +514,7 @@ func TestFailingStepResultsInFailingPlaybook(t *testing.T) {
 
 	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
 	mock_time.On("Sleep", time.Millisecond*0).Return()
-	mock_action_executor.On("Execute", metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(firstResult), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(firstResult), nil)
 
 	playbookStepMetadata2 := executors.PlaybookStepMetadata{
 		Step:      step2,
@@ -516,7 +523,7 @@ func TestFailingStepResultsInFailingPlaybook(t *testing.T) {
 		Agent:     expectedAgent,
 		Variables: cacao.NewVariables(expectedVariables2, firstResult),
 	}
-	mock_action_executor.On("Execute", metaStep2, playbookStepMetadata2).Return(cacao.NewVariables(cacao.Variable{Name: "all", Value: "good"}), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaStep2, playbookStepMetadata2).Return(cacao.NewVariables(cacao.Variable{Name: "all", Value: "good"}), nil)
 
 	playbookStepMetadata3 := executors.PlaybookStepMetadata{
 		Step:      step3,
@@ -526,13 +533,13 @@ func TestFailingStepResultsInFailingPlaybook(t *testing.T) {
 		Variables: cacao.NewVariables(expectedVariables2, firstResult, cacao.Variable{Name: "all", Value: "good"}),
 	}
 
-	mock_action_executor.On("Execute", metaStep3, playbookStepMetadata3).Return(cacao.NewVariables(), errors.New("everything broke"))
+	mock_action_executor.On("Execute", mock.Anything, metaStep3, playbookStepMetadata3).Return(cacao.NewVariables(), errors.New("everything broke"))
 
 	mock_time.On("Now").Return(timeNow)
-	expectedError := errors.New("playbook execution failed at step [ action--test3 ]. See step log for error information")
+	expectedError := execution.ErrorStepFailed{StepId: "action--test3", Err: errors.New("everything broke")}
 	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, expectedError, timeNow).Return()
 
-	_, err := decomposer.Execute(playbook)
+	_, err := decomposer.Execute(context.Background(), playbook)
 	t.Log(err)
 	uuid_mock.AssertExpectations(t)
 	assert.Equal(t, err, expectedError)
@@ -543,6 +550,283 @@ func TestFailingStepResultsInFailingPlaybook(t *testing.T) {
 	mock_reporter.AssertExpectations(t)
 }
 
+/*
+A failing step with on_failure continues on the failure branch, the playbook
+execution itself does not fail.
+*/
+func TestFailingStepFollowsOnFailure(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	expectedAgent := cacao.AgentTarget{
+		ID:   "agent1",
+		Type: "soarca",
+		Name: "soarca-ssh",
+	}
+
+	step1 := cacao.Step{
+		Type:      "action",
+		ID:        "action--test",
+		Name:      "ssh-tests",
+		Commands:  []cacao.Command{{Type: "ssh", Command: "ssh breakeverything.exe"}},
+		Agent:     "agent1",
+		OnSuccess: "action--success",
+		OnFailure: "action--failure",
+	}
+	success := cacao.Step{
+		Type:         "action",
+		ID:           "action--success",
+		Commands:     []cacao.Command{{Type: "ssh", Command: "ssh ls -la"}},
+		Agent:        "agent1",
+		OnCompletion: "end--test",
+	}
+	failure := cacao.Step{
+		Type:         "action",
+		ID:           "action--failure",
+		Commands:     []cacao.Command{{Type: "ssh", Command: "ssh restore.exe"}},
+		Agent:        "agent1",
+		OnCompletion: "end--test",
+	}
+	end := cacao.Step{
+		Type: "end",
+		ID:   "end--test",
+		Name: "end step",
+	}
+
+	playbook := cacao.Playbook{
+		ID:               "test",
+		Type:             "test",
+		Name:             "ssh-test",
+		WorkflowStart:    step1.ID,
+		AgentDefinitions: map[string]cacao.AgentTarget{"agent1": expectedAgent},
+		Workflow: map[string]cacao.Step{step1.ID: step1,
+			success.ID: success,
+			failure.ID: failure,
+			end.ID:     end},
+	}
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	metaStep1 := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID}
+	metaFailure := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: failure.ID}
+
+	uuid_mock.On("New").Return(executionId)
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+
+	playbookStepMetadata1 := executors.PlaybookStepMetadata{
+		Step:      step1,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(),
+	}
+	partialResult := cacao.Variable{Type: "string", Name: "partial", Value: "output"}
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(partialResult), errors.New("everything broke"))
+
+	playbookStepMetadataFailure := executors.PlaybookStepMetadata{
+		Step:      failure,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(partialResult),
+	}
+	restored := cacao.Variable{Type: "string", Name: "restored", Value: "true"}
+	mock_action_executor.On("Execute", mock.Anything, metaFailure, playbookStepMetadataFailure).Return(cacao.NewVariables(restored), nil)
+
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	details, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, details.Variables, cacao.NewVariables(partialResult, restored))
+	uuid_mock.AssertExpectations(t)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
+
+/*
+A succeeding step without on_success or on_completion ends its branch.
+*/
+func TestSucceedingStepWithOnlyOnFailureEndsBranch(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	expectedAgent := cacao.AgentTarget{
+		ID:   "agent1",
+		Type: "soarca",
+		Name: "soarca-ssh",
+	}
+
+	step1 := cacao.Step{
+		Type:      "action",
+		ID:        "action--test",
+		Name:      "ssh-tests",
+		Commands:  []cacao.Command{{Type: "ssh", Command: "ssh ls -la"}},
+		Agent:     "agent1",
+		OnFailure: "action--failure",
+	}
+	failure := cacao.Step{
+		Type:     "action",
+		ID:       "action--failure",
+		Commands: []cacao.Command{{Type: "ssh", Command: "ssh restore.exe"}},
+		Agent:    "agent1",
+	}
+
+	playbook := cacao.Playbook{
+		ID:               "test",
+		Type:             "test",
+		Name:             "ssh-test",
+		WorkflowStart:    step1.ID,
+		AgentDefinitions: map[string]cacao.AgentTarget{"agent1": expectedAgent},
+		Workflow:         map[string]cacao.Step{step1.ID: step1, failure.ID: failure},
+	}
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	metaStep1 := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID}
+
+	uuid_mock.On("New").Return(executionId)
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+
+	playbookStepMetadata1 := executors.PlaybookStepMetadata{
+		Step:      step1,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(),
+	}
+	result := cacao.Variable{Type: "string", Name: "result", Value: "output"}
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(result), nil)
+
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	details, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, details.Variables, cacao.NewVariables(result))
+	uuid_mock.AssertExpectations(t)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
+
+/*
+A succeeding step follows on_success instead of on_completion.
+*/
+func TestSucceedingStepFollowsOnSuccess(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	expectedAgent := cacao.AgentTarget{
+		ID:   "agent1",
+		Type: "soarca",
+		Name: "soarca-ssh",
+	}
+
+	step1 := cacao.Step{
+		Type:         "action",
+		ID:           "action--test",
+		Name:         "ssh-tests",
+		Commands:     []cacao.Command{{Type: "ssh", Command: "ssh ls -la"}},
+		Agent:        "agent1",
+		OnCompletion: "end--test",
+		OnSuccess:    "action--success",
+	}
+	success := cacao.Step{
+		Type:         "action",
+		ID:           "action--success",
+		Commands:     []cacao.Command{{Type: "ssh", Command: "ssh pwd"}},
+		Agent:        "agent1",
+		OnCompletion: "end--test",
+	}
+	end := cacao.Step{
+		Type: "end",
+		ID:   "end--test",
+		Name: "end step",
+	}
+
+	playbook := cacao.Playbook{
+		ID:               "test",
+		Type:             "test",
+		Name:             "ssh-test",
+		WorkflowStart:    step1.ID,
+		AgentDefinitions: map[string]cacao.AgentTarget{"agent1": expectedAgent},
+		Workflow:         map[string]cacao.Step{step1.ID: step1, success.ID: success, end.ID: end},
+	}
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	metaStep1 := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID}
+	metaSuccess := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: success.ID}
+
+	uuid_mock.On("New").Return(executionId)
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+
+	playbookStepMetadata1 := executors.PlaybookStepMetadata{
+		Step:      step1,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(),
+	}
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(), nil)
+
+	playbookStepMetadataSuccess := executors.PlaybookStepMetadata{
+		Step:      success,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(),
+	}
+	mock_action_executor.On("Execute", mock.Anything, metaSuccess, playbookStepMetadataSuccess).Return(cacao.NewVariables(), nil)
+
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	_, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, nil)
+	uuid_mock.AssertExpectations(t)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
+
 /*
 Test with an not occuring on completion id will result in not executing the step.
 */
@@ -602,7 +886,7 @@ func TestExecuteIllegalMultiStep(t *testing.T) {
 	mock_time.On("Sleep", time.Millisecond*0).Return()
 	mock_reporter.On("ReportWorkflowEnd", id, playbook, errors.New("empty completion step"), timeNow).Return()
 
-	returnedId, err := decomposer2.Execute(playbook)
+	returnedId, err := decomposer2.Execute(context.Background(), playbook)
 	uuid_mock2.AssertExpectations(t)
 	mock_reporter.AssertExpectations(t)
 	fmt.Println(err)
@@ -668,11 +952,12 @@ func TestExecutePlaybookAction(t *testing.T) {
 	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
 
 	mock_playbook_action_executor.On("Execute",
+		mock.Anything,
 		metaStep1,
 		step1,
 		cacao.NewVariables(expectedVariables)).Return(cacao.NewVariables(cacao.Variable{Name: "return", Value: "value"}), nil)
 
-	details, err := decomposer.Execute(playbook)
+	details, err := decomposer.Execute(context.Background(), playbook)
 	uuid_mock.AssertExpectations(t)
 	fmt.Println(err)
 	assert.Equal(t, err, nil)
@@ -840,6 +1125,7 @@ func TestExecuteIfCondition(t *testing.T) {
 	mock_time.On("Sleep", time.Millisecond*0).Return()
 
 	mock_action_executor.On("Execute",
+		mock.Anything,
 		metaStepTrue,
 		stepTrueDetails).Return(cacao.NewVariables(expectedVariables2), nil)
 
@@ -855,10 +1141,11 @@ func TestExecuteIfCondition(t *testing.T) {
 	mock_time.On("Sleep", time.Millisecond*0).Return()
 
 	mock_action_executor.On("Execute",
+		mock.Anything,
 		metaStepCompletion,
 		stepCompletionDetails).Return(cacao.NewVariables(), nil)
 	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
-	details, err := decomposer.Execute(playbook)
+	details, err := decomposer.Execute(context.Background(), playbook)
 	uuid_mock.AssertExpectations(t)
 	fmt.Println(err)
 	assert.Equal(t, err, nil)
@@ -916,9 +1203,9 @@ func TestDelayStepExecution(t *testing.T) {
 	}
 
 	mock_time.On("Sleep", time.Millisecond*10).Return()
-	mock_action_executor.On("Execute", metaStep1, playbookStepMetadata).Return(cacao.NewVariables(cacao.Variable{Name: "return", Value: "value"}), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata).Return(cacao.NewVariables(cacao.Variable{Name: "return", Value: "value"}), nil)
 
-	_, err := decomposer.ExecuteStep(step1, cacao.NewVariables(expectedVariables))
+	_, err := decomposer.ExecuteStep(context.Background(), step1, cacao.NewVariables(expectedVariables))
 	assert.Equal(t, err, nil)
 
 }
@@ -970,9 +1257,9 @@ func TestDelayStepNegativeTimeExecution(t *testing.T) {
 	}
 
 	mock_time.On("Sleep", time.Millisecond*-10).Return()
-	mock_action_executor.On("Execute", metaStep1, playbookStepMetadata).Return(cacao.NewVariables(cacao.Variable{Name: "return", Value: "value"}), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata).Return(cacao.NewVariables(cacao.Variable{Name: "return", Value: "value"}), nil)
 
-	_, err := decomposer.ExecuteStep(step1, cacao.NewVariables(expectedVariables))
+	_, err := decomposer.ExecuteStep(context.Background(), step1, cacao.NewVariables(expectedVariables))
 	assert.Equal(t, err, nil)
 
 }
@@ -1102,18 +1389,27 @@ func TestExecuteWhileCondition(t *testing.T) {
 			Variables: cacao.NewVariables(expectedVariables)},
 	).Return(stepTrue.ID, true, nil)
 
+	mock_reporter.On("ReportLoopIteration", executionId, stepWhile, 1, timeNow).Return()
+
+	// The body gets the iteration counter, it is not merged back into the scope
+	iterationVariable := cacao.Variable{
+		Type:  cacao.VariableTypeInt,
+		Name:  "__soarca_loop_iteration__",
+		Value: "1",
+	}
 	stepTrueDetails := executors.PlaybookStepMetadata{
 		Step:      stepTrue,
 		Targets:   playbook.TargetDefinitions,
 		Auth:      playbook.AuthenticationInfoDefinitions,
 		Agent:     expectedAgent,
-		Variables: cacao.NewVariables(expectedVariables),
+		Variables: cacao.NewVariables(expectedVariables, iterationVariable),
 	}
 
 	metaStepTrue := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: stepTrue.ID}
 	mock_time.On("Sleep", time.Millisecond*0).Return()
 
 	mock_action_executor.On("Execute",
+		mock.Anything,
 		metaStepTrue,
 		stepTrueDetails).Return(cacao.NewVariables(expectedVariables2), nil)
 
@@ -1135,10 +1431,11 @@ func TestExecuteWhileCondition(t *testing.T) {
 	mock_time.On("Sleep", time.Millisecond*0).Return()
 
 	mock_action_executor.On("Execute",
+		mock.Anything,
 		metaStepCompletion,
 		stepCompletionDetails).Return(cacao.NewVariables(), nil)
 	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
-	details, err := decomposer.Execute(playbook)
+	details, err := decomposer.Execute(context.Background(), playbook)
 	uuid_mock.AssertExpectations(t)
 	fmt.Println(err)
 	assert.Equal(t, err, nil)
@@ -1148,3 +1445,1073 @@ func TestExecuteWhileCondition(t *testing.T) {
 	mock_action_executor.AssertExpectations(t)
 
 }
+
+func TestExecuteParallel(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	expectedVariables := cacao.Variable{
+		Type:  "string",
+		Name:  "__var1__",
+		Value: "testing",
+	}
+
+	expectedCommand := cacao.Command{
+		Type:    "ssh",
+		Command: "ssh ls -la",
+	}
+
+	expectedAgent := cacao.AgentTarget{
+		Type: "soarca",
+		Name: "soarca-ssh",
+	}
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	end := cacao.Step{
+		Type: cacao.StepTypeEnd,
+		ID:   "end--test",
+	}
+	endBranch1 := cacao.Step{
+		Type: cacao.StepTypeEnd,
+		ID:   "end--branch1",
+	}
+	endBranch2 := cacao.Step{
+		Type: cacao.StepTypeEnd,
+		ID:   "end--branch2",
+	}
+
+	stepBranch1 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--branch1",
+		Commands:     []cacao.Command{expectedCommand},
+		OnCompletion: endBranch1.ID,
+	}
+	stepBranch2 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--branch2",
+		Commands:     []cacao.Command{expectedCommand},
+		OnCompletion: endBranch2.ID,
+	}
+
+	stepParallel := cacao.Step{
+		Type:         cacao.StepTypeParallel,
+		ID:           "parallel--test",
+		NextSteps:    []string{stepBranch1.ID, stepBranch2.ID},
+		OnCompletion: end.ID,
+	}
+
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "playbook-test",
+		WorkflowStart:     stepParallel.ID,
+		PlaybookVariables: cacao.NewVariables(expectedVariables),
+		Workflow: map[string]cacao.Step{stepParallel.ID: stepParallel,
+			stepBranch1.ID: stepBranch1,
+			stepBranch2.ID: stepBranch2,
+			endBranch1.ID:  endBranch1,
+			endBranch2.ID:  endBranch2,
+			end.ID:         end},
+		AgentDefinitions: map[string]cacao.AgentTarget{expectedAgent.ID: expectedAgent},
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	metaBranch1 := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: stepBranch1.ID}
+	metaBranch2 := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: stepBranch2.ID}
+
+	uuid_mock.On("New").Return(executionId)
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	branch1Details := executors.PlaybookStepMetadata{
+		Step:      stepBranch1,
+		Targets:   playbook.TargetDefinitions,
+		Auth:      playbook.AuthenticationInfoDefinitions,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(expectedVariables),
+	}
+	branch2Details := executors.PlaybookStepMetadata{
+		Step:      stepBranch2,
+		Targets:   playbook.TargetDefinitions,
+		Auth:      playbook.AuthenticationInfoDefinitions,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(expectedVariables),
+	}
+
+	mock_action_executor.On("Execute", mock.Anything, metaBranch1, branch1Details).
+		Return(cacao.NewVariables(cacao.Variable{Name: "branch1", Value: "one"},
+			cacao.Variable{Name: "shared", Value: "from branch1"}), nil)
+	mock_action_executor.On("Execute", mock.Anything, metaBranch2, branch2Details).
+		Return(cacao.NewVariables(cacao.Variable{Name: "branch2", Value: "two"},
+			cacao.Variable{Name: "shared", Value: "from branch2"}), nil)
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	details, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, nil)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+
+	value, found := details.Variables.Find("branch1")
+	assert.Equal(t, found, true)
+	assert.Equal(t, value.Value, "one")
+	value, found = details.Variables.Find("branch2")
+	assert.Equal(t, found, true)
+	assert.Equal(t, value.Value, "two")
+	// Branches are merged in next_steps order, the last branch wins
+	value, found = details.Variables.Find("shared")
+	assert.Equal(t, found, true)
+	assert.Equal(t, value.Value, "from branch2")
+}
+
+func TestExecuteParallelFailingBranch(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	endBranch1 := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--branch1"}
+	endBranch2 := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--branch2"}
+	stepBranch1 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--branch1",
+		OnCompletion: endBranch1.ID,
+	}
+	stepBranch2 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--branch2",
+		OnCompletion: endBranch2.ID,
+	}
+	stepParallel := cacao.Step{
+		Type:      cacao.StepTypeParallel,
+		ID:        "parallel--test",
+		NextSteps: []string{stepBranch1.ID, stepBranch2.ID},
+	}
+
+	current := &playbookExecution{playbook: cacao.Playbook{
+		ID: "test",
+		Workflow: map[string]cacao.Step{stepParallel.ID: stepParallel,
+			stepBranch1.ID: stepBranch1,
+			stepBranch2.ID: stepBranch2,
+			endBranch1.ID:  endBranch1,
+			endBranch2.ID:  endBranch2},
+	}}
+	ctx := withPlaybookExecution(context.Background(), current)
+
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{StepId: stepBranch1.ID},
+		executors.PlaybookStepMetadata{Step: stepBranch1, Variables: cacao.NewVariables()}).
+		Return(cacao.NewVariables(), nil)
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{StepId: stepBranch2.ID},
+		executors.PlaybookStepMetadata{Step: stepBranch2, Variables: cacao.NewVariables()}).
+		Return(cacao.NewVariables(), errors.New("isolation failed"))
+
+	_, err := decomposer.ExecuteStep(ctx, stepParallel, cacao.NewVariables())
+	mock_action_executor.AssertExpectations(t)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, err.Error(), "parallel branch [ action--branch2 ] failed: playbook execution failed at step [ action--branch2 ]. See step log for error information")
+}
+
+func TestExecuteWorkflowException(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--test"}
+	endException := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--exception"}
+
+	step1 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test",
+		OnCompletion: end.ID,
+	}
+	stepCleanup := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--cleanup",
+		OnCompletion: endException.ID,
+	}
+
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "exception-test",
+		WorkflowStart:     step1.ID,
+		WorkflowException: stepCleanup.ID,
+		Workflow: map[string]cacao.Step{step1.ID: step1,
+			stepCleanup.ID:  stepCleanup,
+			end.ID:          end,
+			endException.ID: endException},
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	uuid_mock.On("New").Return(executionId)
+
+	stepError := errors.New("host unreachable")
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID},
+		executors.PlaybookStepMetadata{Step: step1, Variables: cacao.NewVariables()}).
+		Return(cacao.NewVariables(), stepError)
+
+	exceptionVariables := cacao.NewVariables(
+		cacao.Variable{Type: cacao.VariableTypeString, Name: "__soarca_exception_step_id__", Value: step1.ID},
+		cacao.Variable{Type: cacao.VariableTypeString, Name: "__soarca_exception_error__", Value: "host unreachable"})
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: stepCleanup.ID},
+		executors.PlaybookStepMetadata{Step: stepCleanup, Variables: exceptionVariables}).
+		Return(cacao.NewVariables(cacao.Variable{Name: "cleaned", Value: "true"}), nil)
+
+	expectedError := execution.ErrorExceptionCondition{StepId: step1.ID,
+		Err: execution.ErrorStepFailed{StepId: step1.ID, Err: stepError}}
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, expectedError, timeNow).Return()
+
+	details, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, expectedError)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+	value, found := details.Variables.Find("cleaned")
+	assert.Equal(t, found, true)
+	assert.Equal(t, value.Value, "true")
+}
+
+func TestCancelExecution(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+	executionManager := execution_manager.New()
+	decomposer.SetExecutionManager(executionManager)
+
+	end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--test"}
+	endException := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--exception"}
+
+	step2 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test2",
+		OnCompletion: end.ID,
+	}
+	step1 := cacao.Step{
+		Type:      cacao.StepTypeAction,
+		ID:        "action--test",
+		OnSuccess: step2.ID,
+		OnFailure: step2.ID,
+	}
+	stepCleanup := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--cleanup",
+		OnCompletion: endException.ID,
+	}
+
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "cancel-test",
+		WorkflowStart:     step1.ID,
+		WorkflowException: stepCleanup.ID,
+		Workflow: map[string]cacao.Step{step1.ID: step1,
+			step2.ID:        step2,
+			stepCleanup.ID:  stepCleanup,
+			end.ID:          end,
+			endException.ID: endException},
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	uuid_mock.On("New").Return(executionId)
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+
+	// The step is cancelled through the execution manager while it runs
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID},
+		executors.PlaybookStepMetadata{Step: step1, Variables: cacao.NewVariables()}).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			assert.Equal(t, executionManager.Cancel(executionId), nil)
+			<-ctx.Done()
+		}).
+		Return(cacao.NewVariables(), errors.New("step cancelled"))
+
+	var reportedError error
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, mock.Anything, timeNow).
+		Run(func(args mock.Arguments) { reportedError = args.Error(2) }).
+		Return()
+
+	_, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, errors.Is(err, context.Canceled), true)
+	assert.Equal(t, reportedError, err)
+	mock_action_executor.AssertExpectations(t)
+	mock_action_executor.AssertNumberOfCalls(t, "Execute", 1)
+	mock_reporter.AssertExpectations(t)
+
+	// The execution is no longer registered once it has ended
+	assert.Equal(t, executionManager.Cancel(executionId), execution.ErrorExecutionNotFound{ExecutionId: executionId})
+}
+
+func TestPauseAndResumeExecution(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+	executionManager := execution_manager.New()
+	decomposer.SetExecutionManager(executionManager)
+
+	end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--test"}
+	step2 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test2",
+		OnCompletion: end.ID,
+	}
+	step1 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test",
+		OnCompletion: step2.ID,
+	}
+
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "pause-test",
+		WorkflowStart:     step1.ID,
+		PlaybookVariables: cacao.NewVariables(host),
+		Workflow: map[string]cacao.Step{step1.ID: step1,
+			step2.ID: step2,
+			end.ID:   end},
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	uuid_mock.On("New").Return(executionId)
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+
+	// The execution is paused while the first step runs, while it waits before
+	// the second step the host is updated and the execution is resumed
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID},
+		executors.PlaybookStepMetadata{Step: step1, Variables: cacao.NewVariables(host)}).
+		Run(func(args mock.Arguments) {
+			assert.Equal(t, executionManager.Pause(executionId), nil)
+			go func() {
+				for {
+					variables, _ := executionManager.GetVariables(executionId)
+					if _, ok := variables.Find(host.Name); ok {
+						break
+					}
+					time.Sleep(time.Millisecond)
+				}
+				_, err := executionManager.UpdateVariables(executionId,
+					cacao.NewVariables(cacao.Variable{Name: host.Name, Value: "10.0.0.2"}))
+				assert.Equal(t, err, nil)
+				assert.Equal(t, executionManager.Resume(executionId), nil)
+			}()
+		}).
+		Return(cacao.NewVariables(), nil)
+
+	updatedHost := host
+	updatedHost.Value = "10.0.0.2"
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step2.ID},
+		executors.PlaybookStepMetadata{Step: step2, Variables: cacao.NewVariables(updatedHost)}).
+		Return(cacao.NewVariables(), nil)
+
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	_, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, nil)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
+
+func TestExecuteRegisteredExecution(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+	executionManager := execution_manager.New()
+	decomposer.SetExecutionManager(executionManager)
+
+	end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--test"}
+	step1 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test",
+		OnCompletion: end.ID,
+	}
+	playbook := cacao.Playbook{
+		ID:            "test",
+		Type:          "test",
+		Name:          "registered-test",
+		WorkflowStart: step1.ID,
+		Workflow: map[string]cacao.Step{step1.ID: step1,
+			end.ID: end},
+	}
+
+	timeNow, _ := time.Parse("2006-01-02T15:04:05.000Z", "2014-11-12T11:45:26.371Z")
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	// Registered and paused before it starts, e.g. by the scheduler while queued
+	cancelled := false
+	ctx := executionManager.Register(context.Background(), executionId, func() { cancelled = true })
+	assert.Equal(t, executionManager.Pause(executionId), nil)
+
+	ran := make(chan struct{})
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID},
+		executors.PlaybookStepMetadata{Step: step1, Variables: cacao.NewVariables()}).
+		Run(func(args mock.Arguments) { close(ran) }).
+		Return(cacao.NewVariables(), nil)
+
+	done := make(chan error)
+	go func() {
+		_, err := decomposer.ExecuteWithId(ctx, executionId, playbook)
+		done <- err
+	}()
+	select {
+	case <-ran:
+		t.Fatal("paused execution ran a step")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, executionManager.Resume(executionId), nil)
+	assert.Equal(t, <-done, nil)
+	mock_action_executor.AssertExpectations(t)
+	uuid_mock.AssertNotCalled(t, "New")
+
+	// The registration belongs to the caller and outlives the execution
+	assert.Equal(t, executionManager.Cancel(executionId), nil)
+	assert.Equal(t, cancelled, true)
+}
+
+func TestJournalCheckpointsMainWorkflow(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+	mock_journal := new(mock_journal.MockJournal)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+	decomposer.SetExecutionManager(execution_manager.New())
+	decomposer.SetJournal(mock_journal)
+
+	end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--test"}
+	step2 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test2",
+		OnCompletion: end.ID,
+	}
+	step1 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test",
+		OnCompletion: step2.ID,
+	}
+
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	output := cacao.Variable{Type: cacao.VariableTypeString, Name: "__output__", Value: "done"}
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "journal-test",
+		WorkflowStart:     step1.ID,
+		PlaybookVariables: cacao.NewVariables(host),
+		Workflow: map[string]cacao.Step{step1.ID: step1,
+			step2.ID: step2,
+			end.ID:   end},
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	uuid_mock.On("New").Return(executionId)
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID},
+		executors.PlaybookStepMetadata{Step: step1, Variables: cacao.NewVariables(host)}).
+		Return(cacao.NewVariables(output), nil)
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step2.ID},
+		executors.PlaybookStepMetadata{Step: step2, Variables: cacao.NewVariables(host, output)}).
+		Return(cacao.NewVariables(), nil)
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	// The scope variables are checked when the checkpoint is written, as the
+	// decomposer keeps updating the same scope
+	mock_journal.On("Checkpoint", executionId, playbook, step1.ID, mock.Anything).
+		Run(func(args mock.Arguments) {
+			assert.Equal(t, args.Get(3), cacao.NewVariables(host))
+		}).Return().Once()
+	mock_journal.On("Checkpoint", executionId, playbook, step2.ID, mock.Anything).
+		Run(func(args mock.Arguments) {
+			assert.Equal(t, args.Get(3), cacao.NewVariables(host, output))
+		}).Return().Once()
+
+	_, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, nil)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+	mock_journal.AssertExpectations(t)
+}
+
+func TestResumeJournaledExecution(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+	mock_journal := new(mock_journal.MockJournal)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+	decomposer.SetJournal(mock_journal)
+
+	end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--test"}
+	step2 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test2",
+		OnCompletion: end.ID,
+	}
+	step1 := cacao.Step{
+		Type:         cacao.StepTypeAction,
+		ID:           "action--test",
+		OnCompletion: step2.ID,
+	}
+
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	output := cacao.Variable{Type: cacao.VariableTypeString, Name: "__output__", Value: "done"}
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "journal-test",
+		WorkflowStart:     step1.ID,
+		PlaybookVariables: cacao.NewVariables(host),
+		Workflow: map[string]cacao.Step{step1.ID: step1,
+			step2.ID: step2,
+			end.ID:   end},
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	record := journal.ExecutionRecord{ExecutionId: executionId.String(),
+		Playbook:  playbook,
+		StepId:    step2.ID,
+		Variables: cacao.NewVariables(host, output)}
+
+	// Only the step that did not complete before the restart is executed,
+	// under the original execution id
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+	mock_journal.On("Checkpoint", executionId, playbook, step2.ID, cacao.NewVariables(host, output)).Return()
+	mock_action_executor.On("Execute",
+		mock.Anything,
+		execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step2.ID},
+		executors.PlaybookStepMetadata{Step: step2, Variables: cacao.NewVariables(host, output)}).
+		Return(cacao.NewVariables(), nil)
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	details, err := decomposer.Resume(context.Background(), record)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, details.ExecutionId, executionId)
+	mock_action_executor.AssertExpectations(t)
+	mock_action_executor.AssertNumberOfCalls(t, "Execute", 1)
+	mock_reporter.AssertExpectations(t)
+	mock_journal.AssertExpectations(t)
+	uuid_mock.AssertNotCalled(t, "New")
+}
+
+func TestInterruptJournaledExecution(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	playbook := cacao.Playbook{ID: "test", Type: "test", Name: "journal-test"}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	record := journal.ExecutionRecord{ExecutionId: executionId.String(),
+		Playbook: playbook,
+		StepId:   "action--test"}
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook,
+		execution.ErrorExecutionInterrupted{ExecutionId: executionId, StepId: "action--test"}, timeNow).Return()
+
+	err := decomposer.Interrupt(record)
+	assert.Equal(t, err, nil)
+	mock_reporter.AssertExpectations(t)
+	mock_action_executor.AssertNotCalled(t, "Execute")
+}
+
+func TestStepOnlyGetsInArgs(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	expectedAgent := cacao.AgentTarget{
+		ID:   "agent1",
+		Type: "soarca",
+		Name: "soarca-ssh",
+	}
+
+	token := cacao.Variable{Type: cacao.VariableTypeString, Name: "__token__", Value: "secret", Constant: true}
+	ioc := cacao.Variable{Type: cacao.VariableTypeIpv4Address, Name: "__ioc__", Value: "10.0.0.1"}
+	stepVariable := cacao.Variable{Type: cacao.VariableTypeString, Name: "__port__", Value: "22"}
+
+	step1 := cacao.Step{
+		Type:          "action",
+		ID:            "action--test",
+		Commands:      []cacao.Command{{Type: "ssh", Command: "ssh block __ioc__:value"}},
+		Agent:         "agent1",
+		InArgs:        []string{"__ioc__"},
+		StepVariables: cacao.NewVariables(stepVariable),
+		OnCompletion:  "end--test",
+	}
+	end := cacao.Step{
+		Type: "end",
+		ID:   "end--test",
+	}
+
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "in-args-test",
+		WorkflowStart:     step1.ID,
+		AgentDefinitions:  map[string]cacao.AgentTarget{"agent1": expectedAgent},
+		PlaybookVariables: cacao.NewVariables(token, ioc),
+		Workflow:          map[string]cacao.Step{step1.ID: step1, end.ID: end},
+	}
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	metaStep1 := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID}
+
+	uuid_mock.On("New").Return(executionId)
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+
+	// The constant token is not passed to the step
+	playbookStepMetadata1 := executors.PlaybookStepMetadata{
+		Step:      step1,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(ioc, stepVariable),
+	}
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata1).Return(cacao.NewVariables(), nil)
+
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return()
+
+	_, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, nil)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
+
+func TestStepCannotOverwriteConstantVariable(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	expectedAgent := cacao.AgentTarget{
+		ID:   "agent1",
+		Type: "soarca",
+		Name: "soarca-ssh",
+	}
+
+	token := cacao.Variable{Type: cacao.VariableTypeString, Name: "__token__", Value: "secret", Constant: true}
+
+	step1 := cacao.Step{
+		Type:         "action",
+		ID:           "action--test",
+		Commands:     []cacao.Command{{Type: "ssh", Command: "ssh ls -la"}},
+		Agent:        "agent1",
+		OnCompletion: "end--test",
+	}
+	end := cacao.Step{
+		Type: "end",
+		ID:   "end--test",
+	}
+
+	playbook := cacao.Playbook{
+		ID:                "test",
+		Type:              "test",
+		Name:              "constant-test",
+		WorkflowStart:     step1.ID,
+		AgentDefinitions:  map[string]cacao.AgentTarget{"agent1": expectedAgent},
+		PlaybookVariables: cacao.NewVariables(token),
+		Workflow:          map[string]cacao.Step{step1.ID: step1, end.ID: end},
+	}
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	metaStep1 := execution.Metadata{ExecutionId: executionId, PlaybookId: "test", StepId: step1.ID}
+
+	uuid_mock.On("New").Return(executionId)
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+
+	playbookStepMetadata1 := executors.PlaybookStepMetadata{
+		Step:      step1,
+		Agent:     expectedAgent,
+		Variables: cacao.NewVariables(token),
+	}
+	overwritten := cacao.Variable{Type: cacao.VariableTypeString, Name: "__token__", Value: "stolen"}
+	mock_action_executor.On("Execute", mock.Anything, metaStep1, playbookStepMetadata1).
+		Return(cacao.NewVariables(overwritten), nil)
+
+	expectedError := execution.ErrorStepFailed{StepId: step1.ID,
+		Err: cacao.ErrorConstantVariable{Names: []string{"__token__"}}}
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, expectedError, timeNow).Return()
+
+	_, err := decomposer.Execute(context.Background(), playbook)
+	assert.Equal(t, err, expectedError)
+	mock_action_executor.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
+
+func TestConcurrentExecutions(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	// One decomposer runs both executions
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		new(guid.Guid),
+		mock_reporter,
+		mock_time)
+
+	newPlaybook := func(id string) cacao.Playbook {
+		step := cacao.Step{Type: cacao.StepTypeAction, ID: "action--" + id, OnCompletion: "end--" + id}
+		end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--" + id}
+		return cacao.Playbook{ID: id,
+			WorkflowStart: step.ID,
+			Workflow:      map[string]cacao.Step{step.ID: step, end.ID: end}}
+	}
+	playbooks := []cacao.Playbook{newPlaybook("playbook1"), newPlaybook("playbook2")}
+
+	mock_time.On("Now").Return(time.Now())
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+	mock_reporter.On("ReportWorkflowStart", mock.Anything, mock.Anything, mock.Anything).Return()
+	mock_reporter.On("ReportWorkflowEnd", mock.Anything, mock.Anything, nil, mock.Anything).Return()
+
+	// Both executions are inside their step at the same time
+	var inStep sync.WaitGroup
+	inStep.Add(len(playbooks))
+	seen := make([]execution.Metadata, len(playbooks))
+	for index, playbook := range playbooks {
+		stepId := playbook.WorkflowStart
+		mock_action_executor.On("Execute",
+			mock.Anything,
+			mock.MatchedBy(func(metadata execution.Metadata) bool {
+				return metadata.PlaybookId == playbook.ID && metadata.StepId == stepId
+			}),
+			executors.PlaybookStepMetadata{Step: playbook.Workflow[stepId], Variables: cacao.NewVariables()}).
+			Run(func(args mock.Arguments) {
+				seen[index] = args.Get(1).(execution.Metadata)
+				inStep.Done()
+				inStep.Wait()
+			}).
+			Return(cacao.NewVariables(), nil).Once()
+	}
+
+	details := make([]*ExecutionDetails, len(playbooks))
+	var executions sync.WaitGroup
+	for index, playbook := range playbooks {
+		executions.Add(1)
+		go func() {
+			defer executions.Done()
+			result, err := decomposer.Execute(context.Background(), playbook)
+			assert.Equal(t, err, nil)
+			details[index] = result
+		}()
+	}
+	executions.Wait()
+
+	for index, playbook := range playbooks {
+		assert.Equal(t, details[index].PlaybookId, playbook.ID)
+		assert.Equal(t, seen[index].ExecutionId, details[index].ExecutionId)
+	}
+	assert.NotEqual(t, details[0].ExecutionId, details[1].ExecutionId)
+	mock_action_executor.AssertExpectations(t)
+}
+
+func TestWhileConditionStopsAtMaxIterations(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+
+	end := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--test"}
+	endTrue := cacao.Step{Type: cacao.StepTypeEnd, ID: "end--true"}
+	stepTrue := cacao.Step{Type: cacao.StepTypeAction, ID: "action--step-true", OnCompletion: endTrue.ID}
+	stepWhile := cacao.Step{
+		Type:         cacao.StepTypeWhileCondition,
+		ID:           "while-condition--test",
+		Condition:    "[__var1__:value = 'testing']",
+		OnTrue:       stepTrue.ID,
+		OnCompletion: end.ID,
+		StepExtensions: cacao.Extensions{LoopExtensionId: map[string]interface{}{
+			"max_iterations": 2,
+		}},
+	}
+	playbook := cacao.Playbook{
+		ID:            "test",
+		WorkflowStart: stepWhile.ID,
+		Workflow: map[string]cacao.Step{stepWhile.ID: stepWhile,
+			stepTrue.ID: stepTrue,
+			end.ID:      end,
+			endTrue.ID:  endTrue},
+	}
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	uuid_mock.On("New").Return(executionId)
+	mock_time.On("Now").Return(timeNow)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+	mock_reporter.On("ReportWorkflowStart", executionId, playbook, timeNow).Return()
+	mock_reporter.On("ReportLoopIteration", executionId, stepWhile, mock.Anything, timeNow).Return()
+	mock_reporter.On("ReportWorkflowEnd", executionId, playbook, mock.Anything, timeNow).Return()
+
+	// The condition keeps holding
+	mock_condition_executor.On("Execute", mock.Anything, mock.Anything).Return(stepTrue.ID, true, nil)
+
+	iterations := []string{}
+	mock_action_executor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		details := args.Get(2).(executors.PlaybookStepMetadata)
+		iterations = append(iterations, details.Variables["__soarca_loop_iteration__"].Value)
+	}).Return(cacao.NewVariables(), nil)
+
+	_, err := decomposer.Execute(context.Background(), playbook)
+	limitErr := execution.ErrorLoopLimit{}
+	assert.Equal(t, errors.As(err, &limitErr), true)
+	assert.Equal(t, limitErr, execution.ErrorLoopLimit{StepId: stepWhile.ID, Iterations: 2})
+	assert.Equal(t, iterations, []string{"1", "2"})
+	mock_condition_executor.AssertNumberOfCalls(t, "Execute", 3)
+	mock_reporter.AssertCalled(t, "ReportLoopIteration", executionId, stepWhile, 1, timeNow)
+	mock_reporter.AssertCalled(t, "ReportLoopIteration", executionId, stepWhile, 2, timeNow)
+}
+
+func TestWhileConditionStopsAtMaxDuration(t *testing.T) {
+	mock_action_executor := new(mock_executor.Mock_Action_Executor)
+	mock_playbook_action_executor := new(mock_playbook_action_executor.Mock_PlaybookActionExecutor)
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	uuid_mock := new(mock_guid.Mock_Guid)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(mock_action_executor,
+		mock_playbook_action_executor,
+		mock_condition_executor,
+		uuid_mock,
+		mock_reporter,
+		mock_time)
+	decomposer.SetLoopLimits(LoopLimits{MaxIterations: 10, MaxDuration: 1000})
+
+	stepWhile := cacao.Step{
+		Type:         cacao.StepTypeWhileCondition,
+		ID:           "while-condition--test",
+		Condition:    "[__var1__:value = 'testing']",
+		OnTrue:       "action--step-true",
+		OnCompletion: "end--test",
+	}
+
+	// The condition is still true after the loop has run for two seconds
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+	mock_time.On("Now").Return(timeNow).Once()
+	mock_time.On("Now").Return(timeNow.Add(2 * time.Second))
+	mock_condition_executor.On("Execute", mock.Anything, mock.Anything).Return("action--step-true", true, nil)
+
+	_, err := decomposer.ExecuteStep(context.Background(), stepWhile, cacao.NewVariables())
+	assert.Equal(t, err, execution.ErrorLoopLimit{StepId: stepWhile.ID, Duration: time.Second})
+	mock_action_executor.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	mock_reporter.AssertNotCalled(t, "ReportLoopIteration", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWhileConditionWithInvalidLimits(t *testing.T) {
+	mock_condition_executor := new(mock_condition_executor.Mock_Condition)
+	mock_time := new(mock_time.MockTime)
+
+	decomposer := New(new(mock_executor.Mock_Action_Executor),
+		new(mock_playbook_action_executor.Mock_PlaybookActionExecutor),
+		mock_condition_executor,
+		new(mock_guid.Mock_Guid),
+		new(mock_reporter.Mock_Reporter),
+		mock_time)
+
+	stepWhile := cacao.Step{
+		Type:      cacao.StepTypeWhileCondition,
+		ID:        "while-condition--test",
+		Condition: "[__var1__:value = 'testing']",
+		StepExtensions: cacao.Extensions{LoopExtensionId: map[string]interface{}{
+			"max_iterations": -1,
+		}},
+	}
+	mock_time.On("Sleep", time.Millisecond*0).Return()
+
+	_, err := decomposer.ExecuteStep(context.Background(), stepWhile, cacao.NewVariables())
+	assert.NotEqual(t, err, nil)
+	mock_condition_executor.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+}
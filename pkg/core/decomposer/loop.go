@@ -0,0 +1,99 @@
+package decomposer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"soarca/pkg/core/executors"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+
+	t "time"
+)
+
+// Step extension overriding the loop limits of a while-condition step (not part of CACAO spec)
+const LoopExtensionId = "extension-definition--9a2d4c6e-1f3b-4d5a-8e7c-b0f2a4c6e8d1"
+
+// Exposed to the loop body, counts the iterations starting at 1
+const loopIterationVariableName = "__soarca_loop_iteration__"
+
+const (
+	DefaultLoopMaxIterations = 1000
+	DefaultLoopMaxDuration   = 60 * 60 * 1000
+)
+
+// The duration is in milliseconds and is checked before every iteration
+type LoopLimits struct {
+	MaxIterations int `json:"max_iterations,omitempty"`
+	MaxDuration   int `json:"max_duration,omitempty"`
+}
+
+// Set the limits of while-condition steps without the loop extension
+func (decomposer *Decomposer) SetLoopLimits(limits LoopLimits) {
+	decomposer.loopLimits = limits
+}
+
+// Get the limits of a loop, the step extension overrides the configured limits
+func (decomposer *Decomposer) loopLimitsOf(step cacao.Step) (LoopLimits, error) {
+	limits := decomposer.loopLimits
+	if _, err := cacao.DecodeExtension(step.StepExtensions, LoopExtensionId, &limits); err != nil {
+		return LoopLimits{}, fmt.Errorf("invalid loop limits of step [ %s ]: %w", step.ID, err)
+	}
+	if limits.MaxIterations < 1 || limits.MaxDuration < 1 {
+		return LoopLimits{}, fmt.Errorf("invalid loop limits of step [ %s ]: %w", step.ID,
+			errors.New("max_iterations and max_duration must be at least 1"))
+	}
+	return limits, nil
+}
+
+func (decomposer *Decomposer) executeLoop(ctx context.Context,
+	step cacao.Step,
+	variables cacao.Variables) (cacao.Variables, error) {
+	metadata := playbookExecutionFrom(ctx).metadata(step.ID)
+
+	limits, err := decomposer.loopLimitsOf(step)
+	if err != nil {
+		return cacao.NewVariables(), err
+	}
+	maxDuration := t.Duration(limits.MaxDuration) * t.Millisecond
+	started := decomposer.time.Now()
+
+	for iteration := 1; ; iteration++ {
+		if ctx.Err() != nil {
+			return variables, fmt.Errorf("loop %s cancelled: %w", step.ID, ctx.Err())
+		}
+
+		stepId, loop, err := decomposer.conditionExecutor.Execute(metadata,
+			executors.Context{Step: step, Variables: variables})
+		if err != nil {
+			return cacao.NewVariables(), err
+		}
+		if !loop {
+			return variables, nil
+		}
+
+		if iteration > limits.MaxIterations {
+			return variables, execution.ErrorLoopLimit{StepId: step.ID, Iterations: limits.MaxIterations}
+		}
+		if elapsed := decomposer.time.Now().Sub(started); elapsed >= maxDuration {
+			return variables, execution.ErrorLoopLimit{StepId: step.ID, Iterations: iteration - 1, Duration: maxDuration}
+		}
+		decomposer.reporter.ReportLoopIteration(metadata.ExecutionId, step, iteration, decomposer.time.Now())
+
+		// The counter only lives in the body, an enclosing loop keeps its own
+		bodyVariables := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeInt,
+			Name:  loopIterationVariableName,
+			Value: strconv.Itoa(iteration)})
+		bodyVariables.InsertRange(variables)
+		branchVariables, err := decomposer.ExecuteBranch(ctx, stepId, bodyVariables)
+		if err != nil {
+			return variables, err
+		}
+		delete(branchVariables, loopIterationVariableName)
+		if err := variables.Merge(branchVariables); err != nil {
+			return variables, err
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package execution_manager
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"soarca/internal/logger"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	component = reflect.TypeOf(ExecutionManager{}).PkgPath()
+	log       *logger.Log
+)
+
+func init() {
+	log = logger.Logger(component, logger.Info, "", logger.Json)
+}
+
+// Keeps track of the running executions so they can be controlled from the API
+type IExecutionManager interface {
+	// Register a running execution, the returned context carries its state
+	Register(ctx context.Context, executionId uuid.UUID, cancel context.CancelFunc) context.Context
+	Deregister(executionId uuid.UUID)
+	Cancel(executionId uuid.UUID) error
+	Pause(executionId uuid.UUID) error
+	Resume(executionId uuid.UUID) error
+	GetVariables(executionId uuid.UUID) (cacao.Variables, error)
+	UpdateVariables(executionId uuid.UUID, variables cacao.Variables) (cacao.Variables, error)
+	// Block at a step boundary while the execution is paused
+	AwaitResume(ctx context.Context, executionId uuid.UUID, scopeVariables cacao.Variables) error
+}
+
+// Informed when an execution is paused or resumed, e.g. the cache behind the reporter API
+type IExecutionStateReporter interface {
+	ReportExecutionPaused(executionId uuid.UUID) error
+	ReportExecutionResumed(executionId uuid.UUID) error
+}
+
+// State of a running execution as seen by the capabilities executing its steps
+type IExecutionState interface {
+	ExecutionId() uuid.UUID
+	// Returns whether the execution is paused and a channel that is closed on
+	// the next change of the paused state
+	Paused() (bool, <-chan struct{})
+}
+
+type stateKey struct{}
+
+// Get the state of the execution a context belongs to
+func StateFromContext(ctx context.Context) (IExecutionState, bool) {
+	state, ok := ctx.Value(stateKey{}).(IExecutionState)
+	return state, ok
+}
+
+type ExecutionManager struct {
+	mutex         sync.Mutex
+	executions    map[uuid.UUID]*executionEntry
+	stateReporter IExecutionStateReporter
+}
+
+type executionEntry struct {
+	manager     *ExecutionManager
+	executionId uuid.UUID
+	cancel      context.CancelFunc
+	paused      bool
+	changed     chan struct{}
+	// Scopes of the branches waiting at a step boundary, keyed by arrival
+	waiting map[int]cacao.Variables
+	next    int
+	// Variable updates made while paused, applied to branches that arrive later
+	updates cacao.Variables
+	// Execution of the playbook action that started this one, uuid.Nil if none
+	parent uuid.UUID
+}
+
+func New() *ExecutionManager {
+	return &ExecutionManager{executions: map[uuid.UUID]*executionEntry{}}
+}
+
+func (manager *ExecutionManager) SetStateReporter(stateReporter IExecutionStateReporter) {
+	manager.stateReporter = stateReporter
+}
+
+func (manager *ExecutionManager) Register(ctx context.Context,
+	executionId uuid.UUID,
+	cancel context.CancelFunc) context.Context {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	log.Trace("registering execution ", executionId)
+	entry := &executionEntry{manager: manager,
+		executionId: executionId,
+		cancel:      cancel,
+		changed:     make(chan struct{}),
+		waiting:     map[int]cacao.Variables{},
+		updates:     cacao.NewVariables()}
+	// A child execution started while its parent is paused starts paused
+	if state, ok := StateFromContext(ctx); ok && state.ExecutionId() != executionId {
+		entry.parent = state.ExecutionId()
+		if parent, found := manager.executions[entry.parent]; found {
+			entry.paused = parent.paused
+		}
+	}
+	manager.executions[executionId] = entry
+	return context.WithValue(ctx, stateKey{}, IExecutionState(entry))
+}
+
+func (manager *ExecutionManager) Deregister(executionId uuid.UUID) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	log.Trace("deregistering execution ", executionId)
+	delete(manager.executions, executionId)
+}
+
+// Cancel the context of a running execution. The execution is deregistered
+// by its decomposer once it has stopped. The cancel function is called without
+// holding the lock, so it may deregister the execution itself.
+func (manager *ExecutionManager) Cancel(executionId uuid.UUID) error {
+	manager.mutex.Lock()
+	entry, ok := manager.executions[executionId]
+	manager.mutex.Unlock()
+	if !ok {
+		return execution.ErrorExecutionNotFound{ExecutionId: executionId}
+	}
+	log.Info("cancelling execution ", executionId)
+	entry.cancel()
+	return nil
+}
+
+// Pause an execution before its next step. Steps that are running complete,
+// child executions of running playbook actions are paused with it.
+func (manager *ExecutionManager) Pause(executionId uuid.UUID) error {
+	executionIds, err := manager.setPaused(executionId, true)
+	if err != nil {
+		return err
+	}
+	for _, executionId := range executionIds {
+		log.Info("pausing execution ", executionId)
+		if manager.stateReporter != nil {
+			if err := manager.stateReporter.ReportExecutionPaused(executionId); err != nil {
+				log.Warning(err)
+			}
+		}
+	}
+	return nil
+}
+
+// Resume a paused execution and the child executions paused with it
+func (manager *ExecutionManager) Resume(executionId uuid.UUID) error {
+	executionIds, err := manager.setPaused(executionId, false)
+	if err != nil {
+		return err
+	}
+	for _, executionId := range executionIds {
+		log.Info("resuming execution ", executionId)
+		if manager.stateReporter != nil {
+			if err := manager.stateReporter.ReportExecutionResumed(executionId); err != nil {
+				log.Warning(err)
+			}
+		}
+	}
+	return nil
+}
+
+// Set the paused state of an execution and its registered descendants.
+// Returns the executions whose state changed, the execution itself first.
+func (manager *ExecutionManager) setPaused(executionId uuid.UUID, paused bool) ([]uuid.UUID, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	entry, ok := manager.executions[executionId]
+	if !ok {
+		return nil, execution.ErrorExecutionNotFound{ExecutionId: executionId}
+	}
+	if entry.paused == paused {
+		return nil, execution.ErrorExecutionState{ExecutionId: executionId, Paused: paused}
+	}
+
+	executionIds := []uuid.UUID{executionId}
+	for i := 0; i < len(executionIds); i++ {
+		for childId, child := range manager.executions {
+			if child.parent == executionIds[i] {
+				executionIds = append(executionIds, childId)
+			}
+		}
+	}
+
+	changed := []uuid.UUID{}
+	for _, executionId := range executionIds {
+		entry := manager.executions[executionId]
+		if entry.paused == paused {
+			continue
+		}
+		entry.paused = paused
+		if !paused {
+			entry.updates = cacao.NewVariables()
+		}
+		// Wake up everybody waiting on the paused state
+		close(entry.changed)
+		entry.changed = make(chan struct{})
+		changed = append(changed, executionId)
+	}
+	return changed, nil
+}
+
+// Get the scope variables of the branches waiting at a step boundary
+func (manager *ExecutionManager) GetVariables(executionId uuid.UUID) (cacao.Variables, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	entry, err := manager.getPausedExecution(executionId)
+	if err != nil {
+		return cacao.NewVariables(), err
+	}
+	return entry.scopeVariables(), nil
+}
+
+// Update the scope variables of a paused execution. Constant variables cannot
+// be changed, the type of an existing variable must be kept and the values
+// must match their type.
+func (manager *ExecutionManager) UpdateVariables(executionId uuid.UUID,
+	variables cacao.Variables) (cacao.Variables, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	entry, err := manager.getPausedExecution(executionId)
+	if err != nil {
+		return cacao.NewVariables(), err
+	}
+
+	scope := entry.scopeVariables()
+	if err := scope.Merge(entry.updates); err != nil {
+		return cacao.NewVariables(), err
+	}
+	for name, variable := range variables {
+		variable.Name = name
+		existing, found := scope.Find(name)
+		if !found {
+			if err := variable.Validate(); err != nil {
+				return cacao.NewVariables(), err
+			}
+			continue
+		}
+		if existing.Constant {
+			return cacao.NewVariables(), fmt.Errorf("variable [ %s ] is constant and cannot be updated", name)
+		}
+		if variable.Type != "" && variable.Type != existing.Type {
+			return cacao.NewVariables(), fmt.Errorf("mismatch in variable type for [ %s ]: update type = %s, variable type = %s",
+				name, variable.Type, existing.Type)
+		}
+		existing.Value = variable.Value
+		if err := existing.Validate(); err != nil {
+			return cacao.NewVariables(), err
+		}
+	}
+
+	for name, variable := range variables {
+		variable.Name = name
+		if existing, found := scope.Find(name); found {
+			existing.Value = variable.Value
+			variable = existing
+		}
+		entry.updates.InsertOrReplace(variable)
+		for _, waiting := range entry.waiting {
+			waiting.InsertOrReplace(variable)
+		}
+	}
+	log.Info("updated variables of paused execution ", executionId)
+	return entry.scopeVariables(), nil
+}
+
+func (manager *ExecutionManager) getPausedExecution(executionId uuid.UUID) (*executionEntry, error) {
+	entry, ok := manager.executions[executionId]
+	if !ok {
+		return nil, execution.ErrorExecutionNotFound{ExecutionId: executionId}
+	}
+	if !entry.paused {
+		return nil, execution.ErrorExecutionState{ExecutionId: executionId, Paused: false}
+	}
+	return entry, nil
+}
+
+func (manager *ExecutionManager) AwaitResume(ctx context.Context,
+	executionId uuid.UUID,
+	scopeVariables cacao.Variables) error {
+	manager.mutex.Lock()
+	entry, ok := manager.executions[executionId]
+	if !ok || !entry.paused {
+		manager.mutex.Unlock()
+		return nil
+	}
+	if err := scopeVariables.Merge(entry.updates); err != nil {
+		log.Warning("could not apply variable updates: ", err)
+	}
+	key := entry.next
+	entry.next++
+	entry.waiting[key] = scopeVariables
+	changed := entry.changed
+	manager.mutex.Unlock()
+
+	log.Info("execution ", executionId, " is paused")
+	defer func() {
+		manager.mutex.Lock()
+		delete(entry.waiting, key)
+		manager.mutex.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+			manager.mutex.Lock()
+			paused := entry.paused
+			changed = entry.changed
+			manager.mutex.Unlock()
+			if !paused {
+				return nil
+			}
+		}
+	}
+}
+
+func (entry *executionEntry) ExecutionId() uuid.UUID {
+	return entry.executionId
+}
+
+func (entry *executionEntry) Paused() (bool, <-chan struct{}) {
+	entry.manager.mutex.Lock()
+	defer entry.manager.mutex.Unlock()
+	return entry.paused, entry.changed
+}
+
+// Combined view of the waiting scopes, callers must hold the manager lock
+func (entry *executionEntry) scopeVariables() cacao.Variables {
+	variables := cacao.NewVariables()
+	for _, waiting := range entry.waiting {
+		variables.InsertRange(waiting)
+	}
+	return variables
+}
@@ -0,0 +1,241 @@
+package execution_manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCancelRegisteredExecution(t *testing.T) {
+	manager := New()
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Register(ctx, executionId, cancel)
+
+	err := manager.Cancel(executionId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ctx.Err(), context.Canceled)
+}
+
+func TestCancelDeregisteredExecution(t *testing.T) {
+	manager := New()
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Register(ctx, executionId, cancel)
+	manager.Deregister(executionId)
+
+	err := manager.Cancel(executionId)
+	assert.Equal(t, err, execution.ErrorExecutionNotFound{ExecutionId: executionId})
+	assert.Equal(t, ctx.Err(), nil)
+}
+
+type mockStateReporter struct {
+	mock.Mock
+}
+
+func (reporter *mockStateReporter) ReportExecutionPaused(executionId uuid.UUID) error {
+	args := reporter.Called(executionId)
+	return args.Error(0)
+}
+
+func (reporter *mockStateReporter) ReportExecutionResumed(executionId uuid.UUID) error {
+	args := reporter.Called(executionId)
+	return args.Error(0)
+}
+
+func TestPauseAndResumeExecution(t *testing.T) {
+	manager := New()
+	reporter := new(mockStateReporter)
+	manager.SetStateReporter(reporter)
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = manager.Register(ctx, executionId, cancel)
+
+	state, ok := StateFromContext(ctx)
+	assert.Equal(t, ok, true)
+	paused, changed := state.Paused()
+	assert.Equal(t, paused, false)
+
+	reporter.On("ReportExecutionPaused", executionId).Return(nil)
+	reporter.On("ReportExecutionResumed", executionId).Return(nil)
+
+	err := manager.Pause(executionId)
+	assert.Equal(t, err, nil)
+	<-changed
+	paused, _ = state.Paused()
+	assert.Equal(t, paused, true)
+
+	err = manager.Pause(executionId)
+	assert.Equal(t, err, execution.ErrorExecutionState{ExecutionId: executionId, Paused: true})
+
+	resumed := make(chan error)
+	go func() {
+		resumed <- manager.AwaitResume(ctx, executionId, cacao.NewVariables())
+	}()
+
+	select {
+	case <-resumed:
+		t.Fatal("execution resumed while paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	err = manager.Resume(executionId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-resumed, nil)
+
+	err = manager.Resume(executionId)
+	assert.Equal(t, err, execution.ErrorExecutionState{ExecutionId: executionId, Paused: false})
+	reporter.AssertExpectations(t)
+}
+
+func TestCancelPausedExecution(t *testing.T) {
+	manager := New()
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = manager.Register(ctx, executionId, cancel)
+
+	err := manager.Pause(executionId)
+	assert.Equal(t, err, nil)
+
+	resumed := make(chan error)
+	go func() {
+		resumed <- manager.AwaitResume(ctx, executionId, cacao.NewVariables())
+	}()
+
+	err = manager.Cancel(executionId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-resumed, context.Canceled)
+}
+
+func TestPauseChildExecutions(t *testing.T) {
+	manager := New()
+	reporter := new(mockStateReporter)
+	manager.SetStateReporter(reporter)
+	parentId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	childId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	grandchildId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430ca")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parentCtx := manager.Register(ctx, parentId, cancel)
+	childCtx := manager.Register(parentCtx, childId, cancel)
+	manager.Register(childCtx, grandchildId, cancel)
+
+	for _, executionId := range []uuid.UUID{parentId, childId, grandchildId} {
+		reporter.On("ReportExecutionPaused", executionId).Return(nil).Once()
+		reporter.On("ReportExecutionResumed", executionId).Return(nil).Once()
+	}
+
+	err := manager.Pause(parentId)
+	assert.Equal(t, err, nil)
+	state, _ := StateFromContext(childCtx)
+	paused, _ := state.Paused()
+	assert.Equal(t, paused, true)
+
+	resumed := make(chan error)
+	go func() {
+		resumed <- manager.AwaitResume(childCtx, childId, cacao.NewVariables())
+	}()
+
+	// A child started while its parent is paused waits as well
+	lateId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430cb")
+	lateCtx := manager.Register(parentCtx, lateId, cancel)
+	state, _ = StateFromContext(lateCtx)
+	paused, _ = state.Paused()
+	assert.Equal(t, paused, true)
+	manager.Deregister(lateId)
+
+	err = manager.Resume(parentId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-resumed, nil)
+	state, _ = StateFromContext(childCtx)
+	paused, _ = state.Paused()
+	assert.Equal(t, paused, false)
+	reporter.AssertExpectations(t)
+}
+
+func TestUpdateVariablesOfPausedExecution(t *testing.T) {
+	manager := New()
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = manager.Register(ctx, executionId, cancel)
+
+	_, err := manager.UpdateVariables(executionId, cacao.NewVariables())
+	assert.Equal(t, err, execution.ErrorExecutionState{ExecutionId: executionId, Paused: false})
+
+	err = manager.Pause(executionId)
+	assert.Equal(t, err, nil)
+
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	port := cacao.Variable{Type: cacao.VariableTypeString, Name: "__port__", Value: "22", Constant: true}
+	scope := cacao.NewVariables(host, port)
+
+	resumed := make(chan error)
+	go func() {
+		resumed <- manager.AwaitResume(ctx, executionId, scope)
+	}()
+
+	// Wait for the branch to arrive at its checkpoint
+	for {
+		variables, err := manager.GetVariables(executionId)
+		assert.Equal(t, err, nil)
+		if len(variables) == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = manager.UpdateVariables(executionId,
+		cacao.NewVariables(cacao.Variable{Name: "__port__", Value: "2222"}))
+	assert.Equal(t, err != nil, true)
+
+	_, err = manager.UpdateVariables(executionId,
+		cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeInt, Name: "__host__", Value: "1"}))
+	assert.Equal(t, err != nil, true)
+
+	_, err = manager.UpdateVariables(executionId,
+		cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeIpv4Address, Name: "__gateway__", Value: "10.0.0"}))
+	assert.Equal(t, errors.As(err, &cacao.ErrorVariableValue{}), true)
+
+	variables, err := manager.UpdateVariables(executionId,
+		cacao.NewVariables(cacao.Variable{Name: "__host__", Value: "10.0.0.2"}))
+	assert.Equal(t, err, nil)
+
+	updatedHost := host
+	updatedHost.Value = "10.0.0.2"
+	expected := cacao.NewVariables(updatedHost, port)
+	assert.Equal(t, variables, expected)
+
+	err = manager.Resume(executionId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-resumed, nil)
+	assert.Equal(t, scope, expected)
+}
+
+func TestPauseUnknownExecution(t *testing.T) {
+	manager := New()
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	err := manager.Pause(executionId)
+	assert.Equal(t, errors.As(err, &execution.ErrorExecutionNotFound{}), true)
+	err = manager.Resume(executionId)
+	assert.Equal(t, errors.As(err, &execution.ErrorExecutionNotFound{}), true)
+}
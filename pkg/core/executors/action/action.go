@@ -1,16 +1,21 @@
 package action
 
 import (
+	"context"
+	b64 "encoding/base64"
 	"errors"
 	"fmt"
 	"reflect"
 	"soarca/internal/logger"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/core/executors"
+	"soarca/pkg/core/secrets"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 	"soarca/pkg/reporting/reporter"
+	"soarca/pkg/utils/stix/expression/comparison"
 	timeUtil "soarca/pkg/utils/time"
+	"time"
 )
 
 var component = reflect.TypeOf(Executor{}).PkgPath()
@@ -20,23 +25,30 @@ func init() {
 	log = logger.Logger(component, logger.Info, "", logger.Json)
 }
 
-func New(capabilities map[string]capability.ICapability, reporter reporter.IStepReporter, time timeUtil.ITime) *Executor {
+func New(capabilities map[string]capability.ICapability,
+	comparison comparison.IComparison,
+	reporter reporter.IStepReporter,
+	time timeUtil.ITime) *Executor {
 	var instance = Executor{}
 	instance.capabilities = capabilities
+	instance.comparison = comparison
 	instance.reporter = reporter
 	instance.time = time
 	return &instance
 }
 
 type IExecuter interface {
-	Execute(metadata execution.Metadata,
+	Execute(ctx context.Context,
+		metadata execution.Metadata,
 		step executors.PlaybookStepMetadata) (cacao.Variables, error)
 }
 
 type Executor struct {
-	capabilities map[string]capability.ICapability
-	reporter     reporter.IStepReporter
-	time         timeUtil.ITime
+	capabilities   map[string]capability.ICapability
+	comparison     comparison.IComparison
+	reporter       reporter.IStepReporter
+	time           timeUtil.ITime
+	secretProvider secrets.ISecretProvider
 }
 
 type data struct {
@@ -46,9 +58,13 @@ type data struct {
 	variables      cacao.Variables
 	agent          cacao.AgentTarget
 	step           cacao.Step
+	retryPolicy    RetryPolicy
+	retry          bool
+	outputMappings OutputMappings
 }
 
-func (executor *Executor) Execute(meta execution.Metadata,
+func (executor *Executor) Execute(ctx context.Context,
+	meta execution.Metadata,
 	metadata executors.PlaybookStepMetadata) (cacao.Variables, error) {
 
 	executor.reporter.ReportStepStart(meta.ExecutionId, metadata.Step, metadata.Variables, executor.time.Now())
@@ -65,20 +81,105 @@ func (executor *Executor) Execute(meta execution.Metadata,
 		return cacao.NewVariables(), err
 	}
 
-	returnVariables, err = executor.executeCommandFromArray(meta, metadata)
+	// Step.Timeout is in milliseconds, capabilities abort when the context is done
+	ctx, cancel := stepContext(ctx, metadata.Step.Timeout)
+	defer cancel()
+
+	returnVariables, err = executor.executeCommandFromArray(ctx, meta, metadata)
+	if err != nil && ctx.Err() != nil {
+		err = contextError(ctx.Err(), metadata.Step.Timeout, err)
+		log.Error(err)
+	}
+
+	if err != nil {
+		return returnVariables, err
+	}
+
+	// The output cannot change constant variables of the scope
+	variables := cacao.NewVariables()
+	variables.InsertRange(metadata.Variables)
+	if err = variables.Merge(returnVariables); err != nil {
+		log.Error(err)
+		return returnVariables, err
+	}
+
+	if metadata.Step.SuccessCondition != "" {
+		err = executor.evaluateSuccessCondition(metadata.Step.SuccessCondition, variables)
+	}
 	return returnVariables, err
 }
 
-func (executor *Executor) executeCommandFromArray(meta execution.Metadata,
+// The success condition is evaluated on the scope variables updated with the
+// step output. When it does not hold the step is failed, the output is still
+// returned so an on_failure branch can use it.
+func (executor *Executor) evaluateSuccessCondition(condition string,
+	variables cacao.Variables) error {
+	result, err := executor.comparison.Evaluate(condition, variables)
+	if err != nil {
+		err = fmt.Errorf("could not evaluate success condition [ %s ]: %w", condition, err)
+		log.Error(err)
+		return err
+	}
+	if !result {
+		err = fmt.Errorf("success condition [ %s ] not met", condition)
+		log.Warning(err)
+		return err
+	}
+	return nil
+}
+
+// Make sure a timed out or cancelled step can be recognised with errors.Is,
+// also when the capability did not wrap the context error
+func contextError(contextErr error, timeout int, err error) error {
+	reason := "step cancelled"
+	if errors.Is(contextErr, context.DeadlineExceeded) {
+		reason = fmt.Sprintf("step timed out after %d ms", timeout)
+	}
+	if errors.Is(err, contextErr) {
+		return fmt.Errorf("%s: %w", reason, err)
+	}
+	return fmt.Errorf("%s: %w: %w", reason, contextErr, err)
+}
+
+func stepContext(ctx context.Context, timeout int) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (executor *Executor) executeCommandFromArray(ctx context.Context,
+	meta execution.Metadata,
 	metadata executors.PlaybookStepMetadata) (cacao.Variables, error) {
 	returnVariables := cacao.NewVariables()
+	policy, retry, err := retryPolicy(metadata.Step)
+	if err != nil {
+		log.Error(err)
+		return returnVariables, err
+	}
+	fanOut, parallel, err := fanOut(metadata.Step)
+	if err != nil {
+		log.Error(err)
+		return returnVariables, err
+	}
+	mappings, err := outputMappings(metadata.Step)
+	if err != nil {
+		log.Error(err)
+		return returnVariables, err
+	}
+	if parallel {
+		return executor.executeFanOut(ctx, meta, metadata, fanOut, policy, retry, mappings)
+	}
 	for _, command := range metadata.Step.Commands {
 		// NOTE: This assumes we want to run Command for every Target individually.
 		//       Is that something we want to enforce or leave up to the capability?
 		for _, element := range metadata.Step.Targets {
-			// NOTE: What about Agent authentication?
 			target := metadata.Targets[element]
-			auth := metadata.Auth[target.AuthInfoIdentifier]
+			auth, err := authentication(metadata, target)
+			if err != nil {
+				log.Error(err)
+				return cacao.NewVariables(), err
+			}
 
 			data := data{
 				command:        command,
@@ -87,9 +188,13 @@ func (executor *Executor) executeCommandFromArray(meta execution.Metadata,
 				variables:      metadata.Variables,
 				agent:          metadata.Agent,
 				step:           metadata.Step,
+				retryPolicy:    policy,
+				retry:          retry,
+				outputMappings: mappings,
 			}
 
 			outputVariables, err := executor.executeCommands(
+				ctx,
 				meta,
 				data)
 
@@ -98,7 +203,9 @@ func (executor *Executor) executeCommandFromArray(meta execution.Metadata,
 				outputVariables = outputVariables.Select(metadata.Step.OutArgs)
 			}
 
-			returnVariables.Merge(outputVariables)
+			if mergeErr := returnVariables.Merge(outputVariables); err == nil {
+				err = mergeErr
+			}
 
 			if err != nil {
 				log.Error("Error executing Command ", err)
@@ -111,27 +218,92 @@ func (executor *Executor) executeCommandFromArray(meta execution.Metadata,
 	return returnVariables, nil
 }
 
+// Get the authentication of a command on a target. The authentication_info of
+// the step takes precedence over the one of the agent, which takes precedence
+// over the one of the target.
+func authentication(metadata executors.PlaybookStepMetadata, target cacao.AgentTarget) (cacao.AuthenticationInformation, error) {
+	for _, authInfoId := range []string{metadata.Step.AuthenticationInfo,
+		metadata.Agent.AuthInfoIdentifier,
+		target.AuthInfoIdentifier} {
+		if authInfoId == "" {
+			continue
+		}
+		auth, found := metadata.Auth[authInfoId]
+		if !found {
+			return cacao.AuthenticationInformation{}, fmt.Errorf("authentication_info %s of step [ %s ] is not defined", authInfoId, metadata.Step.ID)
+		}
+		return auth, nil
+	}
+	return cacao.AuthenticationInformation{}, nil
+}
+
+// Decode command_b64 and content_b64 into the plain fields, so capabilities get
+// a single effective command and content. A command setting both fields must
+// set them to the same value. A manual command keeps command_b64, the manual
+// API hands it to the analyst encoded.
+func decodeCommand(command cacao.Command) (cacao.Command, error) {
+	effectiveCommand, err := decodedField("command", command.Command, command.CommandB64)
+	if err != nil {
+		return cacao.Command{}, err
+	}
+	effectiveContent, err := decodedField("content", command.Content, command.ContentB64)
+	if err != nil {
+		return cacao.Command{}, err
+	}
+	command.Command, command.Content, command.ContentB64 = effectiveCommand, effectiveContent, ""
+	if command.Type != cacao.CommandTypeManual {
+		command.CommandB64 = ""
+	}
+	return command, nil
+}
+
+func decodedField(name string, plain string, encoded string) (string, error) {
+	if encoded == "" {
+		return plain, nil
+	}
+	decoded, err := b64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("could not decode %s_b64: %w", name, err)
+	}
+	if plain != "" && plain != string(decoded) {
+		return "", fmt.Errorf("%s and %s_b64 are both set but differ", name, name)
+	}
+	return string(decoded), nil
+}
+
+// Variables are interpolated in the decoded command and content
 func interpolateCommand(command cacao.Command, variables cacao.Variables) cacao.Command {
 	command.Command = variables.Interpolate(command.Command)
 	command.Content = variables.Interpolate(command.Content)
-	command.ContentB64 = variables.Interpolate(command.ContentB64)
-	for key, headers := range command.Headers {
-		var slice []string
-		for _, header := range headers {
-			slice = append(slice, variables.Interpolate(header))
+	if command.CommandB64 != "" {
+		command.CommandB64 = b64.StdEncoding.EncodeToString([]byte(command.Command))
+	}
+	// Copied, the headers of the step are shared by the targets of a fan-out
+	if command.Headers != nil {
+		headers := cacao.Headers{}
+		for key, values := range command.Headers {
+			var slice []string
+			for _, header := range values {
+				slice = append(slice, variables.Interpolate(header))
+			}
+			headers[key] = slice
 		}
-		command.Headers[key] = slice
+		command.Headers = headers
 	}
 	return command
 }
 
 func interpolatedTarget(target cacao.AgentTarget, variables cacao.Variables) cacao.AgentTarget {
-	for key, addresses := range target.Address {
-		var slice []string
-		for _, address := range addresses {
-			slice = append(slice, variables.Interpolate(address))
+	if target.Address != nil {
+		address := cacao.Addresses{}
+		for key, addresses := range target.Address {
+			var slice []string
+			for _, value := range addresses {
+				slice = append(slice, variables.Interpolate(value))
+			}
+			address[key] = slice
 		}
-		target.Address[key] = slice
+		target.Address = address
 	}
 	return target
 }
@@ -148,19 +320,51 @@ func interpolateAuthentication(authentication cacao.AuthenticationInformation, v
 
 }
 
-func (executor *Executor) executeCommands(metadata execution.Metadata,
+func (executor *Executor) executeCommands(ctx context.Context,
+	metadata execution.Metadata,
 	data data) (cacao.Variables, error) {
 
-	context := capability.Context{}
+	capabilityContext := capability.Context{}
 
 	if capability, ok := executor.capabilities[data.agent.Name]; ok {
-		context.Command = interpolateCommand(data.command, data.variables)
-		context.Target = interpolatedTarget(data.target, data.variables)
-		context.Authentication = interpolateAuthentication(data.authentication, data.variables)
-		context.Variables = data.variables
-		context.Step = data.step
-		returnVariables, err := capability.Execute(metadata, context)
-		return returnVariables, err
+		command, err := decodeCommand(data.command)
+		if err != nil {
+			err = fmt.Errorf("invalid command of step [ %s ]: %w", data.step.ID, err)
+			log.Error(err)
+			return cacao.NewVariables(), err
+		}
+		capabilityContext.Command = interpolateCommand(command, data.variables)
+		capabilityContext.Target = interpolatedTarget(data.target, data.variables)
+		capabilityContext.Authentication, err = executor.authenticate(ctx, data.authentication, data.variables)
+		if err != nil {
+			err = fmt.Errorf("could not resolve secrets of step [ %s ]: %w", data.step.ID, err)
+			log.Error(err)
+			return cacao.NewVariables(), err
+		}
+		capabilityContext.Variables = data.variables
+		capabilityContext.Step = data.step
+		var outputVariables cacao.Variables
+		if !data.retry {
+			outputVariables, err = capability.Execute(ctx, metadata, capabilityContext)
+		} else {
+			outputVariables, err = executor.executeWithRetry(ctx, metadata, data, capability, capabilityContext)
+		}
+		if err != nil {
+			return outputVariables, err
+		}
+		outputVariables, err = data.outputMappings.apply(outputVariables, data.variables)
+		if err != nil {
+			err = fmt.Errorf("invalid output of step [ %s ]: %w", data.step.ID, err)
+			log.Error(err)
+			return cacao.NewVariables(), err
+		}
+		// Outputs are checked like trigger and manual input, before they reach the scope
+		if err := outputVariables.Validate(); err != nil {
+			err = fmt.Errorf("invalid output of step [ %s ]: %w", data.step.ID, err)
+			log.Error(err)
+			return cacao.NewVariables(), err
+		}
+		return outputVariables, nil
 	} else {
 		empty := cacao.NewVariables()
 		err := errors.New(fmt.Sprint("capability: ", data.agent.Name, " is not available in soarca"))
@@ -169,3 +373,30 @@ func (executor *Executor) executeCommands(metadata execution.Metadata,
 	}
 
 }
+
+// Execute the command until it succeeds, fails with an error the policy does not
+// retry, or the attempts run out. Every attempt is reported.
+func (executor *Executor) executeWithRetry(ctx context.Context,
+	metadata execution.Metadata,
+	data data,
+	agentCapability capability.ICapability,
+	capabilityContext capability.Context) (cacao.Variables, error) {
+	policy := data.retryPolicy
+	for attempt := 1; ; attempt++ {
+		returnVariables, err := agentCapability.Execute(ctx, metadata, capabilityContext)
+		executor.reporter.ReportStepAttempt(metadata.ExecutionId, data.step, attempt, err, executor.time.Now())
+		if err == nil {
+			return returnVariables, nil
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.retryable(err) {
+			return returnVariables, err
+		}
+
+		delay := policy.delay(attempt)
+		log.Warning(fmt.Sprintf("attempt %d of %d for step [ %s ] failed, retrying in %s: %s",
+			attempt, policy.MaxAttempts, data.step.ID, delay, err))
+		if !executor.waitForRetry(ctx, delay) {
+			return returnVariables, err
+		}
+	}
+}
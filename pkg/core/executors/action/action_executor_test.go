@@ -1,20 +1,32 @@
 package action
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"math"
+	"reflect"
+	"regexp"
+	"sync"
 	"testing"
 	"time"
 
 	"soarca/pkg/core/capability"
 	"soarca/pkg/core/executors"
+	"soarca/pkg/core/secrets"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
+	httpUtil "soarca/pkg/utils/http"
 	"soarca/test/unittest/mocks/mock_capability"
 	"soarca/test/unittest/mocks/mock_reporter"
+	"soarca/test/unittest/mocks/mock_secrets"
+	mock_stix "soarca/test/unittest/mocks/mock_utils/stix"
 	mock_time "soarca/test/unittest/mocks/mock_utils/time"
 
 	"github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestExecuteStep(t *testing.T) {
@@ -25,7 +37,7 @@ func TestExecuteStep(t *testing.T) {
 
 	capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh, "http-api": mock_http}
 
-	executerObject := New(capabilities, mock_reporter, mock_time)
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
 	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
 	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
@@ -95,12 +107,14 @@ func TestExecuteStep(t *testing.T) {
 
 	mock_reporter.On("ReportStepEnd", executionId, step, cacao.NewVariables(expectedVariables), nil, timeNow).Return()
 	mock_ssh.On("Execute",
+		mock.Anything,
 		metadata,
 		context1).
 		Return(cacao.NewVariables(expectedVariables),
 			nil)
 
-	_, err := executerObject.Execute(metadata,
+	_, err := executerObject.Execute(context.Background(),
+		metadata,
 		actionMetadata)
 
 	assert.Equal(t, err, nil)
@@ -109,6 +123,165 @@ func TestExecuteStep(t *testing.T) {
 	mock_time.AssertExpectations(t)
 }
 
+func TestExecuteStepTimeout(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh}
+
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+
+	metadata := execution.Metadata{ExecutionId: executionId, PlaybookId: playbookId, StepId: stepId}
+
+	expectedCommand := cacao.Command{
+		Type:    "ssh",
+		Command: "sleep 60",
+	}
+
+	expectedTarget := cacao.AgentTarget{
+		ID:   "target1",
+		Name: "sometarget",
+	}
+
+	agent := cacao.AgentTarget{
+		Type: "ssh",
+		Name: "mock-ssh",
+	}
+
+	step := cacao.Step{
+		Type:     cacao.StepTypeAction,
+		Name:     "action test",
+		ID:       stepId,
+		Commands: []cacao.Command{expectedCommand},
+		Agent:    "mock-ssh",
+		Targets:  []string{"target1"},
+		Timeout:  10,
+	}
+
+	actionMetadata := executors.PlaybookStepMetadata{
+		Step:      step,
+		Targets:   map[string]cacao.AgentTarget{expectedTarget.ID: expectedTarget},
+		Agent:     agent,
+		Variables: cacao.NewVariables(),
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	mock_reporter.On("ReportStepStart", executionId, step, cacao.NewVariables(), timeNow).Return()
+	mock_reporter.On("ReportStepEnd", executionId, step, cacao.NewVariables(), mock.Anything, timeNow).Return()
+
+	capabilityError := errors.New("connection closed")
+	mock_ssh.On("Execute",
+		mock.Anything,
+		metadata,
+		mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			<-ctx.Done()
+		}).
+		Return(cacao.NewVariables(),
+			capabilityError)
+
+	_, err := executerObject.Execute(context.Background(),
+		metadata,
+		actionMetadata)
+
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, errors.Is(err, capabilityError), true)
+	assert.Equal(t, err.Error(), "step timed out after 10 ms: context deadline exceeded: connection closed")
+	mock_reporter.AssertExpectations(t)
+	mock_ssh.AssertExpectations(t)
+	mock_time.AssertExpectations(t)
+}
+
+func TestExecuteStepSuccessConditionNotMet(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+	mock_stix := new(mock_stix.MockStix)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh}
+
+	executerObject := New(capabilities, mock_stix, mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+
+	metadata := execution.Metadata{ExecutionId: executionId, PlaybookId: playbookId, StepId: stepId}
+
+	expectedCommand := cacao.Command{
+		Type:    "ssh",
+		Command: "ssh ls -la",
+	}
+
+	expectedTarget := cacao.AgentTarget{
+		ID:   "target1",
+		Name: "sometarget",
+	}
+
+	agent := cacao.AgentTarget{
+		Type: "ssh",
+		Name: "mock-ssh",
+	}
+
+	step := cacao.Step{
+		Type:             cacao.StepTypeAction,
+		Name:             "action test",
+		ID:               stepId,
+		Commands:         []cacao.Command{expectedCommand},
+		Agent:            "mock-ssh",
+		Targets:          []string{"target1"},
+		SuccessCondition: "__exit_code__:value = 0",
+	}
+
+	scopeVariable := cacao.Variable{Type: cacao.VariableTypeString, Name: "__var1__", Value: "testing"}
+	actionMetadata := executors.PlaybookStepMetadata{
+		Step:      step,
+		Targets:   map[string]cacao.AgentTarget{expectedTarget.ID: expectedTarget},
+		Agent:     agent,
+		Variables: cacao.NewVariables(scopeVariable),
+	}
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	outputVariable := cacao.Variable{Type: cacao.VariableTypeInt, Name: "__exit_code__", Value: "1"}
+	expectedError := errors.New("success condition [ __exit_code__:value = 0 ] not met")
+
+	mock_reporter.On("ReportStepStart", executionId, step, cacao.NewVariables(scopeVariable), timeNow).Return()
+	mock_reporter.On("ReportStepEnd", executionId, step, cacao.NewVariables(outputVariable), expectedError, timeNow).Return()
+	mock_ssh.On("Execute",
+		mock.Anything,
+		metadata,
+		mock.Anything).
+		Return(cacao.NewVariables(outputVariable),
+			nil)
+	mock_stix.On("Evaluate",
+		"__exit_code__:value = 0",
+		cacao.NewVariables(scopeVariable, outputVariable)).
+		Return(false, nil)
+
+	variables, err := executerObject.Execute(context.Background(),
+		metadata,
+		actionMetadata)
+
+	assert.Equal(t, err, expectedError)
+	assert.Equal(t, variables, cacao.NewVariables(outputVariable))
+	mock_reporter.AssertExpectations(t)
+	mock_ssh.AssertExpectations(t)
+	mock_stix.AssertExpectations(t)
+	mock_time.AssertExpectations(t)
+}
+
 func TestExecuteActionStep(t *testing.T) {
 	mock_ssh := new(mock_capability.Mock_Capability)
 	mock_http := new(mock_capability.Mock_Capability)
@@ -117,7 +290,7 @@ func TestExecuteActionStep(t *testing.T) {
 
 	capabilities := map[string]capability.ICapability{"ssh": mock_ssh, "http-api": mock_http}
 
-	executerObject := New(capabilities, mock_reporter, mock_time)
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
 	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
 	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
@@ -156,6 +329,7 @@ func TestExecuteActionStep(t *testing.T) {
 	}
 
 	mock_ssh.On("Execute",
+		mock.Anything,
 		metadata,
 		context1).
 		Return(cacao.NewVariables(expectedVariables),
@@ -167,7 +341,8 @@ func TestExecuteActionStep(t *testing.T) {
 		variables:      cacao.NewVariables(expectedVariables),
 		agent:          agent}
 
-	_, err := executerObject.executeCommands(metadata,
+	_, err := executerObject.executeCommands(context.Background(),
+		metadata,
 		data)
 
 	assert.Equal(t, err, nil)
@@ -183,7 +358,7 @@ func TestNonExistingCapabilityStep(t *testing.T) {
 
 	capabilities := map[string]capability.ICapability{"ssh": mock_ssh, "http-api": mock_http}
 
-	executerObject := New(capabilities, new(mock_reporter.Mock_Reporter), mock_time)
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), mock_time)
 	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
 	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
@@ -219,7 +394,8 @@ func TestNonExistingCapabilityStep(t *testing.T) {
 		target:         expectedTarget,
 		variables:      cacao.NewVariables(expectedVariables),
 		agent:          agent}
-	_, err := executerObject.executeCommands(metadata,
+	_, err := executerObject.executeCommands(context.Background(),
+		metadata,
 		data)
 
 	assert.Equal(t, err, errors.New("capability: non-existing is not available in soarca"))
@@ -233,7 +409,7 @@ func TestVariableInterpolation(t *testing.T) {
 
 	capabilities := map[string]capability.ICapability{"cap1": mock_capability1}
 
-	executerObject := New(capabilities, new(mock_reporter.Mock_Reporter), mock_time)
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), mock_time)
 	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
 	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
@@ -373,6 +549,7 @@ func TestVariableInterpolation(t *testing.T) {
 		Variables:      cacao.NewVariables(var1, var2, var3, varUser, varPassword, varOauth, varPrivateKey, varToken, varUserId, varheader1, varheader2)}
 
 	mock_capability1.On("Execute",
+		mock.Anything,
 		metadata,
 		context1).
 		Return(cacao.NewVariables(var1),
@@ -384,27 +561,28 @@ func TestVariableInterpolation(t *testing.T) {
 		variables:      cacao.NewVariables(var1, var2, var3, varUser, varPassword, varOauth, varPrivateKey, varToken, varUserId, varheader1, varheader2),
 		agent:          agent}
 
-	_, err := executerObject.executeCommands(metadata,
+	_, err := executerObject.executeCommands(context.Background(),
+		metadata,
 		data1)
 
 	assert.Equal(t, err, nil)
 	mock_capability1.AssertExpectations(t)
 	assert.Equal(t, inputCommand.Command, "ssh __var1__:value")
 
+	// Variables are interpolated in the decoded content
 	httpCommand := cacao.Command{
 		Type:       "http-api",
 		Command:    "GET / HTTP1.1",
 		Content:    "__http-api-content__:value",
-		ContentB64: "__http-api-content__:value",
+		ContentB64: base64.StdEncoding.EncodeToString([]byte("__http-api-content__:value")),
 		Headers:    inputHeaders,
 	}
 
 	expectedHttpCommand := cacao.Command{
-		Type:       "http-api",
-		Command:    "GET / HTTP1.1",
-		Content:    "some content of the body",
-		ContentB64: "some content of the body",
-		Headers:    expectedHeaders,
+		Type:    "http-api",
+		Command: "GET / HTTP1.1",
+		Content: "some content of the body",
+		Headers: expectedHeaders,
 	}
 
 	metadataHttp := execution.Metadata{ExecutionId: executionId, PlaybookId: playbookId, StepId: stepId}
@@ -414,6 +592,7 @@ func TestVariableInterpolation(t *testing.T) {
 		Variables:      cacao.NewVariables(varHttpContent, varheader1, varheader2)}
 
 	mock_capability1.On("Execute",
+		mock.Anything,
 		metadataHttp,
 		contextHttp).
 		Return(cacao.NewVariables(var1),
@@ -425,7 +604,8 @@ func TestVariableInterpolation(t *testing.T) {
 		variables:      cacao.NewVariables(varHttpContent, varheader1, varheader2),
 		agent:          agent}
 
-	_, err = executerObject.executeCommands(metadata,
+	_, err = executerObject.executeCommands(context.Background(),
+		metadata,
 		data2)
 
 	assert.Equal(t, err, nil)
@@ -433,3 +613,736 @@ func TestVariableInterpolation(t *testing.T) {
 	mock_time.AssertExpectations(t)
 
 }
+
+func TestExecuteStepRetriedUntilSuccess(t *testing.T) {
+	mock_http := new(mock_capability.Mock_Capability)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"http-api": mock_http}
+
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+	metadata := execution.Metadata{ExecutionId: executionId, StepId: stepId}
+
+	step := cacao.Step{
+		Type:     cacao.StepTypeAction,
+		ID:       stepId,
+		Commands: []cacao.Command{{Type: "http-api", Command: "GET / HTTP/1.1"}},
+		Agent:    "http-api",
+		Targets:  []string{"target1"},
+		StepExtensions: cacao.Extensions{RetryExtensionId: map[string]interface{}{
+			"max_attempts": 3,
+			"backoff":      "fixed",
+			"delay":        1000,
+		}},
+	}
+
+	actionMetadata := executors.PlaybookStepMetadata{
+		Step:      step,
+		Targets:   map[string]cacao.AgentTarget{"target1": {ID: "target1"}},
+		Agent:     cacao.AgentTarget{Type: "http-api", Name: "http-api"},
+		Variables: cacao.NewVariables(),
+	}
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+
+	serverError := httpUtil.ErrorResponseStatus{StatusCode: 503, Body: "unavailable"}
+	output := cacao.NewVariables(cacao.Variable{Type: "string", Name: "__soarca_http_api_result__", Value: "ok"})
+	mock_http.On("Execute", mock.Anything, metadata, mock.Anything).
+		Return(cacao.NewVariables(), serverError).Twice()
+	mock_http.On("Execute", mock.Anything, metadata, mock.Anything).
+		Return(output, nil).Once()
+	mock_time.On("After", time.Second).Return(elapsed()).Twice()
+
+	mock_reporter.On("ReportStepStart", executionId, step, cacao.NewVariables(), timeNow).Return()
+	mock_reporter.On("ReportStepAttempt", executionId, step, 1, serverError, timeNow).Return().Once()
+	mock_reporter.On("ReportStepAttempt", executionId, step, 2, serverError, timeNow).Return().Once()
+	mock_reporter.On("ReportStepAttempt", executionId, step, 3, nil, timeNow).Return().Once()
+	mock_reporter.On("ReportStepEnd", executionId, step, output, nil, timeNow).Return()
+
+	result, err := executerObject.Execute(context.Background(), metadata, actionMetadata)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, output)
+	mock_reporter.AssertExpectations(t)
+	mock_http.AssertExpectations(t)
+	mock_time.AssertExpectations(t)
+}
+
+func TestExecuteStepNotRetriedOnOtherErrors(t *testing.T) {
+	mock_http := new(mock_capability.Mock_Capability)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"http-api": mock_http}
+
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+	metadata := execution.Metadata{ExecutionId: executionId, StepId: stepId}
+
+	step := cacao.Step{
+		Type:     cacao.StepTypeAction,
+		ID:       stepId,
+		Commands: []cacao.Command{{Type: "http-api", Command: "GET / HTTP/1.1"}},
+		Agent:    "http-api",
+		Targets:  []string{"target1"},
+		StepExtensions: cacao.Extensions{RetryExtensionId: map[string]interface{}{
+			"max_attempts": 3,
+			"delay":        1,
+			"retry_on":     []string{"server_error"},
+		}},
+	}
+
+	actionMetadata := executors.PlaybookStepMetadata{
+		Step:      step,
+		Targets:   map[string]cacao.AgentTarget{"target1": {ID: "target1"}},
+		Agent:     cacao.AgentTarget{Type: "http-api", Name: "http-api"},
+		Variables: cacao.NewVariables(),
+	}
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+
+	clientError := httpUtil.ErrorResponseStatus{StatusCode: 404, Body: "not found"}
+	mock_http.On("Execute", mock.Anything, metadata, mock.Anything).
+		Return(cacao.NewVariables(), clientError).Once()
+
+	mock_reporter.On("ReportStepStart", executionId, step, cacao.NewVariables(), timeNow).Return()
+	mock_reporter.On("ReportStepAttempt", executionId, step, 1, clientError, timeNow).Return().Once()
+	mock_reporter.On("ReportStepEnd", executionId, step, cacao.NewVariables(), clientError, timeNow).Return()
+
+	_, err := executerObject.Execute(context.Background(), metadata, actionMetadata)
+
+	assert.Equal(t, err, clientError)
+	mock_reporter.AssertExpectations(t)
+	mock_http.AssertExpectations(t)
+}
+
+func TestExecuteStepRetryAttemptsExhausted(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh}
+
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+	metadata := execution.Metadata{ExecutionId: executionId, StepId: stepId}
+
+	step := cacao.Step{
+		Type:     cacao.StepTypeAction,
+		ID:       stepId,
+		Commands: []cacao.Command{{Type: "ssh", Command: "ls -la"}},
+		Agent:    "mock-ssh",
+		Targets:  []string{"target1"},
+		StepExtensions: cacao.Extensions{RetryExtensionId: map[string]interface{}{
+			"max_attempts": 2,
+			"backoff":      "linear",
+			"delay":        500,
+			"retry_on":     []string{"any"},
+		}},
+	}
+
+	actionMetadata := executors.PlaybookStepMetadata{
+		Step:      step,
+		Targets:   map[string]cacao.AgentTarget{"target1": {ID: "target1"}},
+		Agent:     cacao.AgentTarget{Type: "ssh", Name: "mock-ssh"},
+		Variables: cacao.NewVariables(),
+	}
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+
+	capabilityError := errors.New("command failed")
+	mock_ssh.On("Execute", mock.Anything, metadata, mock.Anything).
+		Return(cacao.NewVariables(), capabilityError).Twice()
+	mock_time.On("After", 500*time.Millisecond).Return(elapsed()).Once()
+
+	mock_reporter.On("ReportStepStart", executionId, step, cacao.NewVariables(), timeNow).Return()
+	mock_reporter.On("ReportStepAttempt", executionId, step, 1, capabilityError, timeNow).Return().Once()
+	mock_reporter.On("ReportStepAttempt", executionId, step, 2, capabilityError, timeNow).Return().Once()
+	mock_reporter.On("ReportStepEnd", executionId, step, cacao.NewVariables(), capabilityError, timeNow).Return()
+
+	_, err := executerObject.Execute(context.Background(), metadata, actionMetadata)
+
+	assert.Equal(t, err, capabilityError)
+	mock_reporter.AssertExpectations(t)
+	mock_ssh.AssertExpectations(t)
+	mock_time.AssertExpectations(t)
+}
+
+func TestInvalidRetryPolicy(t *testing.T) {
+	step := cacao.Step{ID: "step--81eff59f-d084-4324-9e0a-59e353dbd28f",
+		StepExtensions: cacao.Extensions{RetryExtensionId: map[string]interface{}{
+			"max_attempts": 3,
+			"backoff":      "random",
+		}}}
+
+	_, _, err := retryPolicy(step)
+	assert.NotEqual(t, err, nil)
+
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: BackoffExponential, Delay: 100, MaxDelay: 300}
+	assert.Equal(t, policy.delay(1), 100*time.Millisecond)
+	assert.Equal(t, policy.delay(2), 200*time.Millisecond)
+	assert.Equal(t, policy.delay(3), 300*time.Millisecond)
+	assert.Equal(t, policy.delay(4), 300*time.Millisecond)
+
+	err = RetryPolicy{MaxAttempts: 3, Backoff: BackoffFixed, Delay: maxRetryDelay + 1}.validate()
+	assert.NotEqual(t, err, nil)
+}
+
+func TestRetryDelaySaturates(t *testing.T) {
+	exponential := RetryPolicy{MaxAttempts: 1000, Backoff: BackoffExponential, Delay: 1000}
+	assert.Equal(t, exponential.delay(10), 512*time.Second)
+	assert.Equal(t, exponential.delay(100), 24*time.Hour)
+	assert.Equal(t, exponential.delay(999), 24*time.Hour)
+
+	linear := RetryPolicy{MaxAttempts: math.MaxInt, Backoff: BackoffLinear, Delay: 1000}
+	assert.Equal(t, linear.delay(3), 3*time.Second)
+	assert.Equal(t, linear.delay(math.MaxInt-1), 24*time.Hour)
+}
+
+// Wait of a retry that has already elapsed
+func elapsed() <-chan time.Time {
+	fired := make(chan time.Time)
+	close(fired)
+	return fired
+}
+
+func TestExecuteStepFanOut(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh}
+
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+	metadata := execution.Metadata{ExecutionId: executionId, StepId: stepId}
+
+	targets := []string{"target1", "target2", "target3"}
+	step := cacao.Step{
+		Type:     cacao.StepTypeAction,
+		ID:       stepId,
+		Commands: []cacao.Command{{Type: "ssh", Command: "isolate"}},
+		Agent:    "mock-ssh",
+		Targets:  targets,
+		StepExtensions: cacao.Extensions{FanOutExtensionId: map[string]interface{}{
+			"max_concurrent": 3,
+		}},
+	}
+
+	actionMetadata := executors.PlaybookStepMetadata{
+		Step: step,
+		Targets: map[string]cacao.AgentTarget{"target1": {ID: "target1"},
+			"target2": {ID: "target2"},
+			"target3": {ID: "target3"}},
+		Agent:     cacao.AgentTarget{Type: "ssh", Name: "mock-ssh"},
+		Variables: cacao.NewVariables(),
+	}
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+	mock_reporter.On("ReportStepStart", executionId, step, cacao.NewVariables(), timeNow).Return()
+	mock_reporter.On("ReportStepEnd", executionId, step, mock.Anything, nil, timeNow).Return()
+
+	// The commands only return once all targets are executed at the same time
+	var running sync.WaitGroup
+	running.Add(len(targets))
+	for _, target := range targets {
+		output := cacao.NewVariables(cacao.Variable{Type: "string", Name: "__soarca_ssh_result__", Value: "isolated " + target})
+		mock_ssh.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+			return context.Target.ID == target
+		})).Run(func(args mock.Arguments) {
+			running.Done()
+			running.Wait()
+		}).Return(output, nil).Once()
+	}
+
+	result, err := executerObject.Execute(context.Background(), metadata, actionMetadata)
+
+	assert.Equal(t, err, nil)
+	// Merged in the order of the targets
+	assert.Equal(t, result["__soarca_ssh_result__"].Value, "isolated target3")
+
+	results := map[string]TargetResult{}
+	err = json.Unmarshal([]byte(result[TargetResultsVariableName].Value), &results)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result[TargetResultsVariableName].Type, cacao.VariableTypeDictionary)
+	for _, target := range targets {
+		assert.Equal(t, results[target], TargetResult{Status: "successfully_executed",
+			Variables: map[string]string{"__soarca_ssh_result__": "isolated " + target}})
+	}
+	mock_ssh.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
+
+func TestExecuteStepFanOutFailurePolicy(t *testing.T) {
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	capabilityError := errors.New("host unreachable")
+
+	execute := func(failurePolicy string) (cacao.Variables, error) {
+		mock_ssh := new(mock_capability.Mock_Capability)
+		mock_reporter := new(mock_reporter.Mock_Reporter)
+		mock_time := new(mock_time.MockTime)
+
+		capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh}
+		executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
+		metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+		step := cacao.Step{
+			Type:     cacao.StepTypeAction,
+			ID:       metadata.StepId,
+			Commands: []cacao.Command{{Type: "ssh", Command: "isolate"}},
+			Agent:    "mock-ssh",
+			Targets:  []string{"target1", "target2"},
+			StepExtensions: cacao.Extensions{FanOutExtensionId: map[string]interface{}{
+				"failure_policy": failurePolicy,
+			}},
+		}
+		actionMetadata := executors.PlaybookStepMetadata{
+			Step:      step,
+			Targets:   map[string]cacao.AgentTarget{"target1": {ID: "target1"}, "target2": {ID: "target2"}},
+			Agent:     cacao.AgentTarget{Type: "ssh", Name: "mock-ssh"},
+			Variables: cacao.NewVariables(),
+		}
+
+		mock_time.On("Now").Return(timeNow)
+		mock_reporter.On("ReportStepStart", mock.Anything, step, mock.Anything, timeNow).Return()
+		mock_reporter.On("ReportStepEnd", mock.Anything, step, mock.Anything, mock.Anything, timeNow).Return()
+		mock_ssh.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+			return context.Target.ID == "target1"
+		})).Return(cacao.NewVariables(), capabilityError)
+		mock_ssh.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+			return context.Target.ID == "target2"
+		})).Return(cacao.NewVariables(), nil)
+
+		return executerObject.Execute(context.Background(), metadata, actionMetadata)
+	}
+
+	// By default a single failing target fails the step
+	result, err := execute("")
+	assert.Equal(t, errors.Is(err, capabilityError), true)
+	assert.NotEqual(t, result[TargetResultsVariableName].Value, "")
+
+	result, err = execute(FailurePolicyAll)
+	assert.Equal(t, err, nil)
+	results := map[string]TargetResult{}
+	err = json.Unmarshal([]byte(result[TargetResultsVariableName].Value), &results)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, results["target1"], TargetResult{Status: "failed", Error: "host unreachable", Variables: map[string]string{}})
+	assert.Equal(t, results["target2"].Status, "successfully_executed")
+}
+
+func TestInvalidFanOut(t *testing.T) {
+	step := cacao.Step{ID: "step--81eff59f-d084-4324-9e0a-59e353dbd28f",
+		StepExtensions: cacao.Extensions{FanOutExtensionId: map[string]interface{}{
+			"failure_policy": "some",
+		}}}
+
+	_, _, err := fanOut(step)
+	assert.NotEqual(t, err, nil)
+
+	step.StepExtensions[FanOutExtensionId] = map[string]interface{}{"max_concurrent": -1}
+	_, _, err = fanOut(step)
+	assert.NotEqual(t, err, nil)
+
+	defaults, parallel, err := fanOut(cacao.Step{StepExtensions: cacao.Extensions{FanOutExtensionId: map[string]interface{}{}}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, parallel, true)
+	assert.Equal(t, defaults, FanOut{MaxConcurrent: 10, FailurePolicy: FailurePolicyAny})
+}
+
+func TestExecuteStepDecodesBase64Command(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), mock_time)
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	command := cacao.Command{
+		Type:       "ssh",
+		CommandB64: base64.StdEncoding.EncodeToString([]byte("iptables -A INPUT -s __host__:value -j DROP")),
+		ContentB64: base64.StdEncoding.EncodeToString([]byte("{\"host\": \"__host__:value\"}")),
+	}
+	expectedCommand := cacao.Command{
+		Type:    "ssh",
+		Command: "iptables -A INPUT -s 10.0.0.1 -j DROP",
+		Content: "{\"host\": \"10.0.0.1\"}",
+	}
+
+	mock_ssh.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+		return reflect.DeepEqual(context.Command, expectedCommand)
+	})).Return(cacao.NewVariables(), nil)
+
+	_, err := executerObject.executeCommands(context.Background(), metadata, data{command: command,
+		variables: cacao.NewVariables(host),
+		agent:     cacao.AgentTarget{Type: "ssh", Name: "mock-ssh"}})
+	assert.Equal(t, err, nil)
+	mock_ssh.AssertExpectations(t)
+}
+
+func TestExecuteStepKeepsBase64ManualCommand(t *testing.T) {
+	mock_manual := new(mock_capability.Mock_Capability)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"soarca-manual": mock_manual}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), mock_time)
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	command := cacao.Command{
+		Type:       cacao.CommandTypeManual,
+		CommandB64: base64.StdEncoding.EncodeToString([]byte("Isolate __host__:value")),
+	}
+	expectedCommand := cacao.Command{
+		Type:       cacao.CommandTypeManual,
+		Command:    "Isolate 10.0.0.1",
+		CommandB64: base64.StdEncoding.EncodeToString([]byte("Isolate 10.0.0.1")),
+	}
+
+	mock_manual.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+		return reflect.DeepEqual(context.Command, expectedCommand)
+	})).Return(cacao.NewVariables(), nil)
+
+	_, err := executerObject.executeCommands(context.Background(), metadata, data{command: command,
+		variables: cacao.NewVariables(host),
+		agent:     cacao.AgentTarget{Type: "soarca-manual", Name: "soarca-manual"}})
+	assert.Equal(t, err, nil)
+	mock_manual.AssertExpectations(t)
+}
+
+func TestExecuteStepRejectsConflictingBase64Command(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+
+	capabilities := map[string]capability.ICapability{"mock-ssh": mock_ssh}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime))
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+	agent := cacao.AgentTarget{Type: "ssh", Name: "mock-ssh"}
+
+	conflicting := cacao.Command{
+		Type:       "ssh",
+		Command:    "ls -la",
+		CommandB64: base64.StdEncoding.EncodeToString([]byte("rm -rf /tmp/cache")),
+	}
+	_, err := executerObject.executeCommands(context.Background(), metadata, data{command: conflicting,
+		variables: cacao.NewVariables(),
+		agent:     agent})
+	assert.NotEqual(t, err, nil)
+
+	invalid := cacao.Command{Type: "ssh", ContentB64: "not base64!"}
+	_, err = executerObject.executeCommands(context.Background(), metadata, data{command: invalid,
+		variables: cacao.NewVariables(),
+		agent:     agent})
+	assert.NotEqual(t, err, nil)
+	mock_ssh.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExecuteStepRejectsInvalidOutput(t *testing.T) {
+	mock_fin := new(mock_capability.Mock_Capability)
+
+	capabilities := map[string]capability.ICapability{"mock-fin": mock_fin}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime))
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	output := cacao.Variable{Type: cacao.VariableTypeSha256, Name: "__file_hash__", Value: "d41d8cd98f00b204e9800998ecf8427e"}
+	mock_fin.On("Execute", mock.Anything, metadata, mock.Anything).Return(cacao.NewVariables(output), nil)
+
+	result, err := executerObject.executeCommands(context.Background(), metadata, data{command: cacao.Command{Type: "manual", Command: "hash the file"},
+		step:      cacao.Step{ID: metadata.StepId},
+		variables: cacao.NewVariables(),
+		agent:     cacao.AgentTarget{Type: "soarca-fin", Name: "mock-fin"}})
+
+	assert.Equal(t, errors.As(err, &cacao.ErrorVariableValue{}), true)
+	assert.Equal(t, err.Error(), "invalid output of step [ step--81eff59f-d084-4324-9e0a-59e353dbd28f ]: "+
+		"value of variable [ __file_hash__ ] is not a valid sha256-hash: unexpected length 32")
+	assert.Equal(t, result, cacao.NewVariables())
+}
+
+func TestOutputMappingsOfStep(t *testing.T) {
+	mappings, err := outputMappings(cacao.Step{ID: "action--1"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(mappings.Variables), 0)
+
+	mappings, err = outputMappings(cacao.Step{ID: "action--1",
+		StepExtensions: cacao.Extensions{OutputMappingExtensionId: map[string]interface{}{
+			"variables": map[string]interface{}{
+				"__case_id__": map[string]interface{}{"json_path": "$.data[0].id"},
+				"__ticket__":  map[string]interface{}{"regex": "ticket ([0-9]+)", "type": "integer"},
+			}}}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, mappings.Variables["__case_id__"].JsonPath, "$.data[0].id")
+	assert.Equal(t, mappings.Variables["__ticket__"].Type, cacao.VariableTypeInt)
+
+	for _, mapping := range []map[string]interface{}{
+		{},
+		{"json_path": "$.id", "regex": "id"},
+		{"json_path": "data.id"},
+		{"json_path": "$.data[*]"},
+		{"regex": "("},
+		{"header": "Location", "source": "__soarca_http_api_result__"},
+	} {
+		_, err := outputMappings(cacao.Step{ID: "action--1",
+			StepExtensions: cacao.Extensions{OutputMappingExtensionId: map[string]interface{}{
+				"variables": map[string]interface{}{"__var__": mapping}}}})
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestExecuteCommandsWithOutputMappings(t *testing.T) {
+	mock_http := new(mock_capability.Mock_Capability)
+
+	capabilities := map[string]capability.ICapability{"http-api": mock_http}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime))
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	output := cacao.NewVariables(
+		cacao.Variable{Type: cacao.VariableTypeString, Name: "__soarca_http_api_result__",
+			Value: `{"data": [{"id": "case-42", "severity": 3}], "message": "created ticket 1234"}`},
+		cacao.Variable{Type: cacao.VariableTypeInt, Name: "__soarca_http_api_status__", Value: "201"},
+		cacao.Variable{Type: cacao.VariableTypeDictionary, Name: "__soarca_http_api_headers__",
+			Value: `{"Location": ["/cases/case-42"]}`})
+	mock_http.On("Execute", mock.Anything, metadata, mock.Anything).Return(output, nil)
+
+	step := cacao.Step{ID: metadata.StepId,
+		StepExtensions: cacao.Extensions{OutputMappingExtensionId: map[string]interface{}{
+			"variables": map[string]interface{}{
+				"__case_id__":  map[string]interface{}{"json_path": "$.data[0].id"},
+				"__severity__": map[string]interface{}{"json_path": "$.data[0].severity"},
+				"__ticket__":   map[string]interface{}{"regex": "ticket ([0-9]+)", "type": "integer"},
+				"__location__": map[string]interface{}{"header": "location"},
+				"__status__":   map[string]interface{}{"status": true},
+			}}}}
+	mappings, err := outputMappings(step)
+	assert.Equal(t, err, nil)
+
+	// Declared variables keep their type and description
+	severity := cacao.Variable{Type: cacao.VariableTypeInt, Name: "__severity__", Description: "Severity of the case"}
+	result, err := executerObject.executeCommands(context.Background(), metadata, data{command: cacao.Command{Type: "http-api", Command: "POST /cases HTTP/1.1"},
+		step:           step,
+		variables:      cacao.NewVariables(severity),
+		agent:          cacao.AgentTarget{Type: "http-api", Name: "http-api"},
+		outputMappings: mappings})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["__case_id__"], cacao.Variable{Type: cacao.VariableTypeString, Name: "__case_id__", Value: "case-42"})
+	assert.Equal(t, result["__severity__"], cacao.Variable{Type: cacao.VariableTypeInt, Name: "__severity__", Description: "Severity of the case", Value: "3"})
+	assert.Equal(t, result["__ticket__"], cacao.Variable{Type: cacao.VariableTypeInt, Name: "__ticket__", Value: "1234"})
+	assert.Equal(t, result["__location__"].Value, "/cases/case-42")
+	assert.Equal(t, result["__status__"].Value, "201")
+	assert.Equal(t, result["__soarca_http_api_result__"], output["__soarca_http_api_result__"])
+}
+
+func TestExecuteCommandsFailsOnMissingOutput(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+
+	capabilities := map[string]capability.ICapability{"ssh": mock_ssh}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime))
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	output := cacao.Variable{Type: cacao.VariableTypeString, Name: "__soarca_ssh_result__", Value: "no ticket created"}
+	mock_ssh.On("Execute", mock.Anything, metadata, mock.Anything).Return(cacao.NewVariables(output), nil)
+
+	mappings := OutputMappings{Variables: map[string]OutputMapping{
+		"__ticket__": {Regex: "ticket ([0-9]+)", pattern: regexp.MustCompile("ticket ([0-9]+)")}}}
+	result, err := executerObject.executeCommands(context.Background(), metadata, data{command: cacao.Command{Type: "ssh", Command: "create-ticket"},
+		step:           cacao.Step{ID: metadata.StepId},
+		variables:      cacao.NewVariables(),
+		agent:          cacao.AgentTarget{Type: "ssh", Name: "ssh"},
+		outputMappings: mappings})
+
+	assert.Equal(t, err.Error(), "invalid output of step [ step--81eff59f-d084-4324-9e0a-59e353dbd28f ]: "+
+		"output mapping of variable [ __ticket__ ]: regex ticket ([0-9]+) does not match __soarca_ssh_result__")
+	assert.Equal(t, result, cacao.NewVariables())
+}
+
+func TestAuthenticationPrecedence(t *testing.T) {
+	stepAuth := cacao.AuthenticationInformation{ID: "authentication-info--step", Type: cacao.AuthInfoOAuth2Type, Token: "step-token"}
+	agentAuth := cacao.AuthenticationInformation{ID: "authentication-info--agent", Type: cacao.AuthInfoHTTPBasicType, UserId: "firewall-api"}
+	targetAuth := cacao.AuthenticationInformation{ID: "authentication-info--target", Type: "user-auth", Username: "root"}
+
+	target := cacao.AgentTarget{ID: "target1", AuthInfoIdentifier: targetAuth.ID}
+	metadata := executors.PlaybookStepMetadata{
+		Step:  cacao.Step{ID: "step--81eff59f-d084-4324-9e0a-59e353dbd28f", AuthenticationInfo: stepAuth.ID},
+		Agent: cacao.AgentTarget{Name: "http-api", AuthInfoIdentifier: agentAuth.ID},
+		Auth: cacao.AuthenticationInformations{stepAuth.ID: stepAuth,
+			agentAuth.ID:  agentAuth,
+			targetAuth.ID: targetAuth},
+	}
+
+	auth, err := authentication(metadata, target)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth, stepAuth)
+
+	metadata.Step.AuthenticationInfo = ""
+	auth, err = authentication(metadata, target)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth, agentAuth)
+
+	metadata.Agent.AuthInfoIdentifier = ""
+	auth, err = authentication(metadata, target)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth, targetAuth)
+
+	auth, err = authentication(metadata, cacao.AgentTarget{ID: "target2"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth, cacao.AuthenticationInformation{})
+
+	// A reference to an undefined authentication is an error
+	metadata.Agent.AuthInfoIdentifier = "authentication-info--unknown"
+	_, err = authentication(metadata, target)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestExecuteStepWithAgentAuthentication(t *testing.T) {
+	mock_http := new(mock_capability.Mock_Capability)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	capabilities := map[string]capability.ICapability{"http-api": mock_http}
+	executerObject := New(capabilities, new(mock_stix.MockStix), mock_reporter, mock_time)
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	// The firewall manager uses its own credentials, not the ones of the host it blocks
+	agentAuth := cacao.AuthenticationInformation{ID: "authentication-info--firewall", Type: cacao.AuthInfoOAuth2Type, Token: "firewall-token"}
+	hostAuth := cacao.AuthenticationInformation{ID: "authentication-info--host", Type: "user-auth", Username: "root"}
+	step := cacao.Step{
+		Type:     cacao.StepTypeAction,
+		ID:       metadata.StepId,
+		Commands: []cacao.Command{{Type: "http-api", Command: "POST /block HTTP/1.1"}},
+		Agent:    "http-api--firewall",
+		Targets:  []string{"host1"},
+	}
+	actionMetadata := executors.PlaybookStepMetadata{
+		Step:      step,
+		Targets:   map[string]cacao.AgentTarget{"host1": {ID: "host1", AuthInfoIdentifier: hostAuth.ID}},
+		Auth:      cacao.AuthenticationInformations{agentAuth.ID: agentAuth, hostAuth.ID: hostAuth},
+		Agent:     cacao.AgentTarget{Type: "http-api", Name: "http-api", AuthInfoIdentifier: agentAuth.ID},
+		Variables: cacao.NewVariables(),
+	}
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+	mock_reporter.On("ReportStepStart", mock.Anything, step, mock.Anything, timeNow).Return()
+	mock_reporter.On("ReportStepEnd", mock.Anything, step, mock.Anything, nil, timeNow).Return()
+	mock_http.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+		return context.Authentication == agentAuth
+	})).Return(cacao.NewVariables(), nil)
+
+	_, err := executerObject.Execute(context.Background(), metadata, actionMetadata)
+	assert.Equal(t, err, nil)
+	mock_http.AssertExpectations(t)
+}
+
+func TestResolveSecrets(t *testing.T) {
+	provider := new(mock_secrets.MockSecretProvider)
+	executerObject := New(map[string]capability.ICapability{}, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime))
+	executerObject.SetSecretProvider(provider)
+
+	provider.On("Secret", mock.Anything, "secret://env/SSH_PASSWORD").Return("password", nil)
+	provider.On("Secret", mock.Anything, "firewall-token").Return("token", nil)
+	provider.On("Secret", mock.Anything, "secret://keystore/deploy-key").Return("", secrets.ErrorSecretNotFound{Name: "deploy-key"})
+
+	auth, err := executerObject.resolveSecrets(context.Background(), cacao.AuthenticationInformation{Type: cacao.AuthInfoUserAuthType,
+		Username: "root",
+		Password: "secret://env/SSH_PASSWORD"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth, cacao.AuthenticationInformation{Type: cacao.AuthInfoUserAuthType, Username: "root", Password: "password"})
+
+	// The KMS key gives the secret of the authentication type
+	auth, err = executerObject.resolveSecrets(context.Background(), cacao.AuthenticationInformation{Type: cacao.AuthInfoOAuth2Type,
+		Kms:              true,
+		KmsKeyIdentifier: "firewall-token"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth.Token, "token")
+
+	_, err = executerObject.resolveSecrets(context.Background(), cacao.AuthenticationInformation{Type: cacao.AuthInfoPrivateKeyType,
+		PrivateKey: "secret://keystore/deploy-key"})
+	assert.Equal(t, errors.As(err, &secrets.ErrorSecretNotFound{}), true)
+
+	_, err = executerObject.resolveSecrets(context.Background(), cacao.AuthenticationInformation{Type: cacao.AuthInfoHTTPBasicType, Kms: true})
+	assert.NotEqual(t, err, nil)
+
+	// Plain secrets need no provider
+	plain := cacao.AuthenticationInformation{Type: cacao.AuthInfoHTTPBasicType, UserId: "admin", Password: "password"}
+	auth, err = New(map[string]capability.ICapability{}, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime)).
+		resolveSecrets(context.Background(), plain)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth, plain)
+	provider.AssertExpectations(t)
+}
+
+func TestExecuteCommandsResolvesSecrets(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+	provider := new(mock_secrets.MockSecretProvider)
+
+	capabilities := map[string]capability.ICapability{"ssh": mock_ssh}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime))
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	auth := cacao.AuthenticationInformation{Type: cacao.AuthInfoUserAuthType, Username: "root", Password: "secret://env/SSH_PASSWORD"}
+	command := data{command: cacao.Command{Type: "ssh", Command: "ls"},
+		authentication: auth,
+		step:           cacao.Step{ID: metadata.StepId},
+		variables:      cacao.NewVariables(),
+		agent:          cacao.AgentTarget{Type: "ssh", Name: "ssh"}}
+
+	// Without a provider the reference cannot be resolved
+	_, err := executerObject.executeCommands(context.Background(), metadata, command)
+	assert.Equal(t, err.Error(), "could not resolve secrets of step [ step--81eff59f-d084-4324-9e0a-59e353dbd28f ]: no secret provider is configured")
+
+	executerObject.SetSecretProvider(provider)
+	provider.On("Secret", mock.Anything, "secret://env/SSH_PASSWORD").Return("password", nil)
+	mock_ssh.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+		return context.Authentication.Password == "password"
+	})).Return(cacao.NewVariables(), nil)
+
+	_, err = executerObject.executeCommands(context.Background(), metadata, command)
+	assert.Equal(t, err, nil)
+	mock_ssh.AssertExpectations(t)
+}
+
+func TestExecuteCommandsRejectsSecretReferenceFromVariable(t *testing.T) {
+	mock_ssh := new(mock_capability.Mock_Capability)
+	provider := new(mock_secrets.MockSecretProvider)
+
+	capabilities := map[string]capability.ICapability{"ssh": mock_ssh}
+	executerObject := New(capabilities, new(mock_stix.MockStix), new(mock_reporter.Mock_Reporter), new(mock_time.MockTime))
+	executerObject.SetSecretProvider(provider)
+	metadata := execution.Metadata{StepId: "step--81eff59f-d084-4324-9e0a-59e353dbd28f"}
+
+	variables := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  "__password__",
+		Value: "secret://file/ssh/password"})
+	command := data{command: cacao.Command{Type: "ssh", Command: "ls"},
+		authentication: cacao.AuthenticationInformation{Type: cacao.AuthInfoUserAuthType,
+			Username: "root",
+			Password: "__password__:value"},
+		step:      cacao.Step{ID: metadata.StepId},
+		variables: variables,
+		agent:     cacao.AgentTarget{Type: "ssh", Name: "ssh"}}
+
+	_, err := executerObject.executeCommands(context.Background(), metadata, command)
+	assert.Equal(t, err.Error(), "could not resolve secrets of step [ step--81eff59f-d084-4324-9e0a-59e353dbd28f ]: secret reference in password comes from a variable")
+	provider.AssertNotCalled(t, "Secret", mock.Anything, mock.Anything)
+	mock_ssh.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+
+	// A resolved secret is not interpolated
+	command.authentication.Password = "secret://env/SSH_PASSWORD"
+	provider.On("Secret", mock.Anything, "secret://env/SSH_PASSWORD").Return("__password__:value", nil)
+	mock_ssh.On("Execute", mock.Anything, metadata, mock.MatchedBy(func(context capability.Context) bool {
+		return context.Authentication.Password == "__password__:value"
+	})).Return(cacao.NewVariables(), nil)
+
+	_, err = executerObject.executeCommands(context.Background(), metadata, command)
+	assert.Equal(t, err, nil)
+	mock_ssh.AssertExpectations(t)
+}
@@ -0,0 +1,207 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"soarca/pkg/core/executors"
+	"soarca/pkg/models/api"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+)
+
+// Step extension executing the commands of an action step on its targets in
+// parallel (not part of CACAO spec)
+const FanOutExtensionId = "extension-definition--2d7e9b1c-6a4f-4c8e-b3d5-e1f0a9c7b5d3"
+
+// The step fails when any, all or none of its targets fail
+const (
+	FailurePolicyAny  = "any"
+	FailurePolicyAll  = "all"
+	FailurePolicyNone = "none"
+)
+
+// Dictionary variable holding the result of every target, keyed by target id
+const TargetResultsVariableName = "__soarca_target_results__"
+
+const defaultFanOutConcurrency = 10
+
+type FanOut struct {
+	MaxConcurrent int    `json:"max_concurrent,omitempty"`
+	FailurePolicy string `json:"failure_policy,omitempty"`
+}
+
+// Result of the commands of a step on one target
+type TargetResult struct {
+	Status    string            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	Variables map[string]string `json:"variables"`
+}
+
+// Get the fan-out of a step, a step without the extension runs its targets one after another
+func fanOut(step cacao.Step) (FanOut, bool, error) {
+	fanOut := FanOut{}
+	found, err := cacao.DecodeExtension(step.StepExtensions, FanOutExtensionId, &fanOut)
+	if !found {
+		return FanOut{}, false, nil
+	}
+	if err != nil {
+		return FanOut{}, false, fmt.Errorf("invalid fan-out of step [ %s ]: %w", step.ID, err)
+	}
+
+	if fanOut.MaxConcurrent == 0 {
+		fanOut.MaxConcurrent = defaultFanOutConcurrency
+	}
+	if fanOut.FailurePolicy == "" {
+		fanOut.FailurePolicy = FailurePolicyAny
+	}
+	if err := fanOut.validate(); err != nil {
+		return FanOut{}, false, fmt.Errorf("invalid fan-out of step [ %s ]: %w", step.ID, err)
+	}
+	return fanOut, true, nil
+}
+
+func (fanOut FanOut) validate() error {
+	if fanOut.MaxConcurrent < 1 {
+		return errors.New("max_concurrent must be at least 1")
+	}
+	switch fanOut.FailurePolicy {
+	case FailurePolicyAny, FailurePolicyAll, FailurePolicyNone:
+	default:
+		return fmt.Errorf("unknown failure policy %s", fanOut.FailurePolicy)
+	}
+	return nil
+}
+
+// Whether the step fails with the given number of failed targets
+func (fanOut FanOut) failed(failedTargets int, targets int) bool {
+	switch fanOut.FailurePolicy {
+	case FailurePolicyAll:
+		return failedTargets == targets
+	case FailurePolicyNone:
+		return false
+	default:
+		return failedTargets > 0
+	}
+}
+
+type targetOutput struct {
+	variables cacao.Variables
+	err       error
+}
+
+// Execute the commands on every target, at most MaxConcurrent targets at the
+// same time. The commands of a target are executed in order. Outputs are
+// merged in the order of the step targets, the result of every target is kept
+// in the target results variable.
+func (executor *Executor) executeFanOut(ctx context.Context,
+	meta execution.Metadata,
+	metadata executors.PlaybookStepMetadata,
+	fanOut FanOut,
+	policy RetryPolicy,
+	retry bool,
+	mappings OutputMappings) (cacao.Variables, error) {
+	targets := metadata.Step.Targets
+	outputs := make([]targetOutput, len(targets))
+
+	slots := make(chan struct{}, fanOut.MaxConcurrent)
+	var wg sync.WaitGroup
+	for index, targetId := range targets {
+		wg.Add(1)
+		go func(index int, targetId string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			outputs[index] = executor.executeOnTarget(ctx, meta, metadata, targetId, policy, retry, mappings)
+		}(index, targetId)
+	}
+	wg.Wait()
+
+	returnVariables := cacao.NewVariables()
+	results := map[string]TargetResult{}
+	targetErrors := []error{}
+	for index, targetId := range targets {
+		output := outputs[index]
+		result := TargetResult{Status: api.SuccessfullyExecuted, Variables: map[string]string{}}
+		for name, variable := range output.variables {
+			result.Variables[name] = variable.Value
+		}
+		if output.err != nil {
+			result.Status = api.Failed
+			result.Error = output.err.Error()
+			targetErrors = append(targetErrors, fmt.Errorf("target [ %s ]: %w", targetId, output.err))
+		}
+		results[targetId] = result
+		if err := returnVariables.Merge(output.variables); err != nil {
+			log.Warning(err)
+		}
+	}
+
+	raw, err := json.Marshal(results)
+	if err != nil {
+		return cacao.NewVariables(), err
+	}
+	returnVariables.InsertOrReplace(cacao.Variable{Type: cacao.VariableTypeDictionary,
+		Name:  TargetResultsVariableName,
+		Value: string(raw)})
+
+	if len(metadata.Step.OutArgs) > 0 {
+		// If OutArgs is set, only update execution args that are explicitly referenced
+		returnVariables = returnVariables.Select(metadata.Step.OutArgs)
+	}
+
+	if len(targetErrors) == 0 {
+		return returnVariables, nil
+	}
+	err = fmt.Errorf("%d of %d targets failed: %w", len(targetErrors), len(targets), errors.Join(targetErrors...))
+	if !fanOut.failed(len(targetErrors), len(targets)) {
+		log.Warning(fmt.Sprintf("step [ %s ] continues under failure policy %s: %s", metadata.Step.ID, fanOut.FailurePolicy, err))
+		return returnVariables, nil
+	}
+	log.Error(err)
+	return returnVariables, err
+}
+
+// Execute the commands of a step on a single target, stops at the first failing command
+func (executor *Executor) executeOnTarget(ctx context.Context,
+	meta execution.Metadata,
+	metadata executors.PlaybookStepMetadata,
+	targetId string,
+	policy RetryPolicy,
+	retry bool,
+	mappings OutputMappings) targetOutput {
+	target := metadata.Targets[targetId]
+	output := targetOutput{variables: cacao.NewVariables()}
+	auth, err := authentication(metadata, target)
+	if err != nil {
+		output.err = err
+		return output
+	}
+	for _, command := range metadata.Step.Commands {
+		data := data{
+			command:        command,
+			authentication: auth,
+			target:         target,
+			variables:      metadata.Variables,
+			agent:          metadata.Agent,
+			step:           metadata.Step,
+			retryPolicy:    policy,
+			retry:          retry,
+			outputMappings: mappings,
+		}
+
+		outputVariables, err := executor.executeCommands(ctx, meta, data)
+		if mergeErr := output.variables.Merge(outputVariables); err == nil {
+			err = mergeErr
+		}
+		if err != nil {
+			output.err = err
+			return output
+		}
+	}
+	return output
+}
@@ -0,0 +1,193 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	httpCapability "soarca/pkg/core/capability/http"
+	"soarca/pkg/models/cacao"
+)
+
+// Step extension mapping parts of the command output on variables (not part of CACAO spec)
+const OutputMappingExtensionId = "extension-definition--3c9f7a1e-5b2d-4e8c-a6f4-9d1b3e5c7a20"
+
+// Exactly one of JsonPath, Regex, Header and Status selects the value.
+// JsonPath and Regex read the Source variable of the output, by default the
+// result of the capability (__soarca_<capability>_result__). Header and Status
+// read the response of the soarca-http-api capability.
+type OutputMapping struct {
+	Source   string `json:"source,omitempty"`
+	JsonPath string `json:"json_path,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+	Header   string `json:"header,omitempty"`
+	Status   bool   `json:"status,omitempty"`
+	// Type of the variable, by default the type it is declared with in scope
+	Type string `json:"type,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+type OutputMappings struct {
+	// Mappings keyed by the name of the variable they fill
+	Variables map[string]OutputMapping `json:"variables"`
+}
+
+// Get the output mappings of a step, a step without the extension returns
+// the output of its capability as is
+func outputMappings(step cacao.Step) (OutputMappings, error) {
+	mappings := OutputMappings{}
+	found, err := cacao.DecodeExtension(step.StepExtensions, OutputMappingExtensionId, &mappings)
+	if !found {
+		return OutputMappings{}, nil
+	}
+	if err != nil {
+		return OutputMappings{}, fmt.Errorf("invalid output mappings of step [ %s ]: %w", step.ID, err)
+	}
+
+	for name, mapping := range mappings.Variables {
+		if err := mapping.validate(); err != nil {
+			return OutputMappings{}, fmt.Errorf("invalid output mappings of step [ %s ]: variable [ %s ]: %w", step.ID, name, err)
+		}
+		if mapping.Regex != "" {
+			// Checked by validate
+			mapping.pattern = regexp.MustCompile(mapping.Regex)
+			mappings.Variables[name] = mapping
+		}
+	}
+	return mappings, nil
+}
+
+func (mapping OutputMapping) validate() error {
+	selectors := 0
+	for _, set := range []bool{mapping.JsonPath != "", mapping.Regex != "", mapping.Header != "", mapping.Status} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return errors.New("exactly one of json_path, regex, header and status must be set")
+	}
+	if mapping.JsonPath != "" {
+		if !strings.HasPrefix(mapping.JsonPath, "$") || !cacao.ValidPath(mapping.JsonPath[1:]) {
+			return fmt.Errorf("unsupported json_path %s", mapping.JsonPath)
+		}
+	}
+	if mapping.Regex != "" {
+		if _, err := regexp.Compile(mapping.Regex); err != nil {
+			return err
+		}
+	}
+	if mapping.Source != "" && (mapping.Header != "" || mapping.Status) {
+		return errors.New("source cannot be set for header and status")
+	}
+	return nil
+}
+
+// The output of a command with the mapped variables added. Mapped variables
+// keep the declaration they have in scope.
+func (mappings OutputMappings) apply(output cacao.Variables, scope cacao.Variables) (cacao.Variables, error) {
+	if len(mappings.Variables) == 0 {
+		return output, nil
+	}
+	mapped := cacao.NewVariables()
+	mapped.InsertRange(output)
+
+	names := make([]string, 0, len(mappings.Variables))
+	for name := range mappings.Variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		mapping := mappings.Variables[name]
+		value, err := mapping.extract(output)
+		if err != nil {
+			return cacao.NewVariables(), fmt.Errorf("output mapping of variable [ %s ]: %w", name, err)
+		}
+		variable := scope[name]
+		variable.Name = name
+		if mapping.Type != "" {
+			variable.Type = mapping.Type
+		}
+		if variable.Type == "" {
+			variable.Type = cacao.VariableTypeString
+		}
+		variable.Value = value
+		mapped.InsertOrReplace(variable)
+	}
+	return mapped, nil
+}
+
+func (mapping OutputMapping) extract(output cacao.Variables) (string, error) {
+	switch {
+	case mapping.Status:
+		status, found := output[httpCapability.HttpApiStatusVariableName]
+		if !found {
+			return "", errors.New("the output has no HTTP status")
+		}
+		return status.Value, nil
+	case mapping.Header != "":
+		return header(output, mapping.Header)
+	}
+
+	source, err := mapping.source(output)
+	if err != nil {
+		return "", err
+	}
+	if mapping.JsonPath != "" {
+		// Any JSON value can be read by path as a dictionary
+		source.Type = cacao.VariableTypeDictionary
+		value, err := source.At(mapping.JsonPath[1:])
+		return value.Value, err
+	}
+	match := mapping.pattern.FindStringSubmatch(source.Value)
+	if match == nil {
+		return "", fmt.Errorf("regex %s does not match %s", mapping.Regex, source.Name)
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
+
+// The first value of a response header, the name is case insensitive
+func header(output cacao.Variables, name string) (string, error) {
+	headers, found := output[httpCapability.HttpApiHeadersVariableName]
+	if !found {
+		return "", errors.New("the output has no HTTP headers")
+	}
+	header := http.Header{}
+	if err := json.Unmarshal([]byte(headers.Value), &header); err != nil {
+		return "", err
+	}
+	value := header.Values(name)
+	if len(value) == 0 {
+		return "", fmt.Errorf("the response has no header %s", name)
+	}
+	return value[0], nil
+}
+
+// The variable a mapping reads, by default the one result of the capability
+func (mapping OutputMapping) source(output cacao.Variables) (cacao.Variable, error) {
+	if mapping.Source != "" {
+		source, found := output[mapping.Source]
+		if !found {
+			return cacao.Variable{}, fmt.Errorf("the output has no variable %s", mapping.Source)
+		}
+		return source, nil
+	}
+	results := []cacao.Variable{}
+	for name, variable := range output {
+		if strings.HasPrefix(name, "__soarca_") && strings.HasSuffix(name, "_result__") {
+			results = append(results, variable)
+		}
+	}
+	if len(results) != 1 {
+		return cacao.Variable{}, errors.New("the output has no single capability result, set the source")
+	}
+	return results[0], nil
+}
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+
+	"soarca/pkg/models/cacao"
+	httpUtil "soarca/pkg/utils/http"
+)
+
+// Step extension holding the retry policy of an action step (not part of CACAO spec)
+const RetryExtensionId = "extension-definition--8c5b5a2e-4d36-4c1f-9a63-0f4e7e4b2d5a"
+
+const (
+	BackoffFixed       = "fixed"
+	BackoffLinear      = "linear"
+	BackoffExponential = "exponential"
+)
+
+const (
+	RetryOnTimeout     = "timeout"
+	RetryOnConnection  = "connection"
+	RetryOnServerError = "server_error"
+	RetryOnAny         = "any"
+)
+
+const defaultRetryDelay = 1000
+
+// Upper bound of every delay, a day in milliseconds, so backoff cannot overflow
+const maxRetryDelay = 24 * 60 * 60 * 1000
+
+// Delays are in milliseconds. The step timeout applies to all attempts together.
+type RetryPolicy struct {
+	MaxAttempts int      `json:"max_attempts"`
+	Backoff     string   `json:"backoff,omitempty"`
+	Delay       int      `json:"delay,omitempty"`
+	MaxDelay    int      `json:"max_delay,omitempty"`
+	RetryOn     []string `json:"retry_on,omitempty"`
+}
+
+// Get the retry policy of a step, a step without the extension is executed once
+func retryPolicy(step cacao.Step) (RetryPolicy, bool, error) {
+	policy := RetryPolicy{}
+	found, err := cacao.DecodeExtension(step.StepExtensions, RetryExtensionId, &policy)
+	if !found {
+		return RetryPolicy{}, false, nil
+	}
+	if err != nil {
+		return RetryPolicy{}, false, fmt.Errorf("invalid retry policy of step [ %s ]: %w", step.ID, err)
+	}
+
+	if policy.Backoff == "" {
+		policy.Backoff = BackoffExponential
+	}
+	if policy.Delay == 0 {
+		policy.Delay = defaultRetryDelay
+	}
+	if len(policy.RetryOn) == 0 {
+		policy.RetryOn = []string{RetryOnTimeout, RetryOnConnection, RetryOnServerError}
+	}
+	if err := policy.validate(); err != nil {
+		return RetryPolicy{}, false, fmt.Errorf("invalid retry policy of step [ %s ]: %w", step.ID, err)
+	}
+	return policy, true, nil
+}
+
+func (policy RetryPolicy) validate() error {
+	if policy.MaxAttempts < 1 {
+		return errors.New("max_attempts must be at least 1")
+	}
+	if policy.Delay < 0 || policy.MaxDelay < 0 {
+		return errors.New("delays cannot be negative")
+	}
+	if policy.Delay > maxRetryDelay || policy.MaxDelay > maxRetryDelay {
+		return fmt.Errorf("delays cannot exceed %d ms", maxRetryDelay)
+	}
+	switch policy.Backoff {
+	case BackoffFixed, BackoffLinear, BackoffExponential:
+	default:
+		return fmt.Errorf("unknown backoff strategy %s", policy.Backoff)
+	}
+	for _, class := range policy.RetryOn {
+		switch class {
+		case RetryOnTimeout, RetryOnConnection, RetryOnServerError, RetryOnAny:
+		default:
+			return fmt.Errorf("unknown error class %s", class)
+		}
+	}
+	return nil
+}
+
+// Delay before the next attempt, the first retry waits the configured delay.
+// Delays saturate at max_delay, or at maxRetryDelay when it is not set.
+func (policy RetryPolicy) delay(attempt int) time.Duration {
+	limit := policy.MaxDelay
+	if limit == 0 {
+		limit = maxRetryDelay
+	}
+	delay := policy.Delay
+	switch policy.Backoff {
+	case BackoffLinear:
+		if delay > 0 && attempt > limit/delay {
+			delay = limit
+		} else {
+			delay = policy.Delay * attempt
+		}
+	case BackoffExponential:
+		for i := 1; i < attempt && delay < limit; i++ {
+			delay *= 2
+		}
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+func (policy RetryPolicy) retryable(err error) bool {
+	for _, class := range policy.RetryOn {
+		if class == RetryOnAny || slices.Contains(errorClasses(err), class) {
+			return true
+		}
+	}
+	return false
+}
+
+func errorClasses(err error) []string {
+	classes := []string{}
+
+	var netErr net.Error
+	isNetErr := errors.As(err, &netErr)
+	if errors.Is(err, context.DeadlineExceeded) || (isNetErr && netErr.Timeout()) {
+		classes = append(classes, RetryOnTimeout)
+	}
+	if isNetErr ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		classes = append(classes, RetryOnConnection)
+	}
+
+	var statusErr httpUtil.ErrorResponseStatus
+	if errors.As(err, &statusErr) &&
+		(statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests) {
+		classes = append(classes, RetryOnServerError)
+	}
+	return classes
+}
+
+// Wait for the next attempt, returns false when the step context is done first
+func (executor *Executor) waitForRetry(ctx context.Context, delay time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-executor.time.After(delay):
+		return true
+	}
+}
@@ -0,0 +1,95 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"soarca/pkg/core/secrets"
+	"soarca/pkg/models/cacao"
+)
+
+// Set the provider of the secrets authentication information refers to.
+// Without a provider, authentication information must hold its secrets itself.
+func (executor *Executor) SetSecretProvider(provider secrets.ISecretProvider) {
+	executor.secretProvider = provider
+}
+
+// Resolve the secrets of authentication information just before a capability
+// runs, so they are not stored in the playbook, the variables or the reports.
+// With kms set the kms_key_identifier gives the secret of the authentication
+// type, otherwise the password, token and private_key can be written as
+// secret://<provider>/<name> references.
+func (executor *Executor) resolveSecrets(ctx context.Context,
+	authentication cacao.AuthenticationInformation) (cacao.AuthenticationInformation, error) {
+	if authentication.Kms {
+		if authentication.KmsKeyIdentifier == "" {
+			return cacao.AuthenticationInformation{}, errors.New("kms is set without kms_key_identifier")
+		}
+		secret, err := executor.secret(ctx, authentication.KmsKeyIdentifier)
+		if err != nil {
+			return cacao.AuthenticationInformation{}, err
+		}
+		switch authentication.Type {
+		case cacao.AuthInfoOAuth2Type:
+			authentication.Token = secret
+		case cacao.AuthInfoPrivateKeyType:
+			authentication.PrivateKey = secret
+		default:
+			authentication.Password = secret
+		}
+	}
+
+	for _, field := range []*string{&authentication.Password, &authentication.Token, &authentication.PrivateKey} {
+		if !secrets.IsReference(*field) {
+			continue
+		}
+		secret, err := executor.secret(ctx, *field)
+		if err != nil {
+			return cacao.AuthenticationInformation{}, err
+		}
+		*field = secret
+	}
+	return authentication, nil
+}
+
+// Interpolate the authentication information after resolving the secrets of
+// its raw fields. Variables can be set by whoever triggers a playbook, so a
+// secret reference that only appears after interpolation is rejected instead
+// of resolved, and resolved secrets are not interpolated.
+func (executor *Executor) authenticate(ctx context.Context,
+	authentication cacao.AuthenticationInformation,
+	variables cacao.Variables) (cacao.AuthenticationInformation, error) {
+	resolved, err := executor.resolveSecrets(ctx, authentication)
+	if err != nil {
+		return cacao.AuthenticationInformation{}, err
+	}
+	interpolated := interpolateAuthentication(authentication, variables)
+
+	fields := []struct {
+		name                       string
+		raw, resolved, interpolate *string
+	}{
+		{"password", &authentication.Password, &resolved.Password, &interpolated.Password},
+		{"token", &authentication.Token, &resolved.Token, &interpolated.Token},
+		{"private_key", &authentication.PrivateKey, &resolved.PrivateKey, &interpolated.PrivateKey},
+	}
+	for _, field := range fields {
+		if *field.resolved != *field.raw {
+			*field.interpolate = *field.resolved
+			continue
+		}
+		if secrets.IsReference(*field.interpolate) {
+			return cacao.AuthenticationInformation{},
+				fmt.Errorf("secret reference in %s comes from a variable", field.name)
+		}
+	}
+	return interpolated, nil
+}
+
+func (executor *Executor) secret(ctx context.Context, reference string) (string, error) {
+	if executor.secretProvider == nil {
+		return "", errors.New("no secret provider is configured")
+	}
+	return executor.secretProvider.Secret(ctx, reference)
+}
@@ -11,6 +11,14 @@ import (
 	"soarca/pkg/reporting/reporter"
 	"soarca/pkg/utils/stix/expression/comparison"
 	timeUtil "soarca/pkg/utils/time"
+	"sort"
+	"strings"
+)
+
+const (
+	switchDefaultCase         = "default"
+	switchCaseResultVariable  = "__soarca_switch_case__"
+	switchVariableValueSuffix = ":value"
 )
 
 var component = reflect.TypeOf(Executor{}).PkgPath()
@@ -39,7 +47,9 @@ type Executor struct {
 
 func (executor *Executor) Execute(meta execution.Metadata, stepContext executors.Context) (string, bool, error) {
 
-	if stepContext.Step.Type != cacao.StepTypeIfCondition && stepContext.Step.Type != cacao.StepTypeWhileCondition {
+	if stepContext.Step.Type != cacao.StepTypeIfCondition &&
+		stepContext.Step.Type != cacao.StepTypeWhileCondition &&
+		stepContext.Step.Type != cacao.StepTypeSwitchCondition {
 		err := errors.New("the provided step type is not compatible with this executor")
 		log.Error(err)
 		return stepContext.Step.OnFailure, false, err
@@ -48,9 +58,26 @@ func (executor *Executor) Execute(meta execution.Metadata, stepContext executors
 	executor.reporter.ReportStepStart(meta.ExecutionId, stepContext.Step, stepContext.Variables, executor.time.Now())
 
 	var err error
+	reportVariables := stepContext.Variables
 	defer func() {
-		executor.reporter.ReportStepEnd(meta.ExecutionId, stepContext.Step, stepContext.Variables, err, executor.time.Now())
+		executor.reporter.ReportStepEnd(meta.ExecutionId, stepContext.Step, reportVariables, err, executor.time.Now())
 	}()
+
+	if stepContext.Step.Type == cacao.StepTypeSwitchCondition {
+		var nextStepId, selectedCase string
+		var branch bool
+		nextStepId, branch, selectedCase, err = executor.evaluateSwitch(stepContext)
+		if err == nil {
+			// Report the selected case alongside the scope so the taken path is visible
+			reportVariables = cacao.NewVariables()
+			reportVariables.InsertRange(stepContext.Variables)
+			reportVariables.InsertOrReplace(cacao.Variable{Type: cacao.VariableTypeString,
+				Name:  switchCaseResultVariable,
+				Value: selectedCase})
+		}
+		return nextStepId, branch, err
+	}
+
 	nextStepId, branch, err := executor.evaluate(stepContext)
 	return nextStepId, branch, err
 }
@@ -80,3 +107,53 @@ func (executor *Executor) evaluate(stepContext executors.Context) (string, bool,
 
 	return stepContext.Step.OnCompletion, false, nil
 }
+
+// Evaluate the switch variable against every case key
+//
+// Case keys are compared with the STIX comparison engine using the type of the
+// switch variable. Keys are quoted as STIX string literals, so they can hold
+// spaces and quotes. The first matching key in lexical order is selected, the
+// "default" case is used when no key matches.
+// Returns the next step id, if a branch must be taken and the selected case key
+func (executor *Executor) evaluateSwitch(stepContext executors.Context) (string, bool, string, error) {
+	step := stepContext.Step
+	if step.Switch == "" {
+		err := errors.New("switch-condition step has no switch variable set")
+		log.Error(err)
+		return "", false, "", err
+	}
+
+	switchVariable := step.Switch
+	if !strings.HasSuffix(switchVariable, switchVariableValueSuffix) {
+		switchVariable = switchVariable + switchVariableValueSuffix
+	}
+
+	caseKeys := make([]string, 0, len(step.Cases))
+	for caseKey := range step.Cases {
+		if caseKey != switchDefaultCase {
+			caseKeys = append(caseKeys, caseKey)
+		}
+	}
+	sort.Strings(caseKeys)
+
+	for _, caseKey := range caseKeys {
+		expression := fmt.Sprint(switchVariable, " ", comparison.Equal, " ", comparison.Quote(caseKey))
+		match, err := executor.comparison.Evaluate(expression, stepContext.Variables)
+		if err != nil {
+			log.Error(err)
+			return "", false, "", err
+		}
+		if match {
+			log.Trace("switch matched case ", caseKey, " will return step ", step.Cases[caseKey])
+			return step.Cases[caseKey], true, caseKey, nil
+		}
+	}
+
+	if defaultStepId, ok := step.Cases[switchDefaultCase]; ok {
+		log.Trace("switch matched no case, will return default step ", defaultStepId)
+		return defaultStepId, true, switchDefaultCase, nil
+	}
+
+	log.Trace("switch matched no case, will return on completion step ", step.OnCompletion)
+	return step.OnCompletion, false, "", nil
+}
@@ -5,6 +5,7 @@ import (
 	"soarca/pkg/core/executors"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
+	"soarca/pkg/utils/stix/expression/comparison"
 	"soarca/test/unittest/mocks/mock_reporter"
 	mock_stix "soarca/test/unittest/mocks/mock_utils/stix"
 	mock_time "soarca/test/unittest/mocks/mock_utils/time"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestExecuteConditionTrue(t *testing.T) {
@@ -210,3 +212,189 @@ func TestExecuteConditionWhileFalse(t *testing.T) {
 	mock_stix.AssertExpectations(t)
 	mock_time.AssertExpectations(t)
 }
+
+func TestExecuteSwitchConditionMatchingCase(t *testing.T) {
+	mock_stix := new(mock_stix.MockStix)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	conditionExecutior := New(mock_stix, mock_reporter, mock_time)
+
+	executionId := uuid.New()
+
+	meta := execution.Metadata{ExecutionId: executionId,
+		PlaybookId: "1",
+		StepId:     "2"}
+
+	step := cacao.Step{Type: cacao.StepTypeSwitchCondition,
+		Switch:       "__severity__",
+		Cases:        cacao.Cases{"high": "3", "low": "4", "default": "5"},
+		OnCompletion: "6"}
+	vars := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  "__severity__",
+		Value: "low"})
+
+	reportedVars := cacao.NewVariables(vars["__severity__"],
+		cacao.Variable{Type: cacao.VariableTypeString,
+			Name:  "__soarca_switch_case__",
+			Value: "low"})
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	mock_reporter.On("ReportStepStart", executionId, step, vars, timeNow)
+	mock_stix.On("Evaluate", "__severity__:value = 'high'", vars).Return(false, nil)
+	mock_stix.On("Evaluate", "__severity__:value = 'low'", vars).Return(true, nil)
+	mock_reporter.On("ReportStepEnd", executionId, step, reportedVars, nil, timeNow)
+
+	context := executors.Context{Step: step, Variables: vars}
+	nextStepId, goToBranch, err := conditionExecutior.Execute(meta, context)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, goToBranch)
+	assert.Equal(t, "4", nextStepId)
+
+	mock_reporter.AssertExpectations(t)
+	mock_stix.AssertExpectations(t)
+}
+
+func TestExecuteSwitchConditionDefaultCase(t *testing.T) {
+	mock_stix := new(mock_stix.MockStix)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	conditionExecutior := New(mock_stix, mock_reporter, mock_time)
+
+	executionId := uuid.New()
+
+	meta := execution.Metadata{ExecutionId: executionId,
+		PlaybookId: "1",
+		StepId:     "2"}
+
+	step := cacao.Step{Type: cacao.StepTypeSwitchCondition,
+		Switch:       "__severity__:value",
+		Cases:        cacao.Cases{"high": "3", "default": "5"},
+		OnCompletion: "6"}
+	vars := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  "__severity__",
+		Value: "medium"})
+
+	reportedVars := cacao.NewVariables(vars["__severity__"],
+		cacao.Variable{Type: cacao.VariableTypeString,
+			Name:  "__soarca_switch_case__",
+			Value: "default"})
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	mock_reporter.On("ReportStepStart", executionId, step, vars, timeNow)
+	mock_stix.On("Evaluate", "__severity__:value = 'high'", vars).Return(false, nil)
+	mock_reporter.On("ReportStepEnd", executionId, step, reportedVars, nil, timeNow)
+
+	context := executors.Context{Step: step, Variables: vars}
+	nextStepId, goToBranch, err := conditionExecutior.Execute(meta, context)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, goToBranch)
+	assert.Equal(t, "5", nextStepId)
+
+	mock_reporter.AssertExpectations(t)
+	mock_stix.AssertExpectations(t)
+}
+
+func TestExecuteSwitchConditionNoMatch(t *testing.T) {
+	mock_stix := new(mock_stix.MockStix)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	conditionExecutior := New(mock_stix, mock_reporter, mock_time)
+
+	executionId := uuid.New()
+
+	meta := execution.Metadata{ExecutionId: executionId,
+		PlaybookId: "1",
+		StepId:     "2"}
+
+	step := cacao.Step{Type: cacao.StepTypeSwitchCondition,
+		Switch:       "__severity__",
+		Cases:        cacao.Cases{"high": "3"},
+		OnCompletion: "6"}
+	vars := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  "__severity__",
+		Value: "medium"})
+
+	reportedVars := cacao.NewVariables(vars["__severity__"],
+		cacao.Variable{Type: cacao.VariableTypeString,
+			Name: "__soarca_switch_case__"})
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	mock_reporter.On("ReportStepStart", executionId, step, vars, timeNow)
+	mock_stix.On("Evaluate", "__severity__:value = 'high'", vars).Return(false, nil)
+	mock_reporter.On("ReportStepEnd", executionId, step, reportedVars, nil, timeNow)
+
+	context := executors.Context{Step: step, Variables: vars}
+	nextStepId, goToBranch, err := conditionExecutior.Execute(meta, context)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, goToBranch)
+	assert.Equal(t, "6", nextStepId)
+
+	mock_reporter.AssertExpectations(t)
+	mock_stix.AssertExpectations(t)
+}
+
+func TestExecuteSwitchConditionQuotedCases(t *testing.T) {
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	// Case keys with spaces and quotes go through the STIX comparison engine
+	conditionExecutior := New(comparison.New(), mock_reporter, mock_time)
+
+	executionId := uuid.New()
+
+	meta := execution.Metadata{ExecutionId: executionId,
+		PlaybookId: "1",
+		StepId:     "2"}
+
+	step := cacao.Step{Type: cacao.StepTypeSwitchCondition,
+		Switch:       "__verdict__",
+		Cases:        cacao.Cases{"true positive": "3", "analyst's call": "4", `C:\temp`: "5", "default": "6"},
+		OnCompletion: "7"}
+	vars := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  "__verdict__",
+		Value: "analyst's call"})
+
+	reportedVars := cacao.NewVariables(vars["__verdict__"],
+		cacao.Variable{Type: cacao.VariableTypeString,
+			Name:  "__soarca_switch_case__",
+			Value: "analyst's call"})
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	mock_reporter.On("ReportStepStart", executionId, step, vars, timeNow)
+	mock_reporter.On("ReportStepEnd", executionId, step, reportedVars, nil, timeNow)
+
+	context := executors.Context{Step: step, Variables: vars}
+	nextStepId, goToBranch, err := conditionExecutior.Execute(meta, context)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, goToBranch)
+	assert.Equal(t, "4", nextStepId)
+
+	context.Variables = cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString,
+		Name:  "__verdict__",
+		Value: `C:\temp`})
+	mock_reporter.On("ReportStepStart", executionId, step, context.Variables, timeNow)
+	mock_reporter.On("ReportStepEnd", executionId, step, mock.Anything, nil, timeNow)
+	nextStepId, _, err = conditionExecutior.Execute(meta, context)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "5", nextStepId)
+	mock_reporter.AssertExpectations(t)
+}
@@ -1,12 +1,14 @@
 package executors
 
 import (
+	"context"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 )
 
 type IPlaybookExecuter interface {
-	Execute(execution.Metadata,
+	Execute(context.Context,
+		execution.Metadata,
 		cacao.Step,
 		cacao.Variables) (cacao.Variables, error)
 }
@@ -30,6 +32,7 @@ type PlaybookStepMetadata struct {
 }
 
 type IActionExecutor interface {
-	Execute(metadata execution.Metadata,
+	Execute(ctx context.Context,
+		metadata execution.Metadata,
 		step PlaybookStepMetadata) (cacao.Variables, error)
 }
@@ -1,12 +1,14 @@
 package playbook_action
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"soarca/internal/controller/database"
 	"soarca/internal/controller/decomposer_controller"
 	"soarca/internal/logger"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 	"soarca/pkg/reporting/reporter"
@@ -32,7 +34,8 @@ func New(controller decomposer_controller.IController,
 	return &PlaybookAction{decomposerController: controller, databaseController: database, reporter: reporter, time: time}
 }
 
-func (playbookAction *PlaybookAction) Execute(metadata execution.Metadata,
+func (playbookAction *PlaybookAction) Execute(ctx context.Context,
+	metadata execution.Metadata,
 	step cacao.Step,
 	variables cacao.Variables) (cacao.Variables, error) {
 	log.Trace(metadata.ExecutionId)
@@ -60,11 +63,25 @@ func (playbookAction *PlaybookAction) Execute(metadata execution.Metadata,
 		return cacao.NewVariables(), err
 	}
 
-	playbook.PlaybookVariables.Merge(variables)
+	if err = execution.CheckPlaybookValidity(playbook, playbookAction.time.Now()); err != nil {
+		log.Error(err)
+		return cacao.NewVariables(), err
+	}
+
+	// The child playbook only receives variables up to its own marking
+	variables = marking.PolicyFrom(ctx).Filter(variables, marking.PolicyOf(playbook).Level)
+
+	// Constant variables of the child playbook cannot be set by its parent
+	if err = playbook.PlaybookVariables.Merge(variables); err != nil {
+		err = fmt.Errorf("cannot pass variables to playbook %s: %w", step.PlaybookID, err)
+		log.Error(err)
+		return cacao.NewVariables(), err
+	}
 
-	details, err := decomposer.Execute(playbook)
+	// The child execution is cancelled together with its parent
+	details, err := decomposer.Execute(ctx, playbook)
 	if err != nil {
-		err = errors.New(fmt.Sprint("execution of playbook failed with error: ", err))
+		err = fmt.Errorf("execution of playbook failed with error: %w", err)
 		log.Error(err)
 		reportVars = details.Variables // make sure vars are reported
 		return cacao.NewVariables(), err
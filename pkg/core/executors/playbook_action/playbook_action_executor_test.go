@@ -1,10 +1,12 @@
 package playbook_action
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/marking"
 	mock_database_controller "soarca/test/unittest/mocks/mock_controller/database"
 	mock_decomposer_controller "soarca/test/unittest/mocks/mock_controller/decomposer"
 	"soarca/test/unittest/mocks/mock_decomposer"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestExecutePlaybook(t *testing.T) {
@@ -87,9 +90,9 @@ func TestExecutePlaybook(t *testing.T) {
 
 	playbook2 := cacao.Playbook{ID: playbookId, PlaybookVariables: cacao.NewVariables(expectedVariables)}
 
-	mockDecomposer.On("Execute", playbook2).Return(&details, nil)
+	mockDecomposer.On("Execute", mock.Anything, playbook2).Return(&details, nil)
 
-	results, err := executerObject.Execute(metadata, step, cacao.NewVariables(addedVariables))
+	results, err := executerObject.Execute(context.Background(), metadata, step, cacao.NewVariables(addedVariables))
 
 	mockDecomposer.AssertExpectations(t)
 	mock_reporter.AssertExpectations(t)
@@ -98,3 +101,95 @@ func TestExecutePlaybook(t *testing.T) {
 	assert.Equal(t, results, cacao.NewVariables(returnedVariables))
 
 }
+
+func TestExecuteExpiredPlaybook(t *testing.T) {
+	playbookRepoMock := new(mocks_playbook_test.MockPlaybook)
+	mockDecomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	controller := new(mock_decomposer_controller.Mock_Controller)
+	database := new(mock_database_controller.Mock_Controller)
+
+	executerObject := New(controller, database, mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+	metadata := execution.Metadata{ExecutionId: executionId, PlaybookId: playbookId, StepId: stepId}
+
+	step := cacao.Step{
+		Type:       cacao.StepTypePlaybookAction,
+		ID:         stepId,
+		PlaybookID: playbookId,
+	}
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+	database.On("GetDatabaseInstance").Return(playbookRepoMock)
+	controller.On("NewDecomposer").Return(mockDecomposer)
+
+	playbook := cacao.Playbook{ID: playbookId,
+		ValidFrom:  time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC),
+		ValidUntil: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)}
+	playbookRepoMock.On("Read", playbookId).Return(playbook, nil)
+
+	expected := execution.ErrorPlaybookNotValid{PlaybookId: playbookId,
+		ValidFrom:  playbook.ValidFrom,
+		ValidUntil: playbook.ValidUntil,
+		At:         timeNow}
+	mock_reporter.On("ReportStepStart", executionId, step, cacao.NewVariables(), timeNow).Return()
+	mock_reporter.On("ReportStepEnd", executionId, step, cacao.NewVariables(), expected, timeNow).Return()
+
+	_, err := executerObject.Execute(context.Background(), metadata, step, cacao.NewVariables())
+
+	assert.Equal(t, err, expected)
+	assert.Equal(t, err.Error(), "playbook [ "+playbookId+" ] expired at 2014-01-01T00:00:00Z")
+	mockDecomposer.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	mock_reporter.AssertExpectations(t)
+}
+
+func TestExecutePlaybookFiltersMarkedVariables(t *testing.T) {
+	playbookRepoMock := new(mocks_playbook_test.MockPlaybook)
+	mockDecomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_reporter := new(mock_reporter.Mock_Reporter)
+	mock_time := new(mock_time.MockTime)
+
+	controller := new(mock_decomposer_controller.Mock_Controller)
+	database := new(mock_database_controller.Mock_Controller)
+
+	executerObject := New(controller, database, mock_reporter, mock_time)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	playbookId := "playbook--d09351a2-a075-40c8-8054-0b7c423db83f"
+	stepId := "step--81eff59f-d084-4324-9e0a-59e353dbd28f"
+	metadata := execution.Metadata{ExecutionId: executionId, PlaybookId: playbookId, StepId: stepId}
+
+	step := cacao.Step{
+		Type:       cacao.StepTypePlaybookAction,
+		ID:         stepId,
+		PlaybookID: playbookId,
+	}
+
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	password := cacao.Variable{Type: cacao.VariableTypeString, Name: "__password__", Value: "secret"}
+	variables := cacao.NewVariables(host, password)
+
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+	mock_time.On("Now").Return(timeNow)
+	database.On("GetDatabaseInstance").Return(playbookRepoMock)
+	controller.On("NewDecomposer").Return(mockDecomposer)
+	mock_reporter.On("ReportStepStart", executionId, step, variables, timeNow).Return()
+	mock_reporter.On("ReportStepEnd", executionId, step, cacao.NewVariables(), nil, timeNow).Return()
+
+	// The child playbook is unmarked, so it only receives unmarked variables
+	playbookRepoMock.On("Read", playbookId).Return(cacao.Playbook{ID: playbookId, PlaybookVariables: cacao.NewVariables()}, nil)
+	child := cacao.Playbook{ID: playbookId, PlaybookVariables: cacao.NewVariables(host)}
+	details := decomposer.ExecutionDetails{ExecutionId: executionId, PlaybookId: playbookId, Variables: cacao.NewVariables()}
+	mockDecomposer.On("Execute", mock.Anything, child).Return(&details, nil)
+
+	ctx := marking.WithPolicy(context.Background(), marking.Policy{Variables: map[string]marking.Level{"__password__": marking.Red}})
+	_, err := executerObject.Execute(ctx, metadata, step, variables)
+
+	assert.Equal(t, err, nil)
+	mockDecomposer.AssertExpectations(t)
+	mock_reporter.AssertExpectations(t)
+}
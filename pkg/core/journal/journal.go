@@ -0,0 +1,182 @@
+package journal
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	journalrepository "soarca/internal/database/journal"
+	"soarca/internal/logger"
+	"soarca/pkg/core/marking"
+	"soarca/pkg/models/cacao"
+	cache_model "soarca/pkg/models/cache"
+	journal_model "soarca/pkg/models/journal"
+	itime "soarca/pkg/utils/time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	component = reflect.TypeOf(Journal{}).PkgPath()
+	log       *logger.Log
+)
+
+func init() {
+	log = logger.Logger(component, logger.Info, "", logger.Json)
+}
+
+// Written to by the decomposer at every step boundary of the main workflow
+type IExecutionJournal interface {
+	Checkpoint(executionId uuid.UUID, playbook cacao.Playbook, stepId string, scopeVariables cacao.Variables)
+}
+
+// Persists the state of running executions, so they can be resumed after a
+// restart. Step events are received as downstream reporter. Only executions
+// that passed a checkpoint are journaled, events of other executions are ignored.
+type Journal struct {
+	repository journalrepository.IJournalRepository
+	timeUtil   itime.ITime
+	records    map[uuid.UUID]journal_model.ExecutionRecord
+	mutex      sync.Mutex
+}
+
+func New(repository journalrepository.IJournalRepository, timeUtil itime.ITime) *Journal {
+	return &Journal{repository: repository,
+		timeUtil: timeUtil,
+		records:  map[uuid.UUID]journal_model.ExecutionRecord{}}
+}
+
+// Load the executions that did not finish before the last shutdown
+func (journal *Journal) Load() ([]journal_model.ExecutionRecord, error) {
+	records, err := journal.repository.GetExecutions()
+	if err != nil {
+		return nil, err
+	}
+
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+	unfinished := []journal_model.ExecutionRecord{}
+	for _, record := range records {
+		executionId, err := uuid.Parse(record.ExecutionId)
+		if err != nil {
+			log.Warning("ignoring journal record with invalid execution id ", record.ExecutionId)
+			continue
+		}
+		journal.records[executionId] = record
+		unfinished = append(unfinished, record)
+	}
+	return unfinished, nil
+}
+
+// ############################### Execution journal interface
+
+func (journal *Journal) Checkpoint(executionId uuid.UUID,
+	playbook cacao.Playbook,
+	stepId string,
+	scopeVariables cacao.Variables) {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	variables := cacao.NewVariables()
+	variables.InsertRange(scopeVariables)
+
+	record, found := journal.records[executionId]
+	if !found {
+		record = journal_model.ExecutionRecord{
+			ExecutionId: executionId.String(),
+			Playbook:    playbook,
+			Steps:       map[string]journal_model.StepRecord{},
+			Started:     journal.timeUtil.Now(),
+		}
+	}
+	record.StepId = stepId
+	record.Variables = variables
+	record.Updated = journal.timeUtil.Now()
+	journal.records[executionId] = record
+
+	var err error
+	if found {
+		err = journal.repository.Update(record)
+	} else {
+		err = journal.repository.Create(record)
+	}
+	if err != nil {
+		log.Warning("could not write checkpoint of execution ", executionId, ": ", err)
+	}
+}
+
+// ############################### Reporting interface
+
+// Executions are resumed from the journal, so it receives all data
+func (journal *Journal) MaxMarking() marking.Level {
+	return marking.Red
+}
+
+func (journal *Journal) ReportWorkflowStart(executionId uuid.UUID, playbook cacao.Playbook, at time.Time) error {
+	return nil
+}
+
+// A finished execution no longer needs to be resumed
+func (journal *Journal) ReportWorkflowEnd(executionId uuid.UUID, playbook cacao.Playbook, workflowError error, at time.Time) error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if _, found := journal.records[executionId]; !found {
+		return nil
+	}
+	delete(journal.records, executionId)
+	return journal.repository.Delete(executionId.String())
+}
+
+func (journal *Journal) ReportStepStart(executionId uuid.UUID, step cacao.Step, variables cacao.Variables, at time.Time) error {
+	return journal.update(executionId, func(record *journal_model.ExecutionRecord) {
+		record.Steps[step.ID] = journal_model.StepRecord{
+			StepId:    step.ID,
+			Started:   at,
+			Status:    cache_model.Ongoing.String(),
+			Variables: variables,
+		}
+	})
+}
+
+func (journal *Journal) ReportStepEnd(executionId uuid.UUID, step cacao.Step, returnVars cacao.Variables, stepError error, at time.Time) error {
+	return journal.update(executionId, func(record *journal_model.ExecutionRecord) {
+		stepRecord := record.Steps[step.ID]
+		stepRecord.StepId = step.ID
+		stepRecord.Ended = at
+		stepRecord.Variables = returnVars
+		stepRecord.Status = cache_model.SuccessfullyExecuted.String()
+		if stepError != nil {
+			stepRecord.Status = cache_model.Failed.String()
+			stepRecord.Error = stepError.Error()
+		}
+		record.Steps[step.ID] = stepRecord
+	})
+}
+
+// Attempts do not change where an execution is resumed
+func (journal *Journal) ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, stepError error, at time.Time) error {
+	return nil
+}
+
+// A resumed loop evaluates its condition again, iterations are not journaled
+func (journal *Journal) ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) error {
+	return nil
+}
+
+func (journal *Journal) update(executionId uuid.UUID, change func(record *journal_model.ExecutionRecord)) error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	record, found := journal.records[executionId]
+	if !found {
+		return nil
+	}
+	if record.Steps == nil {
+		record.Steps = map[string]journal_model.StepRecord{}
+	}
+	change(&record)
+	record.Updated = journal.timeUtil.Now()
+	journal.records[executionId] = record
+	return journal.repository.Update(record)
+}
@@ -0,0 +1,116 @@
+package journal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	journalrepository "soarca/internal/database/journal"
+	"soarca/pkg/models/cacao"
+	mock_time "soarca/test/unittest/mocks/mock_utils/time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+)
+
+func TestJournalExecution(t *testing.T) {
+	repository, err := journalrepository.NewFileJournalRepository(t.TempDir())
+	assert.Equal(t, err, nil)
+	mock_time := new(mock_time.MockTime)
+	timeNow, _ := time.Parse("2006-01-02T15:04:05.000Z", "2014-11-12T11:45:26.371Z")
+	mock_time.On("Now").Return(timeNow)
+
+	journal := New(repository, mock_time)
+
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	step1 := cacao.Step{Type: cacao.StepTypeAction, ID: "action--test"}
+	step2 := cacao.Step{Type: cacao.StepTypeAction, ID: "action--test2"}
+	playbook := cacao.Playbook{ID: "playbook--test",
+		WorkflowStart: step1.ID,
+		Workflow:      map[string]cacao.Step{step1.ID: step1, step2.ID: step2}}
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	output := cacao.Variable{Type: cacao.VariableTypeString, Name: "__output__", Value: "done"}
+
+	journal.Checkpoint(executionId, playbook, step1.ID, cacao.NewVariables(host))
+	err = journal.ReportStepStart(executionId, step1, cacao.NewVariables(host), timeNow)
+	assert.Equal(t, err, nil)
+	err = journal.ReportStepEnd(executionId, step1, cacao.NewVariables(output), nil, timeNow)
+	assert.Equal(t, err, nil)
+	journal.Checkpoint(executionId, playbook, step2.ID, cacao.NewVariables(host, output))
+	err = journal.ReportStepStart(executionId, step2, cacao.NewVariables(host, output), timeNow)
+	assert.Equal(t, err, nil)
+
+	// A new journal on the same store finds the unfinished execution
+	records, err := New(repository, mock_time).Load()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 1)
+	record := records[0]
+	assert.Equal(t, record.ExecutionId, executionId.String())
+	assert.Equal(t, record.Playbook.ID, playbook.ID)
+	assert.Equal(t, record.StepId, step2.ID)
+	assert.Equal(t, record.Variables, cacao.NewVariables(host, output))
+	assert.Equal(t, record.Steps[step1.ID].Status, "successfully_executed")
+	assert.Equal(t, record.Steps[step2.ID].Status, "ongoing")
+
+	err = journal.ReportStepEnd(executionId, step2, cacao.NewVariables(), errors.New("rejected"), timeNow)
+	assert.Equal(t, err, nil)
+	records, _ = New(repository, mock_time).Load()
+	assert.Equal(t, records[0].Steps[step2.ID].Error, "rejected")
+
+	err = journal.ReportWorkflowEnd(executionId, playbook, nil, timeNow)
+	assert.Equal(t, err, nil)
+	records, err = New(repository, mock_time).Load()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 0)
+}
+
+func TestJournalIgnoresExecutionsWithoutCheckpoint(t *testing.T) {
+	repository, err := journalrepository.NewFileJournalRepository(t.TempDir())
+	assert.Equal(t, err, nil)
+	mock_time := new(mock_time.MockTime)
+	timeNow, _ := time.Parse("2006-01-02T15:04:05.000Z", "2014-11-12T11:45:26.371Z")
+	mock_time.On("Now").Return(timeNow)
+
+	journal := New(repository, mock_time)
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	step := cacao.Step{Type: cacao.StepTypeAction, ID: "action--test"}
+
+	err = journal.ReportStepStart(executionId, step, cacao.NewVariables(), timeNow)
+	assert.Equal(t, err, nil)
+	err = journal.ReportWorkflowEnd(executionId, cacao.Playbook{}, nil, timeNow)
+	assert.Equal(t, err, nil)
+
+	records, err := repository.GetExecutions()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 0)
+}
+
+// Pending manual commands are not journaled: the checkpoint stays at the
+// manual step, so a resumed execution queues the command again under the same
+// execution and step id.
+func TestJournalPendingManualStep(t *testing.T) {
+	repository, err := journalrepository.NewFileJournalRepository(t.TempDir())
+	assert.Equal(t, err, nil)
+	mock_time := new(mock_time.MockTime)
+	timeNow, _ := time.Parse("2006-01-02T15:04:05.000Z", "2014-11-12T11:45:26.371Z")
+	mock_time.On("Now").Return(timeNow)
+
+	journal := New(repository, mock_time)
+	executionId := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	step := cacao.Step{Type: cacao.StepTypeAction,
+		ID:       "action--manual",
+		Commands: []cacao.Command{{Type: cacao.CommandTypeManual, Command: "Isolate the host"}}}
+	playbook := cacao.Playbook{ID: "playbook--test",
+		WorkflowStart: step.ID,
+		Workflow:      map[string]cacao.Step{step.ID: step}}
+
+	journal.Checkpoint(executionId, playbook, step.ID, cacao.NewVariables())
+	err = journal.ReportStepStart(executionId, step, cacao.NewVariables(), timeNow)
+	assert.Equal(t, err, nil)
+
+	records, err := New(repository, mock_time).Load()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].StepId, step.ID)
+	assert.Equal(t, records[0].Steps[step.ID].Status, "ongoing")
+}
@@ -0,0 +1,228 @@
+package marking
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"soarca/internal/logger"
+	"soarca/pkg/models/cacao"
+)
+
+type Empty struct{}
+
+var component = reflect.TypeOf(Empty{}).PkgPath()
+var log *logger.Log
+
+func init() {
+	log = logger.Logger(component, logger.Info, "", logger.Json)
+}
+
+// Playbook extension marking single variables higher than the playbook itself
+// (not part of CACAO spec)
+const VariableMarkingsExtensionId = "extension-definition--6b3f8e2a-7c1d-4a9e-b5f0-d2c4e6a8b1f3"
+
+// Value of a variable that is redacted for a destination
+const RedactedValue = "[redacted]"
+
+// TLP level of data, IEP markings are mapped on the TLP level they carry.
+// Unmarked data is TLP:CLEAR.
+type Level int
+
+const (
+	Clear Level = iota
+	Green
+	Amber
+	AmberStrict
+	Red
+)
+
+func (level Level) String() string {
+	switch level {
+	case Clear:
+		return "TLP:CLEAR"
+	case Green:
+		return "TLP:GREEN"
+	case Amber:
+		return "TLP:AMBER"
+	case AmberStrict:
+		return "TLP:AMBER+STRICT"
+	default:
+		return "TLP:RED"
+	}
+}
+
+// Parse a TLPv1 or TLPv2 level, with or without the TLP: prefix
+func ParseLevel(value string) (Level, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "tlp:") {
+	case "clear", "white":
+		return Clear, nil
+	case "green":
+		return Green, nil
+	case "amber":
+		return Amber, nil
+	case "amber+strict":
+		return AmberStrict, nil
+	case "red":
+		return Red, nil
+	}
+	return Red, fmt.Errorf("unknown TLP level %s", value)
+}
+
+// Destinations of execution data declare the highest marking they may
+// receive. Destinations that do not declare it only receive TLP:CLEAR data.
+type IMarkingRestricted interface {
+	MaxMarking() Level
+}
+
+func MaxMarkingOf(destination interface{}) Level {
+	if restricted, ok := destination.(IMarkingRestricted); ok {
+		return restricted.MaxMarking()
+	}
+	return Clear
+}
+
+type VariableMarkings struct {
+	// Marking definition ids, keyed by variable name
+	Variables map[string][]string `json:"variables"`
+}
+
+// Effective markings of the data of a playbook execution
+type Policy struct {
+	// Applies to all data of the playbook
+	Level Level
+	// Variables marked higher than the playbook
+	Variables map[string]Level
+}
+
+// Work out the markings of a playbook. Markings that cannot be resolved are
+// taken as TLP:RED, revoked markings and markings without a level are ignored.
+func PolicyOf(playbook cacao.Playbook) Policy {
+	policy := Policy{Level: levelOf(playbook, playbook.Markings), Variables: map[string]Level{}}
+
+	markings := VariableMarkings{}
+	found, err := cacao.DecodeExtension(playbook.PlaybookExtensions, VariableMarkingsExtensionId, &markings)
+	if !found {
+		return policy
+	}
+	if err != nil {
+		log.Warning(fmt.Errorf("invalid variable markings of playbook [ %s ], marking the playbook TLP:RED: %w", playbook.ID, err))
+		policy.Level = Red
+		return policy
+	}
+	for name, ids := range markings.Variables {
+		if level := levelOf(playbook, ids); level > policy.Level {
+			policy.Variables[name] = level
+		}
+	}
+	return policy
+}
+
+// The highest level of the referenced marking definitions
+func levelOf(playbook cacao.Playbook, markingIds []string) Level {
+	highest := Clear
+	for _, id := range markingIds {
+		definition, found := playbook.DataMarkingDefinitions[id]
+		if !found {
+			log.Warning(fmt.Sprintf("marking %s of playbook [ %s ] is not defined, taking it as TLP:RED", id, playbook.ID))
+			return Red
+		}
+		if definition.Revoked {
+			continue
+		}
+		value := definition.TLPv2Level
+		if value == "" {
+			// TLPv1 markings and IEP markings
+			value = definition.TLP
+		}
+		if value == "" {
+			continue
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			log.Warning(fmt.Sprintf("marking %s of playbook [ %s ]: %s, taking it as TLP:RED", id, playbook.ID, err))
+		}
+		if level > highest {
+			highest = level
+		}
+	}
+	return highest
+}
+
+// Level of a variable, variables created during the execution have the level
+// of the playbook
+func (policy Policy) LevelOf(name string) Level {
+	if level, found := policy.Variables[name]; found {
+		return level
+	}
+	return policy.Level
+}
+
+// The variables with the values above the maximum level replaced. The
+// variables are returned as is when nothing is redacted.
+func (policy Policy) Redact(variables cacao.Variables, maxLevel Level) cacao.Variables {
+	if !policy.exceeds(variables, maxLevel) {
+		return variables
+	}
+	redacted := cacao.NewVariables()
+	for name, variable := range variables {
+		if policy.LevelOf(name) > maxLevel {
+			variable.Value = RedactedValue
+		}
+		redacted[name] = variable
+	}
+	return redacted
+}
+
+// The variables without the ones above the maximum level. The variables are
+// returned as is when nothing is filtered.
+func (policy Policy) Filter(variables cacao.Variables, maxLevel Level) cacao.Variables {
+	if !policy.exceeds(variables, maxLevel) {
+		return variables
+	}
+	filtered := cacao.NewVariables()
+	for name, variable := range variables {
+		if policy.LevelOf(name) <= maxLevel {
+			filtered[name] = variable
+		}
+	}
+	return filtered
+}
+
+func (policy Policy) exceeds(variables cacao.Variables, maxLevel Level) bool {
+	for name := range variables {
+		if policy.LevelOf(name) > maxLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// The playbook with its variables redacted
+func (policy Policy) RedactPlaybook(playbook cacao.Playbook, maxLevel Level) cacao.Playbook {
+	playbook.PlaybookVariables = policy.Redact(playbook.PlaybookVariables, maxLevel)
+	return playbook
+}
+
+// The step with its variables redacted
+func (policy Policy) RedactStep(step cacao.Step, maxLevel Level) cacao.Step {
+	step.StepVariables = policy.Redact(step.StepVariables, maxLevel)
+	return step
+}
+
+type policyKey struct{}
+
+// Carry the policy of an execution to the executors and capabilities
+func WithPolicy(ctx context.Context, policy Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, policy)
+}
+
+// Get the policy of the execution a context belongs to, data outside of an
+// execution is unmarked
+func PolicyFrom(ctx context.Context) Policy {
+	if policy, ok := ctx.Value(policyKey{}).(Policy); ok {
+		return policy
+	}
+	return Policy{}
+}
@@ -0,0 +1,105 @@
+package marking
+
+import (
+	"context"
+	"testing"
+
+	"soarca/pkg/models/cacao"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func markedPlaybook(markings ...string) cacao.Playbook {
+	return cacao.Playbook{ID: "playbook--1",
+		Markings: markings,
+		DataMarkingDefinitions: cacao.DataMarkings{
+			"marking-tlp--green":   {Type: "marking-tlp", ID: "marking-tlp--green", TLPv2Level: "TLP:GREEN"},
+			"marking-tlp--red":     {Type: "marking-tlp", ID: "marking-tlp--red", TLPv2Level: "TLP:RED"},
+			"marking-tlp--revoked": {Type: "marking-tlp", ID: "marking-tlp--revoked", TLPv2Level: "TLP:RED", Revoked: true},
+			"marking-iep--amber":   {Type: "marking-iep", ID: "marking-iep--amber", TLP: "amber", IEPVersion: "2.0"},
+			"marking-statement--1": {Type: "marking-statement", ID: "marking-statement--1", Statement: "Copyright"},
+			"marking-tlp--invalid": {Type: "marking-tlp", ID: "marking-tlp--invalid", TLPv2Level: "TLP:BLUE"},
+		}}
+}
+
+func TestParseLevel(t *testing.T) {
+	for value, expected := range map[string]Level{
+		"TLP:CLEAR":        Clear,
+		"white":            Clear,
+		"green":            Green,
+		"TLP:AMBER":        Amber,
+		"tlp:amber+strict": AmberStrict,
+		"RED":              Red,
+	} {
+		level, err := ParseLevel(value)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, level, expected)
+	}
+
+	level, err := ParseLevel("TLP:BLUE")
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, level, Red)
+}
+
+func TestPolicyOfPlaybook(t *testing.T) {
+	assert.Equal(t, PolicyOf(markedPlaybook()).Level, Clear)
+	assert.Equal(t, PolicyOf(markedPlaybook("marking-tlp--green")).Level, Green)
+	assert.Equal(t, PolicyOf(markedPlaybook("marking-tlp--green", "marking-iep--amber")).Level, Amber)
+	assert.Equal(t, PolicyOf(markedPlaybook("marking-tlp--revoked", "marking-statement--1")).Level, Clear)
+
+	// Markings that cannot be resolved are taken as the highest level
+	assert.Equal(t, PolicyOf(markedPlaybook("marking-tlp--unknown")).Level, Red)
+	assert.Equal(t, PolicyOf(markedPlaybook("marking-tlp--invalid")).Level, Red)
+}
+
+func TestPolicyOfVariables(t *testing.T) {
+	playbook := markedPlaybook("marking-tlp--green")
+	playbook.PlaybookExtensions = cacao.Extensions{VariableMarkingsExtensionId: map[string]interface{}{
+		"variables": map[string]interface{}{
+			"__password__": []string{"marking-tlp--red"},
+			"__host__":     []string{"marking-tlp--revoked"},
+		}}}
+
+	policy := PolicyOf(playbook)
+	assert.Equal(t, policy.LevelOf("__password__"), Red)
+	// Variables cannot be marked lower than their playbook
+	assert.Equal(t, policy.LevelOf("__host__"), Green)
+	assert.Equal(t, policy.LevelOf("__output__"), Green)
+
+	playbook.PlaybookExtensions[VariableMarkingsExtensionId] = map[string]interface{}{"variables": "all"}
+	assert.Equal(t, PolicyOf(playbook).Level, Red)
+}
+
+func TestRedactAndFilter(t *testing.T) {
+	policy := Policy{Level: Green, Variables: map[string]Level{"__password__": Red}}
+	host := cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.1"}
+	password := cacao.Variable{Type: cacao.VariableTypeString, Name: "__password__", Value: "secret"}
+	variables := cacao.NewVariables(host, password)
+
+	redacted := policy.Redact(variables, Amber)
+	assert.Equal(t, redacted["__host__"], host)
+	assert.Equal(t, redacted["__password__"].Value, RedactedValue)
+	assert.Equal(t, variables["__password__"].Value, "secret")
+	assert.Equal(t, policy.Redact(variables, Red), variables)
+
+	assert.Equal(t, policy.Filter(variables, Amber), cacao.NewVariables(host))
+	assert.Equal(t, policy.Filter(variables, Clear), cacao.NewVariables())
+	assert.Equal(t, policy.Filter(variables, Red), variables)
+}
+
+func TestPolicyFromContext(t *testing.T) {
+	policy := Policy{Level: Amber}
+	assert.Equal(t, PolicyFrom(WithPolicy(context.Background(), policy)), policy)
+	assert.Equal(t, PolicyFrom(context.Background()), Policy{})
+}
+
+type restricted struct{}
+
+func (restricted) MaxMarking() Level {
+	return AmberStrict
+}
+
+func TestMaxMarkingOf(t *testing.T) {
+	assert.Equal(t, MaxMarkingOf(restricted{}), AmberStrict)
+	assert.Equal(t, MaxMarkingOf(struct{}{}), Clear)
+}
@@ -0,0 +1,324 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"soarca/internal/logger"
+	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/execution_manager"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+	journal_model "soarca/pkg/models/journal"
+	"soarca/pkg/utils/guid"
+	timeUtil "soarca/pkg/utils/time"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	component = reflect.TypeOf(Scheduler{}).PkgPath()
+	log       *logger.Log
+)
+
+func init() {
+	log = logger.Logger(component, logger.Info, "", logger.Json)
+}
+
+// Playbook extension limiting the executions of a playbook running at the same time (not part of CACAO spec)
+const ConcurrencyExtensionId = "extension-definition--5f1c7d3e-2b8a-4e6f-9c0d-7a4b3e2f1d6c"
+
+type Concurrency struct {
+	MaxConcurrentExecutions int `json:"max_concurrent_executions"`
+}
+
+// Sits between the trigger API and the decomposers, executions are started
+// when a slot is free
+type IScheduler interface {
+	// Queue a playbook execution, the execution id is handed out right away.
+	// The result is sent on the returned channel when the execution has ended.
+	Schedule(decomposer decomposer.IDecomposer, playbook cacao.Playbook) (uuid.UUID, <-chan Result, error)
+	Status() QueueStatus
+}
+
+// Decomposer that continues an execution recorded in the journal
+type IResumer interface {
+	Resume(ctx context.Context, record journal_model.ExecutionRecord) (*decomposer.ExecutionDetails, error)
+}
+
+// Informed when an execution is queued, e.g. the cache behind the reporter API
+type IQueueReporter interface {
+	ReportExecutionQueued(executionId uuid.UUID, playbook cacao.Playbook, at time.Time) error
+	ReportQueuedExecutionCancelled(executionId uuid.UUID, at time.Time) error
+}
+
+type Limits struct {
+	// Executions running at the same time
+	MaxRunning int
+	// Executions waiting for a slot, 0 for no limit
+	MaxQueued int
+	// Executions of one playbook running at the same time, 0 for no limit.
+	// Playbooks can set a lower limit with the concurrency extension.
+	MaxRunningPerPlaybook int
+}
+
+// Outcome of an ended execution
+type Result struct {
+	Details decomposer.ExecutionDetails
+	Err     error
+}
+
+type QueueStatus struct {
+	Running    int
+	Queued     int
+	MaxRunning int
+	MaxQueued  int
+}
+
+type Scheduler struct {
+	mutex         sync.Mutex
+	guid          guid.IGuid
+	time          timeUtil.ITime
+	limits        Limits
+	queueReporter IQueueReporter
+	// Executions are registered when they are queued, so they can be cancelled
+	// before they start
+	executionManager execution_manager.IExecutionManager
+	// Ordered on priority and severity, executions with the same order are
+	// started first in first out
+	queue              []*queuedExecution
+	running            int
+	runningPerPlaybook map[string]int
+}
+
+type queuedExecution struct {
+	executionId uuid.UUID
+	playbook    cacao.Playbook
+	start       func(ctx context.Context) (*decomposer.ExecutionDetails, error)
+	limit       int
+	// Context the execution runs with, cancelled through the execution manager
+	ctx    context.Context
+	cancel context.CancelFunc
+	// Buffered, so the result can be sent whether or not somebody waits for it
+	result chan Result
+}
+
+func New(guid guid.IGuid, time timeUtil.ITime, limits Limits) *Scheduler {
+	if limits.MaxRunning < 1 {
+		limits.MaxRunning = 1
+	}
+	return &Scheduler{guid: guid,
+		time:               time,
+		limits:             limits,
+		runningPerPlaybook: map[string]int{}}
+}
+
+func (scheduler *Scheduler) SetQueueReporter(queueReporter IQueueReporter) {
+	scheduler.queueReporter = queueReporter
+}
+
+func (scheduler *Scheduler) SetExecutionManager(executionManager execution_manager.IExecutionManager) {
+	scheduler.executionManager = executionManager
+}
+
+func (scheduler *Scheduler) Schedule(playbookDecomposer decomposer.IDecomposer, playbook cacao.Playbook) (uuid.UUID, <-chan Result, error) {
+	limit, err := scheduler.playbookLimit(playbook)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	if scheduler.limits.MaxQueued > 0 && len(scheduler.queue) >= scheduler.limits.MaxQueued {
+		return uuid.Nil, nil, execution.ErrorQueueFull{PlaybookId: playbook.ID, Queued: len(scheduler.queue)}
+	}
+
+	entry := &queuedExecution{executionId: scheduler.guid.New(),
+		playbook: playbook,
+		limit:    limit,
+		result:   make(chan Result, 1)}
+	entry.start = func(ctx context.Context) (*decomposer.ExecutionDetails, error) {
+		return playbookDecomposer.ExecuteWithId(ctx, entry.executionId, playbook)
+	}
+	scheduler.enqueue(entry)
+	return entry.executionId, entry.result, nil
+}
+
+// Queue an execution that did not finish before the last shutdown, under its
+// original execution id. It was accepted before, so the queue limit does not apply.
+func (scheduler *Scheduler) Resume(resumer IResumer, record journal_model.ExecutionRecord) (<-chan Result, error) {
+	executionId, err := uuid.Parse(record.ExecutionId)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := scheduler.playbookLimit(record.Playbook)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	entry := &queuedExecution{executionId: executionId,
+		playbook: record.Playbook,
+		limit:    limit,
+		result:   make(chan Result, 1)}
+	entry.start = func(ctx context.Context) (*decomposer.ExecutionDetails, error) {
+		return resumer.Resume(ctx, record)
+	}
+	scheduler.enqueue(entry)
+	return entry.result, nil
+}
+
+// Insert an execution in the queue and start it when a slot is free. Callers
+// must hold the lock.
+func (scheduler *Scheduler) enqueue(entry *queuedExecution) {
+	log.Debugf("queueing execution %s for Playbook %s", entry.executionId, entry.playbook.ID)
+	// Registered once for the whole execution, the decomposer runs it under
+	// this registration so it can be paused and cancelled while queued
+	entry.ctx, entry.cancel = context.WithCancel(context.Background())
+	if scheduler.executionManager != nil {
+		entry.ctx = scheduler.executionManager.Register(entry.ctx, entry.executionId, func() {
+			scheduler.cancel(entry)
+		})
+	}
+
+	position, _ := slices.BinarySearchFunc(scheduler.queue, entry, func(queued *queuedExecution, target *queuedExecution) int {
+		if before(target, queued) {
+			return 1
+		}
+		return -1
+	})
+	scheduler.queue = slices.Insert(scheduler.queue, position, entry)
+
+	// Reported before the execution can start, so the reporter sees it queued first
+	if scheduler.queueReporter != nil {
+		err := scheduler.queueReporter.ReportExecutionQueued(entry.executionId, entry.playbook, scheduler.time.Now())
+		if err != nil {
+			log.Warning(err)
+		}
+	}
+	scheduler.dispatch()
+}
+
+// Remove a queued execution from the queue, a running execution is cancelled
+// through its context
+func (scheduler *Scheduler) cancel(entry *queuedExecution) {
+	scheduler.mutex.Lock()
+	index := slices.Index(scheduler.queue, entry)
+	if index < 0 {
+		scheduler.mutex.Unlock()
+		entry.cancel()
+		return
+	}
+	scheduler.queue = slices.Delete(scheduler.queue, index, index+1)
+	scheduler.mutex.Unlock()
+
+	log.Info("cancelling queued execution ", entry.executionId)
+	entry.cancel()
+	if scheduler.executionManager != nil {
+		scheduler.executionManager.Deregister(entry.executionId)
+	}
+	if scheduler.queueReporter != nil {
+		err := scheduler.queueReporter.ReportQueuedExecutionCancelled(entry.executionId, scheduler.time.Now())
+		if err != nil {
+			log.Warning(err)
+		}
+	}
+	entry.result <- Result{Details: decomposer.ExecutionDetails{ExecutionId: entry.executionId, PlaybookId: entry.playbook.ID},
+		Err: fmt.Errorf("execution cancelled while queued: %w", context.Canceled)}
+}
+
+func (scheduler *Scheduler) Status() QueueStatus {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	return QueueStatus{Running: scheduler.running,
+		Queued:     len(scheduler.queue),
+		MaxRunning: scheduler.limits.MaxRunning,
+		MaxQueued:  scheduler.limits.MaxQueued}
+}
+
+// Higher priority goes first, then higher severity. CACAO priority 1 is the
+// highest and 100 the lowest, 0 is not specified and goes after all others.
+func before(execution *queuedExecution, other *queuedExecution) bool {
+	priority, otherPriority := queuePriority(execution.playbook), queuePriority(other.playbook)
+	if priority != otherPriority {
+		return priority < otherPriority
+	}
+	return execution.playbook.Severity > other.playbook.Severity
+}
+
+func queuePriority(playbook cacao.Playbook) int {
+	if playbook.Priority == 0 {
+		return math.MaxInt
+	}
+	return playbook.Priority
+}
+
+// Limit of the playbook, the lower of the configured limit and the playbook extension
+func (scheduler *Scheduler) playbookLimit(playbook cacao.Playbook) (int, error) {
+	limit := scheduler.limits.MaxRunningPerPlaybook
+	concurrency := Concurrency{}
+	found, err := cacao.DecodeExtension(playbook.PlaybookExtensions, ConcurrencyExtensionId, &concurrency)
+	if !found {
+		return limit, nil
+	}
+	if err == nil && concurrency.MaxConcurrentExecutions < 1 {
+		err = errors.New("max_concurrent_executions must be at least 1")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid concurrency of playbook [ %s ]: %w", playbook.ID, err)
+	}
+	if limit == 0 || concurrency.MaxConcurrentExecutions < limit {
+		limit = concurrency.MaxConcurrentExecutions
+	}
+	return limit, nil
+}
+
+// Start queued executions while there are free slots, executions of a
+// playbook at its limit are skipped. Callers must hold the lock.
+func (scheduler *Scheduler) dispatch() {
+	for index := 0; index < len(scheduler.queue) && scheduler.running < scheduler.limits.MaxRunning; {
+		entry := scheduler.queue[index]
+		playbookId := entry.playbook.ID
+		if entry.limit > 0 && scheduler.runningPerPlaybook[playbookId] >= entry.limit {
+			index++
+			continue
+		}
+		scheduler.queue = slices.Delete(scheduler.queue, index, index+1)
+		scheduler.running++
+		scheduler.runningPerPlaybook[playbookId]++
+		go scheduler.run(entry)
+	}
+}
+
+func (scheduler *Scheduler) run(entry *queuedExecution) {
+	// The execution outlives the request, it is cancelled through the execution API
+	details, err := entry.start(entry.ctx)
+	if err != nil {
+		log.Debug(fmt.Sprintf("execution %s ended with error: %s", entry.executionId, err))
+	}
+	entry.cancel()
+	if scheduler.executionManager != nil {
+		scheduler.executionManager.Deregister(entry.executionId)
+	}
+
+	scheduler.mutex.Lock()
+	scheduler.running--
+	scheduler.runningPerPlaybook[entry.playbook.ID]--
+	if scheduler.runningPerPlaybook[entry.playbook.ID] == 0 {
+		delete(scheduler.runningPerPlaybook, entry.playbook.ID)
+	}
+	scheduler.dispatch()
+	scheduler.mutex.Unlock()
+
+	result := Result{Details: decomposer.ExecutionDetails{ExecutionId: entry.executionId, PlaybookId: entry.playbook.ID}, Err: err}
+	if details != nil {
+		result.Details = *details
+	}
+	entry.result <- result
+}
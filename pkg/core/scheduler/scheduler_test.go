@@ -0,0 +1,351 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/execution_manager"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+	"soarca/pkg/models/journal"
+	"soarca/pkg/utils/guid"
+	"soarca/test/unittest/mocks/mock_decomposer"
+	mock_time "soarca/test/unittest/mocks/mock_utils/time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockQueueReporter struct {
+	mock.Mock
+}
+
+func (reporter *mockQueueReporter) ReportExecutionQueued(executionId uuid.UUID, playbook cacao.Playbook, at time.Time) error {
+	args := reporter.Called(executionId, playbook, at)
+	return args.Error(0)
+}
+
+func (reporter *mockQueueReporter) ReportQueuedExecutionCancelled(executionId uuid.UUID, at time.Time) error {
+	args := reporter.Called(executionId, at)
+	return args.Error(0)
+}
+
+type mockResumer struct {
+	mock.Mock
+}
+
+func (resumer *mockResumer) Resume(ctx context.Context, record journal.ExecutionRecord) (*decomposer.ExecutionDetails, error) {
+	args := resumer.Called(ctx, record)
+	return args.Get(0).(*decomposer.ExecutionDetails), args.Error(1)
+}
+
+// Executions block until their playbook is released and report their start
+func blockingDecomposer(started chan string, release map[string]chan struct{}) *mock_decomposer.Mock_Decomposer {
+	decomposerMock := new(mock_decomposer.Mock_Decomposer)
+	decomposerMock.On("ExecuteWithId", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		playbook := args.Get(2).(cacao.Playbook)
+		started <- playbook.ID
+		<-release[playbook.ID]
+	}).Return(&decomposer.ExecutionDetails{}, nil)
+	return decomposerMock
+}
+
+func TestScheduleInPriorityOrder(t *testing.T) {
+	timeMock := new(mock_time.MockTime)
+	reporter := new(mockQueueReporter)
+	at := time.Date(2024, time.January, 1, 1, 1, 1, 0, time.UTC)
+	timeMock.On("Now").Return(at)
+	reporter.On("ReportExecutionQueued", mock.Anything, mock.Anything, at).Return(nil)
+
+	started := make(chan string)
+	release := map[string]chan struct{}{}
+	playbooks := []cacao.Playbook{
+		{ID: "playbook--running"},
+		{ID: "playbook--low", Priority: 50},
+		{ID: "playbook--high", Priority: 10},
+		{ID: "playbook--high-severe", Priority: 10, Severity: 80},
+		{ID: "playbook--high-late", Priority: 10},
+	}
+	for _, playbook := range playbooks {
+		release[playbook.ID] = make(chan struct{})
+	}
+	decomposerMock := blockingDecomposer(started, release)
+
+	scheduler := New(&guid.Guid{}, timeMock, Limits{MaxRunning: 1})
+	scheduler.SetQueueReporter(reporter)
+
+	for _, playbook := range playbooks {
+		executionId, _, err := scheduler.Schedule(decomposerMock, playbook)
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, executionId, uuid.Nil)
+	}
+	assert.Equal(t, <-started, "playbook--running")
+	assert.Equal(t, scheduler.Status(), QueueStatus{Running: 1, Queued: 4, MaxRunning: 1})
+
+	expected := []string{"playbook--running", "playbook--high-severe", "playbook--high", "playbook--high-late"}
+	for index := 1; index < len(expected); index++ {
+		close(release[expected[index-1]])
+		assert.Equal(t, <-started, expected[index])
+	}
+	close(release["playbook--high-late"])
+	assert.Equal(t, <-started, "playbook--low")
+	close(release["playbook--low"])
+
+	reporter.AssertNumberOfCalls(t, "ReportExecutionQueued", len(playbooks))
+}
+
+func TestScheduleHighestCacaoPriorityFirst(t *testing.T) {
+	started := make(chan string)
+	release := map[string]chan struct{}{}
+	playbooks := []cacao.Playbook{
+		{ID: "playbook--running", Priority: 1},
+		{ID: "playbook--unspecified"},
+		{ID: "playbook--priority-50", Priority: 50},
+		{ID: "playbook--priority-1", Priority: 1},
+	}
+	for _, playbook := range playbooks {
+		release[playbook.ID] = make(chan struct{})
+	}
+	decomposerMock := blockingDecomposer(started, release)
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1})
+	for _, playbook := range playbooks {
+		_, _, err := scheduler.Schedule(decomposerMock, playbook)
+		assert.Equal(t, err, nil)
+	}
+
+	// Priority 1 is the highest, an unspecified priority goes last
+	expected := []string{"playbook--running", "playbook--priority-1", "playbook--priority-50", "playbook--unspecified"}
+	assert.Equal(t, <-started, expected[0])
+	for index := 1; index < len(expected); index++ {
+		close(release[expected[index-1]])
+		assert.Equal(t, <-started, expected[index])
+	}
+	close(release["playbook--unspecified"])
+}
+
+func TestSchedulePlaybookConcurrencyLimit(t *testing.T) {
+	started := make(chan string)
+	release := map[string]chan struct{}{
+		"playbook--limited": make(chan struct{}),
+		"playbook--other":   make(chan struct{}),
+	}
+	decomposerMock := blockingDecomposer(started, release)
+
+	limited := cacao.Playbook{ID: "playbook--limited",
+		PlaybookExtensions: cacao.Extensions{ConcurrencyExtensionId: map[string]interface{}{
+			"max_concurrent_executions": 1,
+		}}}
+	other := cacao.Playbook{ID: "playbook--other"}
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 2})
+	for _, playbook := range []cacao.Playbook{limited, limited, other} {
+		_, _, err := scheduler.Schedule(decomposerMock, playbook)
+		assert.Equal(t, err, nil)
+	}
+
+	// The second execution of the limited playbook waits for the first one
+	running := map[string]bool{<-started: true, <-started: true}
+	assert.Equal(t, running, map[string]bool{"playbook--limited": true, "playbook--other": true})
+	assert.Equal(t, scheduler.Status().Queued, 1)
+
+	release["playbook--limited"] <- struct{}{}
+	assert.Equal(t, <-started, "playbook--limited")
+	close(release["playbook--limited"])
+	close(release["playbook--other"])
+}
+
+func TestScheduleInvalidConcurrency(t *testing.T) {
+	decomposerMock := new(mock_decomposer.Mock_Decomposer)
+	playbook := cacao.Playbook{ID: "playbook--1",
+		PlaybookExtensions: cacao.Extensions{ConcurrencyExtensionId: map[string]interface{}{
+			"max_concurrent_executions": 0,
+		}}}
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1})
+	executionId, _, err := scheduler.Schedule(decomposerMock, playbook)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, executionId, uuid.Nil)
+	decomposerMock.AssertNotCalled(t, "ExecuteWithId", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScheduleWithFullQueue(t *testing.T) {
+	started := make(chan string)
+	release := map[string]chan struct{}{"playbook--1": make(chan struct{})}
+	decomposerMock := blockingDecomposer(started, release)
+	playbook := cacao.Playbook{ID: "playbook--1"}
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1, MaxQueued: 1})
+	_, _, err := scheduler.Schedule(decomposerMock, playbook)
+	assert.Equal(t, err, nil)
+	<-started
+	_, _, err = scheduler.Schedule(decomposerMock, playbook)
+	assert.Equal(t, err, nil)
+
+	_, _, err = scheduler.Schedule(decomposerMock, playbook)
+	assert.Equal(t, errors.As(err, &execution.ErrorQueueFull{}), true)
+	assert.Equal(t, scheduler.Status(), QueueStatus{Running: 1, Queued: 1, MaxRunning: 1, MaxQueued: 1})
+	close(release["playbook--1"])
+}
+
+func TestScheduleSendsResult(t *testing.T) {
+	variables := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString, Name: "__verdict__", Value: "benign"})
+	failure := errors.New("step failed")
+	decomposerMock := new(mock_decomposer.Mock_Decomposer)
+	playbook := cacao.Playbook{ID: "playbook--1"}
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1})
+	decomposerMock.On("ExecuteWithId", mock.Anything, mock.Anything, playbook).Return(&decomposer.ExecutionDetails{
+		PlaybookId: playbook.ID,
+		Variables:  variables}, failure)
+
+	executionId, result, err := scheduler.Schedule(decomposerMock, playbook)
+	assert.Equal(t, err, nil)
+
+	ended := <-result
+	assert.Equal(t, ended.Details.Variables, variables)
+	assert.Equal(t, ended.Err, failure)
+	decomposerMock.AssertCalled(t, "ExecuteWithId", mock.Anything, executionId, playbook)
+	assert.Equal(t, scheduler.Status().Running, 0)
+}
+
+func TestResumeWaitsForFreeSlot(t *testing.T) {
+	started := make(chan string)
+	release := map[string]chan struct{}{
+		"playbook--running": make(chan struct{}),
+		"playbook--queued":  make(chan struct{}),
+	}
+	decomposerMock := blockingDecomposer(started, release)
+	resumerMock := new(mockResumer)
+	record := journal.ExecutionRecord{ExecutionId: "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		Playbook: cacao.Playbook{ID: "playbook--resumed"},
+		StepId:   "action--test"}
+	resumerMock.On("Resume", mock.Anything, record).Return(&decomposer.ExecutionDetails{
+		ExecutionId: uuid.MustParse(record.ExecutionId),
+		PlaybookId:  record.Playbook.ID}, nil)
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1, MaxQueued: 1})
+	_, _, err := scheduler.Schedule(decomposerMock, cacao.Playbook{ID: "playbook--running"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-started, "playbook--running")
+
+	// The queue limit does not apply to resumed executions
+	_, _, err = scheduler.Schedule(decomposerMock, cacao.Playbook{ID: "playbook--queued"})
+	assert.Equal(t, err, nil)
+	result, err := scheduler.Resume(resumerMock, record)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, scheduler.Status().Queued, 2)
+	resumerMock.AssertNotCalled(t, "Resume", mock.Anything, record)
+
+	close(release["playbook--running"])
+	assert.Equal(t, <-started, "playbook--queued")
+	close(release["playbook--queued"])
+	ended := <-result
+	assert.Equal(t, ended.Details.ExecutionId.String(), record.ExecutionId)
+	assert.Equal(t, ended.Err, nil)
+}
+
+func TestResumeInvalidExecutionId(t *testing.T) {
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1})
+	_, err := scheduler.Resume(new(mockResumer), journal.ExecutionRecord{ExecutionId: "invalid"})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, scheduler.Status().Queued, 0)
+}
+
+func TestCancelQueuedExecution(t *testing.T) {
+	timeMock := new(mock_time.MockTime)
+	reporter := new(mockQueueReporter)
+	at := time.Date(2024, time.January, 1, 1, 1, 1, 0, time.UTC)
+	timeMock.On("Now").Return(at)
+	reporter.On("ReportExecutionQueued", mock.Anything, mock.Anything, at).Return(nil)
+
+	started := make(chan string)
+	release := map[string]chan struct{}{"playbook--running": make(chan struct{})}
+	decomposerMock := blockingDecomposer(started, release)
+	manager := execution_manager.New()
+
+	scheduler := New(&guid.Guid{}, timeMock, Limits{MaxRunning: 1})
+	scheduler.SetQueueReporter(reporter)
+	scheduler.SetExecutionManager(manager)
+
+	_, _, err := scheduler.Schedule(decomposerMock, cacao.Playbook{ID: "playbook--running"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-started, "playbook--running")
+	queuedId, result, err := scheduler.Schedule(decomposerMock, cacao.Playbook{ID: "playbook--queued"})
+	assert.Equal(t, err, nil)
+	reporter.On("ReportQueuedExecutionCancelled", queuedId, at).Return(nil).Once()
+
+	// The execution id handed out by Schedule can be cancelled right away
+	assert.Equal(t, manager.Cancel(queuedId), nil)
+	ended := <-result
+	assert.Equal(t, errors.Is(ended.Err, context.Canceled), true)
+	assert.Equal(t, ended.Details.ExecutionId, queuedId)
+	assert.Equal(t, scheduler.Status().Queued, 0)
+	assert.Equal(t, errors.As(manager.Cancel(queuedId), &execution.ErrorExecutionNotFound{}), true)
+
+	close(release["playbook--running"])
+	reporter.AssertExpectations(t)
+	decomposerMock.AssertNumberOfCalls(t, "ExecuteWithId", 1)
+}
+
+func TestCancelStartedExecutionThroughScheduler(t *testing.T) {
+	decomposerMock := new(mock_decomposer.Mock_Decomposer)
+	playbook := cacao.Playbook{ID: "playbook--1"}
+	running := make(chan struct{})
+	decomposerMock.On("ExecuteWithId", mock.Anything, mock.Anything, playbook).Run(func(args mock.Arguments) {
+		close(running)
+		<-args.Get(0).(context.Context).Done()
+	}).Return(&decomposer.ExecutionDetails{}, context.Canceled)
+	manager := execution_manager.New()
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1})
+	scheduler.SetExecutionManager(manager)
+	executionId, result, err := scheduler.Schedule(decomposerMock, playbook)
+	assert.Equal(t, err, nil)
+	<-running
+
+	assert.Equal(t, manager.Cancel(executionId), nil)
+	assert.Equal(t, (<-result).Err, context.Canceled)
+}
+
+func TestPauseQueuedExecution(t *testing.T) {
+	decomposerMock := new(mock_decomposer.Mock_Decomposer)
+	running := cacao.Playbook{ID: "playbook--running"}
+	queued := cacao.Playbook{ID: "playbook--queued"}
+	release := make(chan struct{})
+	started := make(chan context.Context, 2)
+	decomposerMock.On("ExecuteWithId", mock.Anything, mock.Anything, running).Run(func(args mock.Arguments) {
+		started <- args.Get(0).(context.Context)
+		<-release
+	}).Return(&decomposer.ExecutionDetails{}, nil)
+	decomposerMock.On("ExecuteWithId", mock.Anything, mock.Anything, queued).Run(func(args mock.Arguments) {
+		started <- args.Get(0).(context.Context)
+	}).Return(&decomposer.ExecutionDetails{}, nil)
+	manager := execution_manager.New()
+
+	scheduler := New(&guid.Guid{}, new(mock_time.MockTime), Limits{MaxRunning: 1})
+	scheduler.SetExecutionManager(manager)
+
+	_, _, err := scheduler.Schedule(decomposerMock, running)
+	assert.Equal(t, err, nil)
+	<-started
+	queuedId, result, err := scheduler.Schedule(decomposerMock, queued)
+	assert.Equal(t, err, nil)
+
+	// The pause is kept when the execution starts, the decomposer runs it
+	// under the registration of the scheduler
+	assert.Equal(t, manager.Pause(queuedId), nil)
+	close(release)
+	state, registered := execution_manager.StateFromContext(<-started)
+	assert.Equal(t, registered, true)
+	assert.Equal(t, state.ExecutionId(), queuedId)
+	paused, _ := state.Paused()
+	assert.Equal(t, paused, true)
+
+	<-result
+	assert.Equal(t, errors.As(manager.Pause(queuedId), &execution.ErrorExecutionNotFound{}), true)
+}
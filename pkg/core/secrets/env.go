@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"context"
+	"os"
+)
+
+// Secrets from environment variables with a prefix. With the prefix
+// SOARCA_SECRET_ the secret FIREWALL_TOKEN is read from SOARCA_SECRET_FIREWALL_TOKEN,
+// the prefix keeps the configuration of SOARCA itself out of reach of playbooks.
+type EnvProvider struct {
+	prefix string
+}
+
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+func (provider *EnvProvider) Secret(_ context.Context, name string) (string, error) {
+	value, found := os.LookupEnv(provider.prefix + name)
+	if !found {
+		return "", ErrorSecretNotFound{Name: name}
+	}
+	return value, nil
+}
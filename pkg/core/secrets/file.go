@@ -0,0 +1,38 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Secrets from files in a directory, like Docker or Kubernetes secrets mounted
+// at /run/secrets. The name is the path of the file within the directory, a
+// trailing newline is not part of the secret.
+type FileProvider struct {
+	directory string
+}
+
+func NewFileProvider(directory string) *FileProvider {
+	return &FileProvider{directory: directory}
+}
+
+func (provider *FileProvider) Secret(_ context.Context, name string) (string, error) {
+	// Names cannot point outside of the directory
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid secret name %s", name)
+	}
+	content, err := os.ReadFile(filepath.Join(provider.directory, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrorSecretNotFound{Name: name}
+	}
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSuffix(string(content), "\n")
+	return strings.TrimSuffix(secret, "\r"), nil
+}
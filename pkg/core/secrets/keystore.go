@@ -0,0 +1,190 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const keystoreVersion = 1
+
+// scrypt parameters of the key derivation
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	keystoreMode = 0600
+)
+
+// Local file holding secrets by name. The secrets are encrypted with
+// AES-256-GCM under a key derived from a passphrase with scrypt, the file
+// holds no plaintext.
+type Keystore struct {
+	path    string
+	salt    []byte
+	key     []byte
+	secrets map[string]string
+	mutex   sync.Mutex
+}
+
+type keystoreFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// Open and decrypt the keystore at a path, a keystore that does not exist yet
+// is empty until it is saved
+func OpenKeystore(path string, passphrase string) (*Keystore, error) {
+	if passphrase == "" {
+		return nil, errors.New("the keystore passphrase is empty")
+	}
+	keystore := &Keystore{path: path, secrets: map[string]string{}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		keystore.salt = make([]byte, saltLength)
+		if _, err := rand.Read(keystore.salt); err != nil {
+			return nil, err
+		}
+		keystore.key, err = deriveKey(passphrase, keystore.salt)
+		return keystore, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := keystoreFile{}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("keystore %s is not a keystore: %w", path, err)
+	}
+	if file.Version != keystoreVersion {
+		return nil, fmt.Errorf("keystore %s has unsupported version %d", path, file.Version)
+	}
+	keystore.salt = file.Salt
+	keystore.key, err = deriveKey(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAead(keystore.key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt keystore %s, wrong passphrase or corrupted file", path)
+	}
+	if err := json.Unmarshal(plaintext, &keystore.secrets); err != nil {
+		return nil, fmt.Errorf("keystore %s holds invalid data: %w", path, err)
+	}
+	return keystore, nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keyLength)
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (keystore *Keystore) Secret(_ context.Context, name string) (string, error) {
+	keystore.mutex.Lock()
+	defer keystore.mutex.Unlock()
+	secret, found := keystore.secrets[name]
+	if !found {
+		return "", ErrorSecretNotFound{Name: name}
+	}
+	return secret, nil
+}
+
+// Add or replace a secret, changes are written by Save
+func (keystore *Keystore) Set(name string, secret string) {
+	keystore.mutex.Lock()
+	defer keystore.mutex.Unlock()
+	keystore.secrets[name] = secret
+}
+
+// Remove a secret, returns false when the keystore did not hold it
+func (keystore *Keystore) Delete(name string) bool {
+	keystore.mutex.Lock()
+	defer keystore.mutex.Unlock()
+	_, found := keystore.secrets[name]
+	delete(keystore.secrets, name)
+	return found
+}
+
+// The names of the secrets in the keystore, in order
+func (keystore *Keystore) Names() []string {
+	keystore.mutex.Lock()
+	defer keystore.mutex.Unlock()
+	names := make([]string, 0, len(keystore.secrets))
+	for name := range keystore.secrets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Encrypt the secrets and replace the keystore file, only the owner can read it
+func (keystore *Keystore) Save() error {
+	keystore.mutex.Lock()
+	defer keystore.mutex.Unlock()
+
+	plaintext, err := json.Marshal(keystore.secrets)
+	if err != nil {
+		return err
+	}
+	aead, err := newAead(keystore.key)
+	if err != nil {
+		return err
+	}
+	// A fresh nonce for every save, a nonce must never be reused with the same key
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(keystoreFile{Version: keystoreVersion,
+		Salt:  keystore.salt,
+		Nonce: nonce,
+		Data:  aead.Seal(nil, nonce, plaintext, nil)})
+	if err != nil {
+		return err
+	}
+
+	temporary, err := os.CreateTemp(filepath.Dir(keystore.path), filepath.Base(keystore.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	if err := temporary.Chmod(keystoreMode); err != nil {
+		temporary.Close()
+		return err
+	}
+	if _, err := temporary.Write(raw); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), keystore.path)
+}
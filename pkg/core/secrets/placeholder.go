@@ -0,0 +1,18 @@
+package secrets
+
+import "context"
+
+// Returned for every secret by the PlaceholderProvider
+const Placeholder = "********"
+
+// Stands in for the real providers in simulated executions, so a dry run never
+// reads or hands out actual secrets
+type PlaceholderProvider struct{}
+
+func NewPlaceholderProvider() *PlaceholderProvider {
+	return &PlaceholderProvider{}
+}
+
+func (provider *PlaceholderProvider) Secret(_ context.Context, _ string) (string, error) {
+	return Placeholder, nil
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"soarca/internal/logger"
+)
+
+type Empty struct{}
+
+var component = reflect.TypeOf(Empty{}).PkgPath()
+var log *logger.Log
+
+func init() {
+	log = logger.Logger(component, logger.Info, "", logger.Json)
+}
+
+// Authentication information refers to secrets as secret://<provider>/<name>,
+// e.g. secret://env/FIREWALL_TOKEN
+const ReferencePrefix = "secret://"
+
+// Names the providers shipped with SOARCA are registered under
+const (
+	EnvProviderName      = "env"
+	FileProviderName     = "file"
+	KeystoreProviderName = "keystore"
+)
+
+// Backend holding secrets by name. Other backends, like Vault or a cloud KMS,
+// implement this interface and are registered with the Resolver.
+type ISecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// Returned when a provider does not hold a secret
+type ErrorSecretNotFound struct {
+	Name string
+}
+
+func (e ErrorSecretNotFound) Error() string {
+	return fmt.Sprintf("secret [ %s ] not found", e.Name)
+}
+
+// Whether a value refers to a secret instead of holding it
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, ReferencePrefix)
+}
+
+// Resolves references with the provider they name. Names without a provider,
+// like KMS key identifiers, are looked up in the default provider.
+type Resolver struct {
+	providers       map[string]ISecretProvider
+	defaultProvider string
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{providers: map[string]ISecretProvider{}}
+}
+
+func (resolver *Resolver) Register(name string, provider ISecretProvider) {
+	log.Info(fmt.Sprintf("registered secret provider %s", name))
+	resolver.providers[name] = provider
+}
+
+func (resolver *Resolver) SetDefaultProvider(name string) {
+	resolver.defaultProvider = name
+}
+
+// Get the secret a reference or name points to. Errors name the secret, never
+// its value.
+func (resolver *Resolver) Secret(ctx context.Context, reference string) (string, error) {
+	providerName, name := resolver.defaultProvider, reference
+	if IsReference(reference) {
+		parts := strings.SplitN(strings.TrimPrefix(reference, ReferencePrefix), "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", fmt.Errorf("invalid secret reference %s", reference)
+		}
+		providerName, name = parts[0], parts[1]
+	}
+	if providerName == "" {
+		return "", errors.New("no default secret provider is configured")
+	}
+	provider, found := resolver.providers[providerName]
+	if !found {
+		return "", fmt.Errorf("secret provider %s is not configured", providerName)
+	}
+	secret, err := provider.Secret(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("secret provider %s: %w", providerName, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestResolverSecret(t *testing.T) {
+	t.Setenv("TEST_SECRET_FIREWALL_TOKEN", "token")
+	t.Setenv("FIREWALL_TOKEN", "unprefixed")
+	resolver := NewResolver()
+	resolver.Register(EnvProviderName, NewEnvProvider("TEST_SECRET_"))
+
+	secret, err := resolver.Secret(context.Background(), "secret://env/FIREWALL_TOKEN")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, secret, "token")
+
+	_, err = resolver.Secret(context.Background(), "secret://env/UNKNOWN")
+	assert.Equal(t, errors.As(err, &ErrorSecretNotFound{}), true)
+	assert.Equal(t, err.Error(), "secret provider env: secret [ UNKNOWN ] not found")
+
+	for _, reference := range []string{"secret://env", "secret:///FIREWALL_TOKEN", "secret://vault/FIREWALL_TOKEN", "FIREWALL_TOKEN"} {
+		_, err = resolver.Secret(context.Background(), reference)
+		assert.NotEqual(t, err, nil)
+	}
+
+	// Names without a provider go to the default provider
+	resolver.SetDefaultProvider(EnvProviderName)
+	secret, err = resolver.Secret(context.Background(), "FIREWALL_TOKEN")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, secret, "token")
+}
+
+func TestFileProviderSecret(t *testing.T) {
+	directory := t.TempDir()
+	err := os.WriteFile(filepath.Join(directory, "ssh_password"), []byte("password\n"), 0600)
+	assert.Equal(t, err, nil)
+	provider := NewFileProvider(directory)
+
+	secret, err := provider.Secret(context.Background(), "ssh_password")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, secret, "password")
+
+	_, err = provider.Secret(context.Background(), "unknown")
+	assert.Equal(t, err, ErrorSecretNotFound{Name: "unknown"})
+
+	_, err = provider.Secret(context.Background(), "../ssh_password")
+	assert.NotEqual(t, err, nil)
+	_, err = provider.Secret(context.Background(), "/etc/passwd")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestPlaceholderProviderSecret(t *testing.T) {
+	secret, err := NewPlaceholderProvider().Secret(context.Background(), "keystore/firewall")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, secret, Placeholder)
+}
+
+func TestKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+
+	keystore, err := OpenKeystore(path, "passphrase")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, keystore.Names(), []string{})
+	keystore.Set("firewall", "token")
+	keystore.Set("ssh", "password")
+	assert.Equal(t, keystore.Delete("ssh"), true)
+	assert.Equal(t, keystore.Delete("ssh"), false)
+	assert.Equal(t, keystore.Save(), nil)
+
+	info, err := os.Stat(path)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0600))
+	raw, err := os.ReadFile(path)
+	assert.Equal(t, err, nil)
+	// Only the encrypted secrets are stored
+	assert.Equal(t, strings.Contains(string(raw), "token"), false)
+
+	reopened, err := OpenKeystore(path, "passphrase")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reopened.Names(), []string{"firewall"})
+	secret, err := reopened.Secret(context.Background(), "firewall")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, secret, "token")
+	_, err = reopened.Secret(context.Background(), "ssh")
+	assert.Equal(t, err, ErrorSecretNotFound{Name: "ssh"})
+
+	_, err = OpenKeystore(path, "wrong")
+	assert.NotEqual(t, err, nil)
+	_, err = OpenKeystore(path, "")
+	assert.NotEqual(t, err, nil)
+}
@@ -3,6 +3,7 @@ package cases
 import (
 	"reflect"
 	"soarca/internal/logger"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/integration/thehive/common/connector"
 	thehive_models "soarca/pkg/integration/thehive/common/models"
 	"soarca/pkg/models/cacao"
@@ -23,11 +24,21 @@ func init() {
 }
 
 type HiveCaseManager struct {
-	connector connector.ITheHiveConnector
+	connector  connector.ITheHiveConnector
+	maxMarking marking.Level
 }
 
 func NewCaseManager(connector connector.ITheHiveConnector) *HiveCaseManager {
-	return &HiveCaseManager{connector: connector}
+	return &HiveCaseManager{connector: connector, maxMarking: marking.Amber}
+}
+
+// Set the highest marking of the data pushed into cases
+func (manager *HiveCaseManager) SetMaxMarking(maxMarking marking.Level) {
+	manager.maxMarking = maxMarking
+}
+
+func (manager *HiveCaseManager) MaxMarking() marking.Level {
+	return manager.maxMarking
 }
 
 func (manager *HiveCaseManager) AddToExistingOrCreateNew(meta execution.Metadata,
@@ -144,3 +155,15 @@ func (manager *HiveCaseManager) ReportStepEnd(executionId uuid.UUID, step cacao.
 	)
 	return err
 }
+
+// Attempts are not reported separately, the step end holds the outcome of the last attempt
+func (manager *HiveCaseManager) ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, stepErr error, at time.Time) error {
+	log.Trace("TheHive cases reporting step attempt")
+	return nil
+}
+
+// Iterations are visible through the steps executed in the loop body
+func (manager *HiveCaseManager) ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) error {
+	log.Trace("TheHive cases reporting loop iteration")
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"soarca/internal/logger"
+	"sync"
 )
 
 var (
@@ -17,7 +18,9 @@ func init() {
 
 // ############################### Playbook to TheHive ID mappings
 
+// Shared by all executions reported to TheHive, access is guarded by the mutex
 type SOARCATheHiveMap struct {
+	mutex              sync.RWMutex
 	ExecutionsCaseMaps map[string]ExecutionCaseMap
 }
 type ExecutionCaseMap struct {
@@ -28,12 +31,22 @@ type ExecutionCaseMap struct {
 // TODO: Change to using observables instead of updating the tasks descriptions
 
 func (soarcaTheHiveMap *SOARCATheHiveMap) CheckExecutionCaseExists(executionId string) error {
+	soarcaTheHiveMap.mutex.RLock()
+	defer soarcaTheHiveMap.mutex.RUnlock()
+	return soarcaTheHiveMap.checkExecutionCaseExists(executionId)
+}
+func (soarcaTheHiveMap *SOARCATheHiveMap) checkExecutionCaseExists(executionId string) error {
 	if _, ok := soarcaTheHiveMap.ExecutionsCaseMaps[executionId]; !ok {
 		return fmt.Errorf("case not found for execution id %s", executionId)
 	}
 	return nil
 }
 func (soarcaTheHiveMap *SOARCATheHiveMap) CheckExecutionStepTaskExists(executionId string, stepId string) error {
+	soarcaTheHiveMap.mutex.RLock()
+	defer soarcaTheHiveMap.mutex.RUnlock()
+	return soarcaTheHiveMap.checkExecutionStepTaskExists(executionId, stepId)
+}
+func (soarcaTheHiveMap *SOARCATheHiveMap) checkExecutionStepTaskExists(executionId string, stepId string) error {
 	if _, ok := soarcaTheHiveMap.ExecutionsCaseMaps[executionId].stepsTasksMap[stepId]; !ok {
 		return fmt.Errorf("task not found for execution id %s for step id %s", executionId, stepId)
 	}
@@ -41,6 +54,8 @@ func (soarcaTheHiveMap *SOARCATheHiveMap) CheckExecutionStepTaskExists(execution
 }
 
 func (soarcaTheHiveMap *SOARCATheHiveMap) RegisterExecutionInCase(executionId string, caseId string) error {
+	soarcaTheHiveMap.mutex.Lock()
+	defer soarcaTheHiveMap.mutex.Unlock()
 	soarcaTheHiveMap.ExecutionsCaseMaps[executionId] = ExecutionCaseMap{
 		caseId:        caseId,
 		stepsTasksMap: map[string]string{},
@@ -50,11 +65,15 @@ func (soarcaTheHiveMap *SOARCATheHiveMap) RegisterExecutionInCase(executionId st
 	return nil
 }
 func (soarcaTheHiveMap *SOARCATheHiveMap) RegisterStepTaskInCase(executionId string, stepId string, taskId string) {
+	soarcaTheHiveMap.mutex.Lock()
+	defer soarcaTheHiveMap.mutex.Unlock()
 	soarcaTheHiveMap.ExecutionsCaseMaps[executionId].stepsTasksMap[stepId] = taskId
 }
 
 func (soarcaTheHiveMap *SOARCATheHiveMap) RetrieveCaseId(executionId string) (string, error) {
-	err := soarcaTheHiveMap.CheckExecutionCaseExists(executionId)
+	soarcaTheHiveMap.mutex.RLock()
+	defer soarcaTheHiveMap.mutex.RUnlock()
+	err := soarcaTheHiveMap.checkExecutionCaseExists(executionId)
 	if err != nil {
 		return "", err
 	}
@@ -62,11 +81,13 @@ func (soarcaTheHiveMap *SOARCATheHiveMap) RetrieveCaseId(executionId string) (st
 }
 
 func (soarcaTheHiveMap *SOARCATheHiveMap) RetrieveTaskId(executionId string, stepId string) (string, error) {
-	err := soarcaTheHiveMap.CheckExecutionCaseExists(executionId)
+	soarcaTheHiveMap.mutex.RLock()
+	defer soarcaTheHiveMap.mutex.RUnlock()
+	err := soarcaTheHiveMap.checkExecutionCaseExists(executionId)
 	if err != nil {
 		return "", err
 	}
-	err = soarcaTheHiveMap.CheckExecutionStepTaskExists(executionId, stepId)
+	err = soarcaTheHiveMap.checkExecutionStepTaskExists(executionId, stepId)
 	if err != nil {
 		return "", err
 	}
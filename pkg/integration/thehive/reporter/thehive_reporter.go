@@ -3,6 +3,7 @@ package thehive
 import (
 	"reflect"
 	"soarca/internal/logger"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/integration/thehive/common/connector"
 	thehive_models "soarca/pkg/integration/thehive/common/models"
 	"soarca/pkg/models/cacao"
@@ -21,11 +22,21 @@ func init() {
 }
 
 type TheHiveReporter struct {
-	connector connector.ITheHiveConnector
+	connector  connector.ITheHiveConnector
+	maxMarking marking.Level
 }
 
 func NewReporter(connector connector.ITheHiveConnector) *TheHiveReporter {
-	return &TheHiveReporter{connector: connector}
+	return &TheHiveReporter{connector: connector, maxMarking: marking.Amber}
+}
+
+// Set the highest marking of the data pushed into cases
+func (theHiveReporter *TheHiveReporter) SetMaxMarking(maxMarking marking.Level) {
+	theHiveReporter.maxMarking = maxMarking
+}
+
+func (theHiveReporter *TheHiveReporter) MaxMarking() marking.Level {
+	return theHiveReporter.maxMarking
 }
 
 func (theHiveReporter *TheHiveReporter) ConnectorTest() string {
@@ -86,3 +97,15 @@ func (theHiveReporter *TheHiveReporter) ReportStepEnd(executionId uuid.UUID, ste
 	)
 	return err
 }
+
+// Attempts are not reported separately, the step end holds the outcome of the last attempt
+func (theHiveReporter *TheHiveReporter) ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, stepErr error, at time.Time) error {
+	log.Trace("TheHive reporter reporting step attempt")
+	return nil
+}
+
+// Iterations are visible through the steps executed in the loop body
+func (theHiveReporter *TheHiveReporter) ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) error {
+	log.Trace("TheHive reporter reporting loop iteration")
+	return nil
+}
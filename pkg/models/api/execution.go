@@ -1,8 +1,27 @@
 package api
 
-import "github.com/google/uuid"
+import (
+	"soarca/pkg/models/cacao"
+
+	"github.com/google/uuid"
+)
 
 type Execution struct {
 	ExecutionId uuid.UUID `json:"execution_id" validate:"required" example:"2c855cd6-bbce-402f-a143-3d6eec346c08"`
 	PlaybookId  string    `json:"payload" validate:"required" example:"playbook--0cec398c-db69-4f17-bde4-8ecbcc4a8879"`
 }
+
+type ExecutionStatus struct {
+	ExecutionId uuid.UUID `json:"execution_id" validate:"required" example:"2c855cd6-bbce-402f-a143-3d6eec346c08"`
+	Status      string    `json:"status" validate:"required" example:"cancelled"`
+}
+
+// Returned by a trigger that waits for the execution to end, the variables are
+// only set once it has ended
+type ExecutionResult struct {
+	ExecutionId uuid.UUID       `json:"execution_id" validate:"required" example:"2c855cd6-bbce-402f-a143-3d6eec346c08"`
+	PlaybookId  string          `json:"payload" validate:"required" example:"playbook--0cec398c-db69-4f17-bde4-8ecbcc4a8879"`
+	Status      string          `json:"status" validate:"required" example:"successfully_executed"`
+	Error       string          `json:"error,omitempty"`
+	Variables   cacao.Variables `json:"variables,omitempty"`
+}
@@ -22,6 +22,9 @@ const (
 	TimeoutError            = "timeout_error"
 	ExceptionConditionError = "exception_condition_error"
 	AwaitUserInput          = "await_user_input"
+	Cancelled               = "cancelled"
+	Paused                  = "paused"
+	Queued                  = "queued"
 
 	SuccessfullyExecutedText    = "%s execution completed successfully"
 	FailedText                  = "something went wrong in the execution of this %s"
@@ -31,6 +34,9 @@ const (
 	TimeoutErrorText            = "the execution of this %s timed out"
 	ExceptionConditionErrorText = "the execution of this %s raised a playbook exception"
 	AwaitUserInputText          = "waiting for users to provide input for the %s execution"
+	CancelledText               = "the execution of this %s was cancelled"
+	PausedText                  = "the execution of this %s is paused"
+	QueuedText                  = "this %s is queued for execution"
 )
 
 type PlaybookExecutionReport struct {
@@ -60,10 +66,24 @@ type StepExecutionReport struct {
 	CommandsB64        []string                  `bson:"commands_b64" json:"commands_b64"`
 	Variables          map[string]cacao.Variable `bson:"variables" json:"variables"`
 	AutomatedExecution bool                      `bson:"automated_execution" json:"automated_execution"`
+	Attempts           []StepAttemptReport       `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	Iterations         []StepIterationReport     `bson:"iterations,omitempty" json:"iterations,omitempty"`
 	// Make sure we can have a playbookID for playbook actions, and also
 	// the execution ID for the invoked playbook
 }
 
+type StepAttemptReport struct {
+	Attempt    int       `bson:"attempt" json:"attempt"`
+	Ended      time.Time `bson:"ended" json:"ended"`
+	Status     string    `bson:"status" json:"status"`
+	StatusText string    `bson:"status_text" json:"status_text"`
+}
+
+type StepIterationReport struct {
+	Iteration int       `bson:"iteration" json:"iteration"`
+	Started   time.Time `bson:"started" json:"started"`
+}
+
 func CacheStatusEnum2String(status cache_model.Status) string {
 	return status.String()
 }
@@ -90,6 +110,12 @@ func GetCacheStatusText(status string, level string) (string, error) {
 		return fmt.Sprintf(ExceptionConditionErrorText, level), nil
 	case AwaitUserInput:
 		return fmt.Sprintf(AwaitUserInputText, level), nil
+	case Cancelled:
+		return fmt.Sprintf(CancelledText, level), nil
+	case Paused:
+		return fmt.Sprintf(PausedText, level), nil
+	case Queued:
+		return fmt.Sprintf(QueuedText, level), nil
 	default:
 		return "", errors.New("unable to read execution information status")
 	}
@@ -13,4 +13,14 @@ type Status struct {
 	Mode    string    `json:"mode"`
 	Time    time.Time `json:"time"`
 	Uptime  Uptime    `json:"uptime"`
+	// Only set when executions are queued by a scheduler
+	Executions *ExecutionQueue `json:"executions,omitempty"`
+}
+
+type ExecutionQueue struct {
+	Running    int `json:"running"`
+	Queued     int `json:"queued"`
+	MaxRunning int `json:"max_running"`
+	// 0 when the queue is unbounded
+	MaxQueued int `json:"max_queued"`
 }
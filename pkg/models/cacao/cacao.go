@@ -30,11 +30,13 @@ const (
 	CommandTypeSsh        = "ssh"
 	CommandTypeYara       = "yara"
 
-	AuthInfoOAuth2Type    = "oauth2"
-	AuthInfoHTTPBasicType = "http-basic"
-	AuthInfoNotSet        = ""
-	CACAO_VERSION_1       = "cacao-1.0"
-	CACAO_VERSION_2       = "cacao-2.0"
+	AuthInfoOAuth2Type     = "oauth2"
+	AuthInfoHTTPBasicType  = "http-basic"
+	AuthInfoUserAuthType   = "user-auth"
+	AuthInfoPrivateKeyType = "private-key"
+	AuthInfoNotSet         = ""
+	CACAO_VERSION_1        = "cacao-1.0"
+	CACAO_VERSION_2        = "cacao-2.0"
 )
 
 // Custom type intended for AgentTarget.Address dict keys
@@ -89,6 +91,8 @@ const (
 	VariableTypeString      = "string"
 	VariableTypeUri         = "uri"
 	VariableTypeUuid        = "uuid"
+	// JSON array, the counterpart of dictionary (not part of CACAO spec)
+	VariableTypeList = "list"
 )
 
 type Playbook struct {
@@ -235,6 +239,7 @@ type Step struct {
 	Cases              Cases                `bson:"cases,omitempty" json:"cases,omitempty"`
 	AuthenticationInfo string               `bson:"authentication_info,omitempty" json:"authentication_info,omitempty"`
 	StepExtensions     Extensions           `bson:"step_extensions,omitempty" json:"step_extensions,omitempty"`
+	SuccessCondition   string               `bson:"success_condition,omitempty" json:"success_condition,omitempty"` // STIX comparison on the step output, action steps only (not part of CACAO spec)
 }
 
 type DataMarking struct {
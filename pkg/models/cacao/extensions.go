@@ -0,0 +1,17 @@
+package cacao
+
+import "encoding/json"
+
+// Decode the extension with the given id into target, e.g. a struct with json
+// tags. Returns whether the extension is set.
+func DecodeExtension(extensions Extensions, id string, target any) (bool, error) {
+	extension, found := extensions[id]
+	if !found {
+		return false, nil
+	}
+	raw, err := json.Marshal(extension)
+	if err == nil {
+		err = json.Unmarshal(raw, target)
+	}
+	return true, err
+}
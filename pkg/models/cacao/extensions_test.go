@@ -0,0 +1,31 @@
+package cacao
+
+import (
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestDecodeExtension(t *testing.T) {
+	type limits struct {
+		MaxIterations int `json:"max_iterations"`
+	}
+	extensions := Extensions{
+		"extension-definition--1": map[string]interface{}{"max_iterations": 5},
+		"extension-definition--2": map[string]interface{}{"max_iterations": "five"},
+	}
+
+	decoded := limits{}
+	found, err := DecodeExtension(extensions, "extension-definition--1", &decoded)
+	assert.Equal(t, found, true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decoded.MaxIterations, 5)
+
+	found, err = DecodeExtension(extensions, "extension-definition--2", &decoded)
+	assert.Equal(t, found, true)
+	assert.NotEqual(t, err, nil)
+
+	found, err = DecodeExtension(extensions, "extension-definition--3", &decoded)
+	assert.Equal(t, found, false)
+	assert.Equal(t, err, nil)
+}
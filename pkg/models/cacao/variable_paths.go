@@ -0,0 +1,186 @@
+package cacao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A path into a dictionary or list value is a sequence of .key, ["key"] and
+// [index] segments, e.g. __alert__:value.src.ip or __hosts__:value[0]
+const pathSegmentPattern = `\.[A-Za-z0-9_-]+|\["[^"\]]*"\]|\[[0-9]+\]`
+
+var pathSegment = regexp.MustCompile(`^(?:\.([A-Za-z0-9_-]+)|\["([^"\]]*)"\]|\[([0-9]+)\])`)
+
+// Whether a path consists of valid segments only, see Variable.At
+func ValidPath(path string) bool {
+	for path != "" {
+		segment := pathSegment.FindString(path)
+		if segment == "" {
+			return false
+		}
+		path = path[len(segment):]
+	}
+	return true
+}
+
+// Whether the value of the variable is JSON that can be accessed by path
+func (variable Variable) Structured() bool {
+	return variable.Type == VariableTypeDictionary || variable.Type == VariableTypeList
+}
+
+// Get the part of the JSON value of a dictionary or list variable at a path.
+// The returned variable is named after the reference and typed after the
+// JSON value: string, integer, float, bool, dictionary or list. Strings are
+// returned as is, all other values as JSON.
+func (variable Variable) At(path string) (Variable, error) {
+	if !variable.Structured() {
+		return Variable{}, fmt.Errorf("variable [ %s ] of type %s has no path %s", variable.Name, variable.Type, path)
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(variable.Value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return Variable{}, fmt.Errorf("value of variable [ %s ] is not JSON", variable.Name)
+	}
+
+	remaining := path
+	for remaining != "" {
+		segment := pathSegment.FindStringSubmatch(remaining)
+		if segment == nil {
+			return Variable{}, fmt.Errorf("invalid path %s of variable [ %s ]", path, variable.Name)
+		}
+		remaining = remaining[len(segment[0]):]
+		resolved := path[:len(path)-len(remaining)]
+
+		var err error
+		if segment[3] != "" {
+			value, err = valueAtIndex(value, segment[3])
+		} else {
+			value, err = valueAtKey(value, segment[1]+segment[2])
+		}
+		if err != nil {
+			return Variable{}, fmt.Errorf("path %s of variable [ %s ]: %w at %s", path, variable.Name, err, resolved)
+		}
+	}
+	return valueVariable(variable.Name+":value"+path, value)
+}
+
+func valueAtKey(value interface{}, key string) (interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("not a dictionary")
+	}
+	found, ok := object[key]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return found, nil
+}
+
+func valueAtIndex(value interface{}, position string) (interface{}, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("not a list")
+	}
+	i, err := strconv.Atoi(position)
+	if err != nil || i >= len(list) {
+		return nil, errors.New("index out of range")
+	}
+	return list[i], nil
+}
+
+func valueVariable(name string, value interface{}) (Variable, error) {
+	variable := Variable{Name: name}
+	switch typed := value.(type) {
+	case nil:
+		variable.Type = VariableTypeString
+	case string:
+		variable.Type = VariableTypeString
+		variable.Value = typed
+	case bool:
+		variable.Type = VariableTypeBool
+		variable.Value = strconv.FormatBool(typed)
+	case json.Number:
+		variable.Type = VariableTypeFloat
+		if _, err := typed.Int64(); err == nil {
+			variable.Type = VariableTypeInt
+		}
+		variable.Value = typed.String()
+	default:
+		variable.Type = VariableTypeDictionary
+		if _, ok := typed.([]interface{}); ok {
+			variable.Type = VariableTypeList
+		}
+		raw, err := json.Marshal(typed)
+		if err != nil {
+			return Variable{}, err
+		}
+		variable.Value = string(raw)
+	}
+	return variable, nil
+}
+
+// The :value of a reference to a variable, with the path following it
+var referenceValue = regexp.MustCompile(`:value((?:` + pathSegmentPattern + `)*)`)
+
+// A reference to a variable at input[start:end]
+type reference struct {
+	start int
+	end   int
+	name  string
+	path  string
+}
+
+// Find the references to the variables, with the path following them. The
+// longest name wins when one name is the end of another.
+func (variables Variables) references(input string) []reference {
+	references := []reference{}
+	end := 0
+	for _, match := range referenceValue.FindAllStringSubmatchIndex(input, -1) {
+		preceding := input[end:match[0]]
+		name := ""
+		for candidate := range variables {
+			if len(candidate) > len(name) && strings.HasSuffix(preceding, candidate) {
+				name = candidate
+			}
+		}
+		if name == "" {
+			continue
+		}
+		references = append(references, reference{start: match[0] - len(name),
+			end:  match[1],
+			name: name,
+			path: input[match[2]:match[3]]})
+		end = match[1]
+	}
+	return references
+}
+
+// Get the variable a reference with an optional path points to. Paths are
+// resolved for dictionary and list variables only.
+func (variables Variables) resolve(name string, path string) (Variable, error) {
+	variable := variables[name]
+	if path == "" || !variable.Structured() {
+		return variable, nil
+	}
+	return variable.At(path)
+}
+
+// Find the first variable referenced in a string
+//
+// For a reference with a path into a dictionary or list variable the value at
+// the path is returned, see Variable.At. Returns false when no variable is
+// referenced and an error when the path cannot be resolved.
+func (variables Variables) Referenced(input string) (Variable, bool, error) {
+	references := variables.references(input)
+	if len(references) == 0 {
+		return Variable{}, false, nil
+	}
+	variable, err := variables.resolve(references[0].name, references[0].path)
+	return variable, err == nil, err
+}
@@ -0,0 +1,144 @@
+package cacao
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Lengths of the hex encoded hashes accepted as hash: MD5, SHA-1, SHA-224,
+// SHA-256, SHA-384 and SHA-512
+var hashLengths = []int{32, 40, 56, 64, 96, 128}
+
+// Check that the value of the variable can be read as its type. Empty values
+// are unassigned and variable types SOARCA does not know are not checked.
+func (variable Variable) Validate() error {
+	if variable.Value == "" {
+		return nil
+	}
+	if err := validateValue(variable.Type, variable.Value); err != nil {
+		return ErrorVariableValue{Name: variable.Name, Type: variable.Type, Reason: err.Error()}
+	}
+	return nil
+}
+
+// Validate every variable, the first invalid variable in name order is returned
+func (variables Variables) Validate() error {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if err := variables[name].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateValue(variableType string, value string) error {
+	switch variableType {
+	case VariableTypeBool:
+		_, err := strconv.ParseBool(value)
+		return unwrapNumError(err)
+	case VariableTypeInt, VariableTypeLong:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return unwrapNumError(err)
+	case VariableTypeFloat:
+		_, err := strconv.ParseFloat(value, 64)
+		return unwrapNumError(err)
+	case VariableTypeDictionary:
+		dictionary := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(value), &dictionary); err != nil {
+			return errors.New("not a JSON object")
+		}
+	case VariableTypeList:
+		list := []interface{}{}
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return errors.New("not a JSON array")
+		}
+	case VariableTypeHexString:
+		if _, err := hex.DecodeString(value); err != nil {
+			return errors.New("not hex encoded")
+		}
+	case VariableTypeHash:
+		return validateHash(value, hashLengths...)
+	case VariableTypeMd5Has:
+		return validateHash(value, 32)
+	case VariableTypeSha256:
+		return validateHash(value, 64)
+	case VariableTypeIpv4Address:
+		if ip := parseAddress(value); ip == nil || ip.To4() == nil {
+			return errors.New("not an IPv4 address or network")
+		}
+	case VariableTypeIpv6Address:
+		if ip := parseAddress(value); ip == nil || ip.To4() != nil {
+			return errors.New("not an IPv6 address or network")
+		}
+	case VariableTypeMacAddress:
+		if _, err := net.ParseMAC(value); err != nil {
+			return errors.New("not a MAC address")
+		}
+	case VariableTypeUri:
+		if uri, err := url.Parse(value); err != nil || uri.Scheme == "" {
+			return errors.New("not an absolute URI")
+		}
+	case VariableTypeUuid:
+		if _, err := uuid.Parse(value); err != nil {
+			return errors.New("not a UUID")
+		}
+	}
+	return nil
+}
+
+// The parse errors of strconv repeat the value, which can be sensitive. The
+// other checks do not report the value either.
+func unwrapNumError(err error) error {
+	var numError *strconv.NumError
+	if errors.As(err, &numError) {
+		return numError.Err
+	}
+	return err
+}
+
+func validateHash(value string, lengths ...int) error {
+	if _, err := hex.DecodeString(value); err != nil {
+		return errors.New("not hex encoded")
+	}
+	if !slices.Contains(lengths, len(value)) {
+		return fmt.Errorf("unexpected length %d", len(value))
+	}
+	return nil
+}
+
+// An address or a network in CIDR notation
+func parseAddress(value string) net.IP {
+	if strings.Contains(value, "/") {
+		ip, _, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil
+		}
+		return ip
+	}
+	return net.ParseIP(value)
+}
+
+// Returned when the value of a variable cannot be read as its type
+type ErrorVariableValue struct {
+	Name   string
+	Type   string
+	Reason string
+}
+
+func (e ErrorVariableValue) Error() string {
+	return fmt.Sprintf("value of variable [ %s ] is not a valid %s: %s", e.Name, e.Type, e.Reason)
+}
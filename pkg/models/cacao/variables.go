@@ -2,6 +2,7 @@ package cacao
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -53,14 +54,29 @@ func (variables Variables) Find(key string) (Variable, bool) {
 
 // Interpolate variable references into a target string
 //
-// Returns the Interpolated string with variables values available in the map
+// Returns the Interpolated string with variables values available in the map.
+// References to dictionary and list variables can have a path into the value,
+// e.g. __alert__:value.src.ip, see Variable.At. References with a path that
+// cannot be resolved are left as is.
 func (variables *Variables) Interpolate(input string) string {
-	replacements := make([]string, 0)
-	for key, value := range *variables {
-		replacementKey := fmt.Sprint(key, ":value")
-		replacements = append(replacements, replacementKey, value.Value)
+	var interpolated strings.Builder
+	end := 0
+	for _, reference := range variables.references(input) {
+		interpolated.WriteString(input[end:reference.start])
+		end = reference.end
+		variable, err := variables.resolve(reference.name, reference.path)
+		if err != nil {
+			interpolated.WriteString(input[reference.start:reference.end])
+			continue
+		}
+		interpolated.WriteString(variable.Value)
+		if !(*variables)[reference.name].Structured() {
+			// Only dictionaries and lists have paths, the rest is text
+			interpolated.WriteString(reference.path)
+		}
 	}
-	return strings.NewReplacer(replacements...).Replace(input)
+	interpolated.WriteString(input[end:])
+	return interpolated.String()
 }
 
 // Select a subset of variables from the map
@@ -79,8 +95,33 @@ func (variables *Variables) Select(keys []string) Variables {
 }
 
 // Merge two maps of Cacao variables and replace the base with the source if exists
-func (variables *Variables) Merge(source Variables) {
+//
+// Constant variables of the base are kept. Returns an ErrorConstantVariable
+// when the source would change the type or value of a constant, the other
+// variables are merged regardless.
+func (variables *Variables) Merge(source Variables) error {
+	overwritten := []string{}
 	for _, variable := range source {
+		if existing, found := (*variables)[variable.Name]; found && existing.Constant {
+			if existing.Value != variable.Value || existing.Type != variable.Type {
+				overwritten = append(overwritten, variable.Name)
+			}
+			continue
+		}
 		variables.InsertOrReplace(variable)
 	}
+	if len(overwritten) > 0 {
+		slices.Sort(overwritten)
+		return ErrorConstantVariable{Names: overwritten}
+	}
+	return nil
+}
+
+// Returned when constant variables would be overwritten
+type ErrorConstantVariable struct {
+	Names []string
+}
+
+func (e ErrorConstantVariable) Error() string {
+	return fmt.Sprintf("constant variables cannot be overwritten: [ %s ]", strings.Join(e.Names, ", "))
 }
@@ -1,6 +1,7 @@
 package cacao
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/go-playground/assert/v2"
@@ -97,7 +98,8 @@ func TestVariablesMerge(t *testing.T) {
 		Value: "NEW",
 	})
 
-	base.Merge(new)
+	err := base.Merge(new)
+	assert.Equal(t, err, nil)
 
 	assert.Equal(t, base["__var0__"].Value, "OLD")
 	assert.Equal(t, new["__var0__"].Value, "")
@@ -116,7 +118,8 @@ func TestVariablesMergeWithUpdate(t *testing.T) {
 		Value: "NEW",
 	})
 
-	base.Merge(new)
+	err := base.Merge(new)
+	assert.Equal(t, err, nil)
 
 	assert.Equal(t, base["__var0__"].Value, "NEW")
 }
@@ -175,6 +178,109 @@ func TestVariablesStringInterpolateMultipleAndUnkown(t *testing.T) {
 	assert.Equal(t, replaced, "GO is __var1_unknown__:value")
 }
 
+func TestVariablesStringInterpolateLongestName(t *testing.T) {
+	original := "__alert__id__:value and __id__:value"
+
+	vars := NewVariables(Variable{
+		Name:  "__id__",
+		Value: "1",
+	}, Variable{
+		Name:  "__alert__id__",
+		Value: "42",
+	})
+
+	replaced := vars.Interpolate(original)
+	assert.Equal(t, replaced, "42 and 1")
+}
+
+func TestVariablesInterpolatePath(t *testing.T) {
+	vars := NewVariables(Variable{
+		Type:  VariableTypeDictionary,
+		Name:  "__alert__",
+		Value: `{"src": {"ip": "10.0.0.1", "port": 443}, "tags": ["phishing", "mail"], "src-host": {"name": "pc1"}}`,
+	}, Variable{
+		Type:  VariableTypeList,
+		Name:  "__hosts__",
+		Value: `["pc1", "pc2"]`,
+	}, Variable{
+		Type:  VariableTypeString,
+		Name:  "__file__",
+		Value: "report",
+	})
+
+	assert.Equal(t, vars.Interpolate("block __alert__:value.src.ip:__alert__:value.src.port"), "block 10.0.0.1:443")
+	assert.Equal(t, vars.Interpolate("__alert__:value.tags[1] on __hosts__:value[0]"), "mail on pc1")
+	assert.Equal(t, vars.Interpolate(`__alert__:value["src-host"].name`), "pc1")
+	assert.Equal(t, vars.Interpolate("__alert__:value.src-host.name"), "pc1")
+	assert.Equal(t, vars.Interpolate("__alert__:value.src"), `{"ip":"10.0.0.1","port":443}`)
+	assert.Equal(t, vars.Interpolate("__hosts__:value"), `["pc1", "pc2"]`)
+
+	// Paths that cannot be resolved are left as is
+	assert.Equal(t, vars.Interpolate("__alert__:value.dst.ip"), "__alert__:value.dst.ip")
+	assert.Equal(t, vars.Interpolate("__hosts__:value[2]"), "__hosts__:value[2]")
+	// Other variables have no path
+	assert.Equal(t, vars.Interpolate("__file__:value.pdf"), "report.pdf")
+}
+
+func TestVariableAt(t *testing.T) {
+	alert := Variable{
+		Type:  VariableTypeDictionary,
+		Name:  "__alert__",
+		Value: `{"count": 3, "score": 0.5, "closed": false, "owner": null, "tags": ["phishing"]}`,
+	}
+
+	for path, expected := range map[string]Variable{
+		".count":   {Type: VariableTypeInt, Name: "__alert__:value.count", Value: "3"},
+		".score":   {Type: VariableTypeFloat, Name: "__alert__:value.score", Value: "0.5"},
+		".closed":  {Type: VariableTypeBool, Name: "__alert__:value.closed", Value: "false"},
+		".owner":   {Type: VariableTypeString, Name: "__alert__:value.owner"},
+		".tags":    {Type: VariableTypeList, Name: "__alert__:value.tags", Value: `["phishing"]`},
+		".tags[0]": {Type: VariableTypeString, Name: "__alert__:value.tags[0]", Value: "phishing"},
+	} {
+		variable, err := alert.At(path)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, variable, expected)
+	}
+
+	_, err := alert.At(".count.value")
+	assert.NotEqual(t, err, nil)
+	_, err = alert.At("count")
+	assert.NotEqual(t, err, nil)
+	_, err = Variable{Type: VariableTypeString, Name: "__var__", Value: "{}"}.At(".key")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestVariablesReferenced(t *testing.T) {
+	vars := NewVariables(Variable{
+		Type:  VariableTypeDictionary,
+		Name:  "__alert__",
+		Value: `{"severity": 2}`,
+	}, Variable{
+		Type:  VariableTypeString,
+		Name:  "__alert__id__",
+		Value: "42",
+	})
+
+	variable, found, err := vars.Referenced("__alert__:value.severity")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, found, true)
+	assert.Equal(t, variable.Type, VariableTypeInt)
+	assert.Equal(t, variable.Value, "2")
+
+	variable, found, err = vars.Referenced("__alert__id__:value")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, found, true)
+	assert.Equal(t, variable, vars["__alert__id__"])
+
+	_, found, err = vars.Referenced("__alert__:value.status")
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, found, false)
+
+	_, found, err = vars.Referenced("__unknown__:value")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, found, false)
+}
+
 func TestVariablesSelect(t *testing.T) {
 	vars := NewVariables(Variable{
 		Name:  "__var0__",
@@ -202,5 +308,104 @@ func TestInsertIntoEmptyMap(t *testing.T) {
 
 	playbook := NewPlaybook()
 
-	playbook.PlaybookVariables.Merge(vars)
+	err := playbook.PlaybookVariables.Merge(vars)
+	assert.Equal(t, err, nil)
+}
+
+func TestVariablesMergeKeepsConstants(t *testing.T) {
+	base := NewVariables(Variable{
+		Name:     "__var0__",
+		Value:    "OLD",
+		Constant: true,
+	}, Variable{
+		Name:     "__var1__",
+		Value:    "SAME",
+		Constant: true,
+	})
+
+	new := NewVariables(Variable{
+		Name:  "__var0__",
+		Value: "NEW",
+	}, Variable{
+		Name:  "__var1__",
+		Value: "SAME",
+	}, Variable{
+		Name:  "__var2__",
+		Value: "NEW",
+	})
+
+	err := base.Merge(new)
+
+	assert.Equal(t, err, ErrorConstantVariable{Names: []string{"__var0__"}})
+	assert.Equal(t, base["__var0__"].Value, "OLD")
+	assert.Equal(t, base["__var1__"].Constant, true)
+	assert.Equal(t, base["__var2__"].Value, "NEW")
+}
+
+func TestVariableValidate(t *testing.T) {
+	valid := map[string][]string{
+		VariableTypeBool:        {"true", "false"},
+		VariableTypeInt:         {"42", "-1"},
+		VariableTypeLong:        {"9007199254740993"},
+		VariableTypeFloat:       {"0.5", "1e3"},
+		VariableTypeDictionary:  {`{"key": "value"}`},
+		VariableTypeList:        {`["value", 1]`},
+		VariableTypeHexString:   {"deadbeef"},
+		VariableTypeHash:        {"da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+		VariableTypeMd5Has:      {"d41d8cd98f00b204e9800998ecf8427e"},
+		VariableTypeSha256:      {"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		VariableTypeIpv4Address: {"10.0.0.1", "10.0.0.0/8"},
+		VariableTypeIpv6Address: {"2001:db8::1", "2001:db8::/32"},
+		VariableTypeMacAddress:  {"00:1b:63:84:45:e6"},
+		VariableTypeUri:         {"https://example.com/path"},
+		VariableTypeUuid:        {"6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		VariableTypeString:      {"anything"},
+		"custom-type":           {"anything"},
+	}
+	for variableType, values := range valid {
+		for _, value := range values {
+			assert.Equal(t, Variable{Name: "__var__", Type: variableType, Value: value}.Validate(), nil)
+		}
+	}
+
+	invalid := map[string][]string{
+		VariableTypeBool:        {"yes"},
+		VariableTypeInt:         {"1.5", "one"},
+		VariableTypeFloat:       {"half"},
+		VariableTypeDictionary:  {`["value"]`, "key=value"},
+		VariableTypeList:        {`{"key": "value"}`},
+		VariableTypeHexString:   {"xyz", "abc"},
+		VariableTypeHash:        {"deadbeef"},
+		VariableTypeMd5Has:      {"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		VariableTypeSha256:      {"d41d8cd98f00b204e9800998ecf8427e"},
+		VariableTypeIpv4Address: {"10.0.0.256", "2001:db8::1"},
+		VariableTypeIpv6Address: {"10.0.0.1", "2001:db8::g"},
+		VariableTypeMacAddress:  {"00:1b:63:84:45"},
+		VariableTypeUri:         {"example.com/path"},
+		VariableTypeUuid:        {"6ba7b810"},
+	}
+	for variableType, values := range invalid {
+		for _, value := range values {
+			err := Variable{Name: "__var__", Type: variableType, Value: value}.Validate()
+			assert.Equal(t, errors.As(err, &ErrorVariableValue{}), true)
+		}
+	}
+
+	// Unassigned variables are valid whatever their type
+	assert.Equal(t, Variable{Name: "__var__", Type: VariableTypeIpv4Address}.Validate(), nil)
+}
+
+func TestVariablesValidate(t *testing.T) {
+	variables := NewVariables(
+		Variable{Name: "__b__", Type: VariableTypeInt, Value: "b"},
+		Variable{Name: "__a__", Type: VariableTypeBool, Value: "a"},
+		Variable{Name: "__c__", Type: VariableTypeString, Value: "c"})
+
+	err := variables.Validate()
+	assert.Equal(t, err, ErrorVariableValue{Name: "__a__", Type: VariableTypeBool, Reason: "invalid syntax"})
+	assert.Equal(t, err.Error(), "value of variable [ __a__ ] is not a valid bool: invalid syntax")
+
+	delete(variables, "__a__")
+	delete(variables, "__b__")
+	assert.Equal(t, variables.Validate(), nil)
 }
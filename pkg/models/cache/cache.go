@@ -18,6 +18,9 @@ const (
 	TimeoutError
 	ExceptionConditionError
 	AwaitUserInput
+	Cancelled
+	Paused
+	Queued
 )
 
 func (status Status) String() string {
@@ -30,6 +33,9 @@ func (status Status) String() string {
 		"timeout_error",
 		"exception_condition_error",
 		"await_user_input",
+		"cancelled",
+		"paused",
+		"queued",
 	}[status]
 }
 
@@ -59,4 +65,20 @@ type StepResult struct {
 	Status      Status
 	Error       error
 	IsAutomated bool
+	// Only filled for steps with a retry policy
+	Attempts []StepAttempt
+	// Only filled for while-condition steps
+	Iterations []StepIteration
+}
+
+type StepAttempt struct {
+	Attempt int
+	Ended   time.Time
+	Status  Status
+	Error   error
+}
+
+type StepIteration struct {
+	Iteration int
+	Started   time.Time
 }
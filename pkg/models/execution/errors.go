@@ -0,0 +1,110 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Raised when a workflow step fails and the branch it belongs to is aborted
+type ErrorStepFailed struct {
+	StepId string
+	Err    error
+}
+
+// Raised when a playbook execution failed and its workflow_exception branch
+// was executed. Err holds the original execution failure.
+type ErrorExceptionCondition struct {
+	StepId string
+	Err    error
+}
+
+func (e ErrorStepFailed) Error() string {
+	return fmt.Sprintf("playbook execution failed at step [ %s ]. See step log for error information", e.StepId)
+}
+
+func (e ErrorStepFailed) Unwrap() error {
+	return e.Err
+}
+
+func (e ErrorExceptionCondition) Error() string {
+	return fmt.Sprintf("workflow exception was executed after failure at step [ %s ]: %s", e.StepId, e.Err)
+}
+
+func (e ErrorExceptionCondition) Unwrap() error {
+	return e.Err
+}
+
+// Raised when an execution is not (or no longer) running
+type ErrorExecutionNotFound struct {
+	ExecutionId uuid.UUID
+}
+
+func (e ErrorExecutionNotFound) Error() string {
+	return fmt.Sprintf("execution [ %s ] is not running", e.ExecutionId)
+}
+
+// Raised when an execution is not in the paused state an operation requires
+type ErrorExecutionState struct {
+	ExecutionId uuid.UUID
+	Paused      bool
+}
+
+func (e ErrorExecutionState) Error() string {
+	if e.Paused {
+		return fmt.Sprintf("execution [ %s ] is already paused", e.ExecutionId)
+	}
+	return fmt.Sprintf("execution [ %s ] is not paused", e.ExecutionId)
+}
+
+// Raised for a journaled execution that was not resumed after a restart
+type ErrorExecutionInterrupted struct {
+	ExecutionId uuid.UUID
+	StepId      string
+}
+
+func (e ErrorExecutionInterrupted) Error() string {
+	return fmt.Sprintf("execution [ %s ] was interrupted by a restart before step [ %s ]", e.ExecutionId, e.StepId)
+}
+
+// Raised when an execution cannot be queued because the queue is full
+type ErrorQueueFull struct {
+	PlaybookId string
+	Queued     int
+}
+
+func (e ErrorQueueFull) Error() string {
+	return fmt.Sprintf("cannot queue execution of playbook [ %s ]: %d executions are already queued", e.PlaybookId, e.Queued)
+}
+
+// Raised when a while-condition loop is still true after its maximum number
+// of iterations or its maximum duration
+type ErrorLoopLimit struct {
+	StepId     string
+	Iterations int
+	// Only set when the duration limit was reached
+	Duration time.Duration
+}
+
+func (e ErrorLoopLimit) Error() string {
+	if e.Duration > 0 {
+		return fmt.Sprintf("loop [ %s ] reached its maximum duration of %s after %d iterations", e.StepId, e.Duration, e.Iterations)
+	}
+	return fmt.Sprintf("loop [ %s ] reached its maximum of %d iterations", e.StepId, e.Iterations)
+}
+
+// Raised when a playbook is executed before its valid_from or after its valid_until
+type ErrorPlaybookNotValid struct {
+	PlaybookId string
+	ValidFrom  time.Time
+	ValidUntil time.Time
+	At         time.Time
+}
+
+func (e ErrorPlaybookNotValid) Error() string {
+	if !e.ValidUntil.IsZero() && e.At.After(e.ValidUntil) {
+		return fmt.Sprintf("playbook [ %s ] expired at %s", e.PlaybookId, e.ValidUntil.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("playbook [ %s ] is not valid before %s", e.PlaybookId, e.ValidFrom.Format(time.RFC3339))
+}
@@ -1,6 +1,9 @@
 package execution
 
 import (
+	"soarca/pkg/models/cacao"
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -9,3 +12,16 @@ type Metadata struct {
 	PlaybookId  string
 	StepId      string
 }
+
+// Check that a playbook can be executed at the given time, a playbook without
+// valid_from or valid_until is not bounded on that side
+func CheckPlaybookValidity(playbook cacao.Playbook, at time.Time) error {
+	if (!playbook.ValidFrom.IsZero() && at.Before(playbook.ValidFrom)) ||
+		(!playbook.ValidUntil.IsZero() && at.After(playbook.ValidUntil)) {
+		return ErrorPlaybookNotValid{PlaybookId: playbook.ID,
+			ValidFrom:  playbook.ValidFrom,
+			ValidUntil: playbook.ValidUntil,
+			At:         at}
+	}
+	return nil
+}
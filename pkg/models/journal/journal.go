@@ -0,0 +1,27 @@
+package journal
+
+import (
+	"soarca/pkg/models/cacao"
+	"time"
+)
+
+// Persisted state of a running execution, used to resume it after a restart
+type ExecutionRecord struct {
+	ExecutionId string         `bson:"_id" json:"execution_id"`
+	Playbook    cacao.Playbook `bson:"playbook" json:"playbook"`
+	// Next step of the main workflow and the scope variables it runs with
+	StepId    string                `bson:"step_id" json:"step_id"`
+	Variables cacao.Variables       `bson:"variables" json:"variables"`
+	Steps     map[string]StepRecord `bson:"steps" json:"steps"`
+	Started   time.Time             `bson:"started" json:"started"`
+	Updated   time.Time             `bson:"updated" json:"updated"`
+}
+
+type StepRecord struct {
+	StepId    string          `bson:"step_id" json:"step_id"`
+	Started   time.Time       `bson:"started" json:"started"`
+	Ended     time.Time       `bson:"ended" json:"ended"`
+	Status    string          `bson:"status" json:"status"`
+	Error     string          `bson:"error,omitempty" json:"error,omitempty"`
+	Variables cacao.Variables `bson:"variables" json:"variables"`
+}
@@ -16,8 +16,8 @@ func init() {
 func IsSafeCacaoWorkflow(playbook *cacao.Playbook) error {
 	// Playbook exception handled?
 	workflowException := playbook.WorkflowException
-	if workflowException == "" {
-		log.Warn("workflow exception not implemented")
+	if _, ok := playbook.Workflow[workflowException]; workflowException != "" && !ok {
+		log.Warn("workflow exception step " + workflowException + " not found in workflow, failures will not be handled")
 	}
 
 	workflowStart := playbook.WorkflowStart
@@ -66,7 +66,20 @@ func isSafeWorkflowStep(playbook *cacao.Playbook, stepId string) error {
 	if err := checkStepAuthInfoExist(playbook, step); err != nil {
 		return err
 	}
+	if err := checkStepSuccessCondition(step); err != nil {
+		return err
+	}
+
+	return nil
+}
 
+// The success condition is evaluated on the output of commands, so only
+// action steps have one
+func checkStepSuccessCondition(step cacao.Step) error {
+	if step.SuccessCondition != "" && step.Type != cacao.StepTypeAction {
+		return errors.New("step " + step.ID + " of type " + step.Type +
+			" cannot have a success_condition, only action steps can")
+	}
 	return nil
 }
 
@@ -160,3 +160,30 @@ func TestIsSafeCacaoWorkflow(t *testing.T) {
 	assert.Equal(t, errSafeWorkflow, nil)
 
 }
+
+func TestIsSafeCacaoWorkflowFailSuccessConditionOnNonAction(t *testing.T) {
+	playbook := cacao.Playbook{
+		WorkflowStart: "start--1",
+		Workflow: cacao.Workflow{
+			"start--1": {Type: cacao.StepTypeStart, ID: "start--1", OnCompletion: "action--1"},
+			"action--1": {Type: cacao.StepTypeAction, ID: "action--1",
+				SuccessCondition: "__exit_code__:value = 0",
+				OnCompletion:     "if-condition--1"},
+			"if-condition--1": {Type: cacao.StepTypeIfCondition, ID: "if-condition--1",
+				Condition:        "__exit_code__:value = 0",
+				SuccessCondition: "__exit_code__:value = 0",
+				OnTrue:           "end--1",
+				OnCompletion:     "end--1"},
+			"end--1": {Type: cacao.StepTypeEnd, ID: "end--1"},
+		},
+	}
+
+	errSafeWorkflow := IsSafeCacaoWorkflow(&playbook)
+	expected := errors.New("step if-condition--1 of type if-condition cannot have a success_condition, only action steps can")
+	assert.Equal(t, errSafeWorkflow, expected)
+
+	step := playbook.Workflow["if-condition--1"]
+	step.SuccessCondition = ""
+	playbook.Workflow["if-condition--1"] = step
+	assert.Equal(t, IsSafeCacaoWorkflow(&playbook), nil)
+}
@@ -1,12 +1,15 @@
 package cache
 
 import (
+	"context"
 	b64 "encoding/base64"
 	"errors"
 	"fmt"
 	"slices"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/models/cacao"
 	cache_report "soarca/pkg/models/cache"
+	"soarca/pkg/models/execution"
 	itime "soarca/pkg/utils/time"
 	"sync"
 	"time"
@@ -119,7 +122,14 @@ func (cacheReporter *Cache) upateEndExecutionWorkflow(executionId uuid.UUID, wor
 		return err
 	}
 
-	if workflowError != nil {
+	var exceptionCondition execution.ErrorExceptionCondition
+	if errors.Is(workflowError, context.Canceled) {
+		executionEntry.Error = workflowError
+		executionEntry.Status = cache_report.Cancelled
+	} else if errors.As(workflowError, &exceptionCondition) {
+		executionEntry.Error = workflowError
+		executionEntry.Status = cache_report.ExceptionConditionError
+	} else if workflowError != nil {
 		executionEntry.Error = workflowError
 		executionEntry.Status = cache_report.Failed
 	} else {
@@ -132,6 +142,51 @@ func (cacheReporter *Cache) upateEndExecutionWorkflow(executionId uuid.UUID, wor
 	// Unlocked
 }
 
+func (cacheReporter *Cache) updateExecutionStatus(executionId uuid.UUID, from cache_report.Status, to cache_report.Status) error {
+	// Locked
+	cacheReporter.mutex.Lock()
+	defer cacheReporter.mutex.Unlock()
+
+	executionEntry, err := cacheReporter.getExecution(executionId)
+	if err != nil {
+		return err
+	}
+
+	if executionEntry.Status != from {
+		return fmt.Errorf("execution status precondition not met for status update [execution status: %s]", executionEntry.Status.String())
+	}
+	executionEntry.Status = to
+	cacheReporter.Cache[executionId.String()] = executionEntry
+
+	return nil
+	// Unlocked
+}
+
+// Mark a queued execution as started, returns false when the execution was not
+// queued. An execution paused while queued stays paused.
+func (cacheReporter *Cache) startQueuedExecution(executionId uuid.UUID, at time.Time) bool {
+	// Locked
+	cacheReporter.mutex.Lock()
+	defer cacheReporter.mutex.Unlock()
+
+	executionEntry, err := cacheReporter.getExecution(executionId)
+	if err != nil || !executionEntry.Started.IsZero() {
+		return false
+	}
+	switch executionEntry.Status {
+	case cache_report.Queued:
+		executionEntry.Status = cache_report.Ongoing
+	case cache_report.Paused:
+	default:
+		return false
+	}
+	executionEntry.Started = at
+	cacheReporter.Cache[executionId.String()] = executionEntry
+
+	return true
+	// Unlocked
+}
+
 func (cacheReporter *Cache) addStartExecutionStep(executionId uuid.UUID, newStepData cache_report.StepResult) error {
 	// Locked
 	cacheReporter.mutex.Lock()
@@ -142,7 +197,8 @@ func (cacheReporter *Cache) addStartExecutionStep(executionId uuid.UUID, newStep
 		return err
 	}
 
-	if executionEntry.Status != cache_report.Ongoing {
+	// Steps that passed their checkpoint before a pause still start
+	if executionEntry.Status != cache_report.Ongoing && executionEntry.Status != cache_report.Paused {
 		return errors.New("trying to report on the execution of a step for an already reportedly terminated playbook execution")
 	}
 	_, alreadyThere := executionEntry.StepResults[newStepData.StepId]
@@ -185,12 +241,8 @@ func (cacheReporter *Cache) upateEndExecutionStep(executionId uuid.UUID, stepId
 		return fmt.Errorf("step status precondition not met for step update [step status: %s]", executionStepResult.Status.String())
 	}
 
-	if stepError != nil {
-		executionStepResult.Error = stepError
-		executionStepResult.Status = cache_report.ServerSideError
-	} else {
-		executionStepResult.Status = cache_report.SuccessfullyExecuted
-	}
+	executionStepResult.Error = stepError
+	executionStepResult.Status = stepStatus(stepError)
 	executionStepResult.Ended = at
 	executionStepResult.Variables = returnVars
 	executionEntry.StepResults[stepId] = executionStepResult
@@ -200,6 +252,68 @@ func (cacheReporter *Cache) upateEndExecutionStep(executionId uuid.UUID, stepId
 	// Unlocked
 }
 
+func (cacheReporter *Cache) addExecutionStepAttempt(executionId uuid.UUID, stepId string, attempt cache_report.StepAttempt) error {
+	// Locked
+	cacheReporter.mutex.Lock()
+	defer cacheReporter.mutex.Unlock()
+
+	executionEntry, err := cacheReporter.getExecution(executionId)
+	if err != nil {
+		return err
+	}
+
+	executionStepResult, ok := executionEntry.StepResults[stepId]
+	if !ok {
+		return errors.New("trying to report an attempt of a step which was not (yet?) recorded in the cache")
+	}
+	if executionStepResult.Status != cache_report.Ongoing {
+		return fmt.Errorf("step status precondition not met for step attempt [step status: %s]", executionStepResult.Status.String())
+	}
+
+	executionStepResult.Attempts = append(executionStepResult.Attempts, attempt)
+	executionEntry.StepResults[stepId] = executionStepResult
+	cacheReporter.Cache[executionId.String()] = executionEntry
+
+	return nil
+	// Unlocked
+}
+
+func (cacheReporter *Cache) addExecutionStepIteration(executionId uuid.UUID, stepId string, iteration cache_report.StepIteration) error {
+	// Locked
+	cacheReporter.mutex.Lock()
+	defer cacheReporter.mutex.Unlock()
+
+	executionEntry, err := cacheReporter.getExecution(executionId)
+	if err != nil {
+		return err
+	}
+
+	// The loop step already ended its first condition evaluation, so its status is not checked
+	executionStepResult, ok := executionEntry.StepResults[stepId]
+	if !ok {
+		return errors.New("trying to report an iteration of a step which was not (yet?) recorded in the cache")
+	}
+
+	executionStepResult.Iterations = append(executionStepResult.Iterations, iteration)
+	executionEntry.StepResults[stepId] = executionStepResult
+	cacheReporter.Cache[executionId.String()] = executionEntry
+
+	return nil
+	// Unlocked
+}
+
+func stepStatus(stepError error) cache_report.Status {
+	if stepError == nil {
+		return cache_report.SuccessfullyExecuted
+	}
+	if errors.Is(stepError, context.DeadlineExceeded) {
+		return cache_report.TimeoutError
+	} else if errors.Is(stepError, context.Canceled) {
+		return cache_report.Cancelled
+	}
+	return cache_report.ServerSideError
+}
+
 // ############################### Informer interface
 
 func (cacheReporter *Cache) GetExecutions() ([]cache_report.ExecutionEntry, error) {
@@ -220,7 +334,16 @@ func (cacheReporter *Cache) GetExecutionReport(executionKey uuid.UUID) (cache_re
 
 // ############################### Reporting interface
 
+// The cache backs the reporter API of SOARCA itself and receives all data
+func (cacheReporter *Cache) MaxMarking() marking.Level {
+	return marking.Red
+}
+
 func (cacheReporter *Cache) ReportWorkflowStart(executionId uuid.UUID, playbook cacao.Playbook, at time.Time) error {
+	// Queued executions are already in the cache
+	if cacheReporter.startQueuedExecution(executionId, at) {
+		return nil
+	}
 
 	newExecutionEntry := cache_report.ExecutionEntry{
 		ExecutionId: executionId,
@@ -293,3 +416,77 @@ func (cacheReporter *Cache) ReportStepEnd(executionId uuid.UUID, step cacao.Step
 
 	return err
 }
+
+func (cacheReporter *Cache) ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, stepError error, at time.Time) error {
+
+	newAttempt := cache_report.StepAttempt{
+		Attempt: attempt,
+		Ended:   at,
+		Status:  stepStatus(stepError),
+		Error:   stepError,
+	}
+	return cacheReporter.addExecutionStepAttempt(executionId, step.ID, newAttempt)
+}
+
+func (cacheReporter *Cache) ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) error {
+
+	newIteration := cache_report.StepIteration{
+		Iteration: iteration,
+		Started:   at,
+	}
+	return cacheReporter.addExecutionStepIteration(executionId, step.ID, newIteration)
+}
+
+// ############################### Execution state interface
+
+// Queued executions can be paused as well, they return to queued when they are
+// resumed before they have started
+func (cacheReporter *Cache) ReportExecutionPaused(executionId uuid.UUID) error {
+	err := cacheReporter.updateExecutionStatus(executionId, cache_report.Ongoing, cache_report.Paused)
+	if err != nil {
+		err = cacheReporter.updateExecutionStatus(executionId, cache_report.Queued, cache_report.Paused)
+	}
+	return err
+}
+
+func (cacheReporter *Cache) ReportExecutionResumed(executionId uuid.UUID) error {
+	// Locked
+	cacheReporter.mutex.Lock()
+	defer cacheReporter.mutex.Unlock()
+
+	executionEntry, err := cacheReporter.getExecution(executionId)
+	if err != nil {
+		return err
+	}
+	if executionEntry.Status != cache_report.Paused {
+		return fmt.Errorf("execution status precondition not met for status update [execution status: %s]", executionEntry.Status.String())
+	}
+	executionEntry.Status = cache_report.Ongoing
+	if executionEntry.Started.IsZero() {
+		executionEntry.Status = cache_report.Queued
+	}
+	cacheReporter.Cache[executionId.String()] = executionEntry
+
+	return nil
+	// Unlocked
+}
+
+// ############################### Execution queue interface
+
+// Queued executions are listed without start time until the workflow start is reported
+func (cacheReporter *Cache) ReportExecutionQueued(executionId uuid.UUID, playbook cacao.Playbook, at time.Time) error {
+	newExecutionEntry := cache_report.ExecutionEntry{
+		ExecutionId: executionId,
+		PlaybookId:  playbook.ID,
+		Name:        playbook.Name,
+		Description: playbook.Description,
+		StepResults: map[string]cache_report.StepResult{},
+		Status:      cache_report.Queued,
+	}
+	return cacheReporter.addExecutionFIFO(newExecutionEntry)
+}
+
+// A queued execution that is cancelled never starts
+func (cacheReporter *Cache) ReportQueuedExecutionCancelled(executionId uuid.UUID, at time.Time) error {
+	return cacheReporter.upateEndExecutionWorkflow(executionId, context.Canceled, at)
+}
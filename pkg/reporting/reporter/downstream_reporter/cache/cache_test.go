@@ -1,10 +1,13 @@
 package cache
 
 import (
+	"context"
 	b64 "encoding/base64"
 	"errors"
+	"fmt"
 	"soarca/pkg/models/cacao"
 	cache_model "soarca/pkg/models/cache"
+	"soarca/pkg/models/execution"
 	mock_time "soarca/test/unittest/mocks/mock_utils/time"
 	"testing"
 	"time"
@@ -965,3 +968,362 @@ func TestAcceptedStepReportAfterExecutionEnd(t *testing.T) {
 
 	mock_time.AssertExpectations(t)
 }
+
+func TestReportWorkflowEndExceptionCondition(t *testing.T) {
+
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{
+		ID:          "test",
+		Type:        "test",
+		Name:        "exception-test-playbook",
+		Description: "Playbook description",
+	}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	err := cacheReporter.ReportWorkflowStart(executionId0, playbook, mock_time.Now())
+	if err != nil {
+		t.Fail()
+	}
+	workflowError := execution.ErrorExceptionCondition{StepId: "action--test",
+		Err: errors.New("failed")}
+	err = cacheReporter.ReportWorkflowEnd(executionId0, playbook, workflowError, mock_time.Now())
+	if err != nil {
+		t.Fail()
+	}
+
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.ExceptionConditionError)
+	assert.Equal(t, exec.Error, workflowError)
+}
+
+func TestReportStepEndTimeout(t *testing.T) {
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	step1 := cacao.Step{
+		Type:         "action",
+		ID:           "action--test",
+		Name:         "ssh-tests",
+		Timeout:      100,
+		OnCompletion: "end--test",
+		Agent:        "agent1",
+		Targets:      []string{"target1"},
+	}
+
+	end := cacao.Step{
+		Type: "end",
+		ID:   "end--test",
+		Name: "end step",
+	}
+
+	playbook := cacao.Playbook{
+		ID:            "test",
+		Type:          "test",
+		Name:          "ssh-test",
+		WorkflowStart: step1.ID,
+		Workflow:      map[string]cacao.Step{step1.ID: step1, end.ID: end},
+	}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	err := cacheReporter.ReportWorkflowStart(executionId0, playbook, mock_time.Now())
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportStepStart(executionId0, step1, cacao.NewVariables(), mock_time.Now())
+	assert.Equal(t, err, nil)
+
+	stepError := fmt.Errorf("step timed out after 100 ms: %w", context.DeadlineExceeded)
+	err = cacheReporter.ReportStepEnd(executionId0, step1, cacao.NewVariables(), stepError, mock_time.Now())
+	assert.Equal(t, err, nil)
+
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	stepResult := exec.StepResults[step1.ID]
+	assert.Equal(t, stepResult.Status, cache_model.TimeoutError)
+	assert.Equal(t, stepResult.Error, stepError)
+	mock_time.AssertExpectations(t)
+}
+
+func TestReportWorkflowEndCancelled(t *testing.T) {
+
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{
+		ID:          "test",
+		Type:        "test",
+		Name:        "cancel-test-playbook",
+		Description: "Playbook description",
+	}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	err := cacheReporter.ReportWorkflowStart(executionId0, playbook, mock_time.Now())
+	if err != nil {
+		t.Fail()
+	}
+	workflowError := fmt.Errorf("execution cancelled before step [ action--test ]: %w", context.Canceled)
+	err = cacheReporter.ReportWorkflowEnd(executionId0, playbook, workflowError, mock_time.Now())
+	if err != nil {
+		t.Fail()
+	}
+
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Cancelled)
+	assert.Equal(t, exec.Error, workflowError)
+}
+
+func TestReportExecutionPausedAndResumed(t *testing.T) {
+
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{
+		ID:          "test",
+		Type:        "test",
+		Name:        "pause-test-playbook",
+		Description: "Playbook description",
+	}
+	step := cacao.Step{
+		Type: cacao.StepTypeAction,
+		ID:   "action--test",
+	}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	err := cacheReporter.ReportWorkflowStart(executionId0, playbook, mock_time.Now())
+	if err != nil {
+		t.Fail()
+	}
+
+	err = cacheReporter.ReportExecutionPaused(executionId0)
+	assert.Equal(t, err, nil)
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Paused)
+
+	// A step that passed its checkpoint before the pause can still be reported
+	err = cacheReporter.ReportStepStart(executionId0, step, cacao.NewVariables(), mock_time.Now())
+	assert.Equal(t, err, nil)
+
+	err = cacheReporter.ReportExecutionPaused(executionId0)
+	assert.NotEqual(t, err, nil)
+
+	err = cacheReporter.ReportExecutionResumed(executionId0)
+	assert.Equal(t, err, nil)
+	exec, err = cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Ongoing)
+}
+
+func TestReportStepAttempts(t *testing.T) {
+
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{
+		ID:          "test",
+		Type:        "test",
+		Name:        "retry-test-playbook",
+		Description: "Playbook description",
+	}
+	step := cacao.Step{
+		Type: cacao.StepTypeAction,
+		ID:   "action--test",
+	}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	err := cacheReporter.ReportWorkflowStart(executionId0, playbook, mock_time.Now())
+	assert.Equal(t, err, nil)
+
+	// Attempts of a step that was not started are not accepted
+	attemptError := errors.New("connection refused")
+	err = cacheReporter.ReportStepAttempt(executionId0, step, 1, attemptError, mock_time.Now())
+	assert.NotEqual(t, err, nil)
+
+	err = cacheReporter.ReportStepStart(executionId0, step, cacao.NewVariables(), mock_time.Now())
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportStepAttempt(executionId0, step, 1, attemptError, mock_time.Now())
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportStepAttempt(executionId0, step, 2, nil, mock_time.Now())
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportStepEnd(executionId0, step, cacao.NewVariables(), nil, mock_time.Now())
+	assert.Equal(t, err, nil)
+
+	expectedAttempts := []cache_model.StepAttempt{
+		{Attempt: 1, Ended: timeNow, Status: cache_model.ServerSideError, Error: attemptError},
+		{Attempt: 2, Ended: timeNow, Status: cache_model.SuccessfullyExecuted},
+	}
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.StepResults[step.ID].Attempts, expectedAttempts)
+	assert.Equal(t, exec.StepResults[step.ID].Status, cache_model.SuccessfullyExecuted)
+
+	// No attempts after the step ended
+	err = cacheReporter.ReportStepAttempt(executionId0, step, 3, nil, mock_time.Now())
+	assert.NotEqual(t, err, nil)
+}
+
+func TestReportLoopIterations(t *testing.T) {
+
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{
+		ID:          "test",
+		Type:        "test",
+		Name:        "loop-test-playbook",
+		Description: "Playbook description",
+	}
+	step := cacao.Step{
+		Type: cacao.StepTypeWhileCondition,
+		ID:   "while-condition--test",
+	}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	err := cacheReporter.ReportWorkflowStart(executionId0, playbook, mock_time.Now())
+	assert.Equal(t, err, nil)
+
+	// Iterations of a step that was not started are not accepted
+	err = cacheReporter.ReportLoopIteration(executionId0, step, 1, mock_time.Now())
+	assert.NotEqual(t, err, nil)
+
+	// The loop step has ended its first condition evaluation before the iterations
+	err = cacheReporter.ReportStepStart(executionId0, step, cacao.NewVariables(), mock_time.Now())
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportStepEnd(executionId0, step, cacao.NewVariables(), nil, mock_time.Now())
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportLoopIteration(executionId0, step, 1, mock_time.Now())
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportLoopIteration(executionId0, step, 2, mock_time.Now())
+	assert.Equal(t, err, nil)
+
+	expectedIterations := []cache_model.StepIteration{
+		{Iteration: 1, Started: timeNow},
+		{Iteration: 2, Started: timeNow},
+	}
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.StepResults[step.ID].Iterations, expectedIterations)
+}
+
+func TestReportExecutionQueuedAndStarted(t *testing.T) {
+
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{
+		ID:          "test",
+		Type:        "test",
+		Name:        "queue-test-playbook",
+		Description: "Playbook description",
+	}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+
+	layout := "2006-01-02T15:04:05.000Z"
+	str := "2014-11-12T11:45:26.371Z"
+	timeNow, _ := time.Parse(layout, str)
+	mock_time.On("Now").Return(timeNow)
+
+	err := cacheReporter.ReportExecutionQueued(executionId0, playbook, mock_time.Now())
+	assert.Equal(t, err, nil)
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Queued)
+	assert.Equal(t, exec.Started, time.Time{})
+
+	err = cacheReporter.ReportWorkflowStart(executionId0, playbook, mock_time.Now())
+	assert.Equal(t, err, nil)
+	exec, err = cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Ongoing)
+	assert.Equal(t, exec.Started, timeNow)
+
+	executions, err := cacheReporter.GetExecutions()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(executions), 1)
+}
+
+func TestReportQueuedExecutionCancelled(t *testing.T) {
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{ID: "test", Name: "queue-test-playbook"}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+	timeNow, _ := time.Parse("2006-01-02T15:04:05.000Z", "2014-11-12T11:45:26.371Z")
+
+	err := cacheReporter.ReportExecutionQueued(executionId0, playbook, timeNow)
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportQueuedExecutionCancelled(executionId0, timeNow)
+	assert.Equal(t, err, nil)
+
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Cancelled)
+	assert.Equal(t, exec.Started, time.Time{})
+	assert.Equal(t, exec.Ended, timeNow)
+}
+
+func TestReportQueuedExecutionPaused(t *testing.T) {
+	mock_time := new(mock_time.MockTime)
+	cacheReporter := New(mock_time, 10)
+
+	playbook := cacao.Playbook{ID: "test", Name: "queue-test-playbook"}
+	executionId0 := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c0")
+	timeNow, _ := time.Parse("2006-01-02T15:04:05.000Z", "2014-11-12T11:45:26.371Z")
+
+	err := cacheReporter.ReportExecutionQueued(executionId0, playbook, timeNow)
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportExecutionPaused(executionId0)
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportExecutionResumed(executionId0)
+	assert.Equal(t, err, nil)
+	exec, err := cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Queued)
+
+	// Paused while queued, the execution stays paused when it starts
+	err = cacheReporter.ReportExecutionPaused(executionId0)
+	assert.Equal(t, err, nil)
+	err = cacheReporter.ReportWorkflowStart(executionId0, playbook, timeNow)
+	assert.Equal(t, err, nil)
+	exec, err = cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Paused)
+	assert.Equal(t, exec.Started, timeNow)
+
+	err = cacheReporter.ReportExecutionResumed(executionId0)
+	assert.Equal(t, err, nil)
+	exec, err = cacheReporter.GetExecutionReport(executionId0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exec.Status, cache_model.Ongoing)
+}
@@ -13,4 +13,6 @@ type IDownStreamReporter interface {
 
 	ReportStepStart(executionId uuid.UUID, step cacao.Step, stepResults cacao.Variables, at time.Time) error
 	ReportStepEnd(executionId uuid.UUID, step cacao.Step, stepResults cacao.Variables, err error, at time.Time) error
+	ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, err error, at time.Time) error
+	ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) error
 }
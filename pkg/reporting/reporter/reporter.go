@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"soarca/internal/logger"
+	"soarca/pkg/core/marking"
 	"soarca/pkg/models/cacao"
 	downstreamReporter "soarca/pkg/reporting/reporter/downstream_reporter"
 	"soarca/pkg/utils"
@@ -29,21 +30,29 @@ type IWorkflowReporter interface {
 	// -> Give info to downstream reporters
 	ReportWorkflowStart(executionId uuid.UUID, playbook cacao.Playbook, at time.Time)
 	ReportWorkflowEnd(executionId uuid.UUID, playbook cacao.Playbook, workflowError error, at time.Time)
+	// Report an iteration of a while-condition step, before its body is executed
+	ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time)
 }
 type IStepReporter interface {
 	// -> Give info to downstream reporters
 	ReportStepStart(executionId uuid.UUID, step cacao.Step, returnVars cacao.Variables, at time.Time)
 	ReportStepEnd(executionId uuid.UUID, step cacao.Step, returnVars cacao.Variables, stepError error, at time.Time)
+	// Report an attempt of a step that is retried, before the step end is reported
+	ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, stepError error, at time.Time)
 }
 
 const MaxReporters int = 10
 
-// High-level reporter class with injection of specific reporters
+// High-level reporter class with injection of specific reporters. Data is
+// redacted to the maximum marking of every downstream reporter.
 type Reporter struct {
 	reporters    []downstreamReporter.IDownStreamReporter
 	maxReporters int
 	wg           sync.WaitGroup
 	reportingch  chan func()
+	// Marking policies of the running executions
+	policies map[uuid.UUID]marking.Policy
+	mutex    sync.Mutex
 }
 
 func New(reporters []downstreamReporter.IDownStreamReporter) *Reporter {
@@ -53,6 +62,7 @@ func New(reporters []downstreamReporter.IDownStreamReporter) *Reporter {
 		maxReporters: maxReporters,
 		reportingch:  make(chan func(), 100), // Buffer size can be adjusted
 		wg:           sync.WaitGroup{},
+		policies:     map[uuid.UUID]marking.Policy{},
 	}
 	go instance.startReportingProcessor()
 	return &instance
@@ -84,15 +94,27 @@ func (reporter *Reporter) RegisterReporters(reporters []downstreamReporter.IDown
 	}
 }
 
+// Policy of an execution, steps reported outside of a workflow are unmarked
+func (reporter *Reporter) policy(executionId uuid.UUID) marking.Policy {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	return reporter.policies[executionId]
+}
+
 // ######################## IWorkflowReporter interface
 
 func (reporter *Reporter) ReportWorkflowStart(executionId uuid.UUID, playbook cacao.Playbook, at time.Time) {
 	log.Trace(fmt.Sprintf("[execution: %s, playbook: %s] reporting workflow start", executionId, playbook.ID))
+	policy := marking.PolicyOf(playbook)
+	reporter.mutex.Lock()
+	reporter.policies[executionId] = policy
+	reporter.mutex.Unlock()
 	reporter.wg.Add(1)
 	reporter.reportingch <- func() {
 		defer reporter.wg.Done()
 		for _, downstreamRep := range reporter.reporters {
-			err := downstreamRep.ReportWorkflowStart(executionId, playbook, at)
+			maxMarking := marking.MaxMarkingOf(downstreamRep)
+			err := downstreamRep.ReportWorkflowStart(executionId, policy.RedactPlaybook(playbook, maxMarking), at)
 			if err != nil {
 				log.Trace("reportWorkflowStart error")
 				log.Warning(err)
@@ -103,11 +125,16 @@ func (reporter *Reporter) ReportWorkflowStart(executionId uuid.UUID, playbook ca
 
 func (reporter *Reporter) ReportWorkflowEnd(executionId uuid.UUID, playbook cacao.Playbook, workflowError error, at time.Time) {
 	log.Trace(fmt.Sprintf("[execution: %s, playbook: %s] reporting workflow end", executionId, playbook.ID))
+	policy := marking.PolicyOf(playbook)
+	reporter.mutex.Lock()
+	delete(reporter.policies, executionId)
+	reporter.mutex.Unlock()
 	reporter.wg.Add(1)
 	reporter.reportingch <- func() {
 		defer reporter.wg.Done()
 		for _, downstreamRep := range reporter.reporters {
-			err := downstreamRep.ReportWorkflowEnd(executionId, playbook, workflowError, at)
+			maxMarking := marking.MaxMarkingOf(downstreamRep)
+			err := downstreamRep.ReportWorkflowEnd(executionId, policy.RedactPlaybook(playbook, maxMarking), workflowError, at)
 			if err != nil {
 				log.Trace("reportWorkflowEnd error")
 				log.Warning(err)
@@ -120,11 +147,13 @@ func (reporter *Reporter) ReportWorkflowEnd(executionId uuid.UUID, playbook caca
 
 func (reporter *Reporter) ReportStepStart(executionId uuid.UUID, step cacao.Step, returnVars cacao.Variables, at time.Time) {
 	log.Trace(fmt.Sprintf("[execution: %s, step: %s] reporting step start", executionId, step.ID))
+	policy := reporter.policy(executionId)
 	reporter.wg.Add(1)
 	reporter.reportingch <- func() {
 		defer reporter.wg.Done()
 		for _, downstreamRep := range reporter.reporters {
-			err := downstreamRep.ReportStepStart(executionId, step, returnVars, at)
+			maxMarking := marking.MaxMarkingOf(downstreamRep)
+			err := downstreamRep.ReportStepStart(executionId, policy.RedactStep(step, maxMarking), policy.Redact(returnVars, maxMarking), at)
 			if err != nil {
 				log.Trace("reportStepStart error")
 				log.Warning(err)
@@ -135,11 +164,13 @@ func (reporter *Reporter) ReportStepStart(executionId uuid.UUID, step cacao.Step
 
 func (reporter *Reporter) ReportStepEnd(executionId uuid.UUID, step cacao.Step, returnVars cacao.Variables, stepError error, at time.Time) {
 	log.Trace(fmt.Sprintf("[execution: %s, step: %s] reporting step end", executionId, step.ID))
+	policy := reporter.policy(executionId)
 	reporter.wg.Add(1)
 	reporter.reportingch <- func() {
 		defer reporter.wg.Done()
 		for _, downstreamRep := range reporter.reporters {
-			err := downstreamRep.ReportStepEnd(executionId, step, returnVars, stepError, at)
+			maxMarking := marking.MaxMarkingOf(downstreamRep)
+			err := downstreamRep.ReportStepEnd(executionId, policy.RedactStep(step, maxMarking), policy.Redact(returnVars, maxMarking), stepError, at)
 			if err != nil {
 				log.Trace("reportStepEnd error")
 				log.Warning(err)
@@ -147,3 +178,37 @@ func (reporter *Reporter) ReportStepEnd(executionId uuid.UUID, step cacao.Step,
 		}
 	}
 }
+
+func (reporter *Reporter) ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, stepError error, at time.Time) {
+	log.Trace(fmt.Sprintf("[execution: %s, step: %s] reporting step attempt %d", executionId, step.ID, attempt))
+	policy := reporter.policy(executionId)
+	reporter.wg.Add(1)
+	reporter.reportingch <- func() {
+		defer reporter.wg.Done()
+		for _, downstreamRep := range reporter.reporters {
+			maxMarking := marking.MaxMarkingOf(downstreamRep)
+			err := downstreamRep.ReportStepAttempt(executionId, policy.RedactStep(step, maxMarking), attempt, stepError, at)
+			if err != nil {
+				log.Trace("reportStepAttempt error")
+				log.Warning(err)
+			}
+		}
+	}
+}
+
+func (reporter *Reporter) ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) {
+	log.Trace(fmt.Sprintf("[execution: %s, step: %s] reporting loop iteration %d", executionId, step.ID, iteration))
+	policy := reporter.policy(executionId)
+	reporter.wg.Add(1)
+	reporter.reportingch <- func() {
+		defer reporter.wg.Done()
+		for _, downstreamRep := range reporter.reporters {
+			maxMarking := marking.MaxMarkingOf(downstreamRep)
+			err := downstreamRep.ReportLoopIteration(executionId, policy.RedactStep(step, maxMarking), iteration, at)
+			if err != nil {
+				log.Trace("reportLoopIteration error")
+				log.Warning(err)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package reporter
 
 import (
+	"soarca/pkg/core/marking"
 	"soarca/pkg/models/cacao"
 	ds_reporter "soarca/pkg/reporting/reporter/downstream_reporter"
 	"soarca/test/unittest/mocks/mock_reporter"
@@ -380,3 +381,58 @@ func TestMultipleDownstreamReporters(t *testing.T) {
 	mock_ds_reporter2.AssertExpectations(t)
 	mock_time.AssertExpectations(t)
 }
+
+// Downstream reporter that may receive all data
+type unrestrictedReporter struct {
+	mock_reporter.Mock_Downstream_Reporter
+}
+
+func (downstream *unrestrictedReporter) MaxMarking() marking.Level {
+	return marking.Red
+}
+
+func TestReportRedactsMarkedVariables(t *testing.T) {
+	var wg sync.WaitGroup
+	restricted := mock_reporter.Mock_Downstream_Reporter{Wg: &wg}
+	unrestricted := unrestrictedReporter{mock_reporter.Mock_Downstream_Reporter{Wg: &wg}}
+	reporter := New([]ds_reporter.IDownStreamReporter{&restricted, &unrestricted})
+
+	secret := cacao.Variable{Type: cacao.VariableTypeString, Name: "__secret__", Value: "testing"}
+	redacted := secret
+	redacted.Value = marking.RedactedValue
+
+	step := cacao.Step{Type: cacao.StepTypeAction,
+		ID:            "action--test",
+		StepVariables: cacao.NewVariables(secret)}
+	redactedStep := step
+	redactedStep.StepVariables = cacao.NewVariables(redacted)
+
+	playbook := cacao.Playbook{ID: "test",
+		Markings: []string{"marking-tlp--amber"},
+		DataMarkingDefinitions: cacao.DataMarkings{"marking-tlp--amber": {Type: "marking-tlp",
+			ID:         "marking-tlp--amber",
+			TLPv2Level: "TLP:AMBER"}},
+		PlaybookVariables: cacao.NewVariables(secret)}
+	redactedPlaybook := playbook
+	redactedPlaybook.PlaybookVariables = cacao.NewVariables(redacted)
+
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	timeNow := time.Date(2014, 11, 12, 11, 45, 26, 0, time.UTC)
+
+	wg.Add(6)
+	restricted.On("ReportWorkflowStart", executionId, redactedPlaybook, timeNow).Return(nil)
+	unrestricted.On("ReportWorkflowStart", executionId, playbook, timeNow).Return(nil)
+	restricted.On("ReportStepEnd", executionId, redactedStep, cacao.NewVariables(redacted), nil, timeNow).Return(nil)
+	unrestricted.On("ReportStepEnd", executionId, step, cacao.NewVariables(secret), nil, timeNow).Return(nil)
+	restricted.On("ReportWorkflowEnd", executionId, redactedPlaybook, nil, timeNow).Return(nil)
+	unrestricted.On("ReportWorkflowEnd", executionId, playbook, nil, timeNow).Return(nil)
+
+	reporter.ReportWorkflowStart(executionId, playbook, timeNow)
+	reporter.ReportStepEnd(executionId, step, cacao.NewVariables(secret), nil, timeNow)
+	reporter.ReportWorkflowEnd(executionId, playbook, nil, timeNow)
+
+	wg.Wait()
+	restricted.AssertExpectations(t)
+	unrestricted.AssertExpectations(t)
+	assert.Equal(t, len(reporter.policies), 0)
+}
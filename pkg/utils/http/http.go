@@ -2,8 +2,8 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +13,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"soarca/internal/logger"
 	"soarca/pkg/models/cacao"
@@ -27,6 +28,16 @@ func init() {
 	log = logger.Logger(component, logger.Info, "", logger.Json)
 }
 
+// Returned for responses outside of the 2xx range, the message is the response body
+type ErrorResponseStatus struct {
+	StatusCode int
+	Body       string
+}
+
+func (e ErrorResponseStatus) Error() string {
+	return e.Body
+}
+
 type HttpOptions struct {
 	Target  *cacao.AgentTarget
 	Command *cacao.Command
@@ -37,11 +48,23 @@ type IHttpOptions interface {
 	ExtractUrl() (string, error)
 }
 type IHttpRequest interface {
-	Request(httpOptions HttpOptions) ([]byte, error)
+	Request(ctx context.Context, httpOptions HttpOptions) ([]byte, error)
+	RequestResponse(ctx context.Context, httpOptions HttpOptions) (Response, error)
 }
 
+// Response to a request with a status in the 2xx range
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// The client is created on the first request and shared by all requests,
+// so connections are reused across executions
 type HttpRequest struct {
 	skipCertificateValidation bool
+	once                      sync.Once
+	client                    *http.Client
 }
 
 // https://gist.githubusercontent.com/ahmetozer/ffa4cd0b319aff32ea9ed0068c8b81cf/raw/fc8742e6e087451e954bf0da214794a620356a4d/IPv4-IPv6-domain-regex.go
@@ -55,31 +78,44 @@ func (httpRequest *HttpRequest) SkipCertificateValidation(skip bool) {
 	httpRequest.skipCertificateValidation = skip
 }
 
-func (httpRequest *HttpRequest) Request(httpOptions HttpOptions) ([]byte, error) {
-	request, err := httpOptions.setupRequest()
-	if err != nil {
-		return []byte{}, err
-	}
+func (httpRequest *HttpRequest) Request(ctx context.Context, httpOptions HttpOptions) ([]byte, error) {
+	response, err := httpRequest.RequestResponse(ctx, httpOptions)
+	return response.Body, err
+}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: httpRequest.skipCertificateValidation},
+// Like Request, with the status and headers of the response
+func (httpRequest *HttpRequest) RequestResponse(ctx context.Context, httpOptions HttpOptions) (Response, error) {
+	request, err := httpOptions.setupRequest(ctx)
+	if err != nil {
+		return Response{Body: []byte{}}, err
 	}
 
-	client := &http.Client{Transport: transport}
 	log.Trace(request)
-	response, err := client.Do(request)
+	response, err := httpRequest.getClient().Do(request)
 	if err != nil {
 		log.Error(err)
-		return []byte{}, err
+		return Response{Body: []byte{}}, err
 	}
 	data, err := httpOptions.handleResponse(response)
 	if response.Body.Close() != nil {
 		log.Warning("error closing response body")
 	}
-	return data, err
+	if err != nil {
+		return Response{Body: data}, err
+	}
+	return Response{StatusCode: response.StatusCode, Header: response.Header, Body: data}, nil
 }
 
-func (httpOptions *HttpOptions) setupRequest() (*http.Request, error) {
+func (httpRequest *HttpRequest) getClient() *http.Client {
+	httpRequest.once.Do(func() {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: httpRequest.skipCertificateValidation}
+		httpRequest.client = &http.Client{Transport: transport}
+	})
+	return httpRequest.client
+}
+
+func (httpOptions *HttpOptions) setupRequest(ctx context.Context) (*http.Request, error) {
 	parsedUrl, err := httpOptions.ExtractUrl()
 	if err != nil {
 		log.Error(err)
@@ -91,22 +127,10 @@ func (httpOptions *HttpOptions) setupRequest() (*http.Request, error) {
 		return nil, err
 	}
 
-	requestBuffer := bytes.NewBufferString("")
-	if httpOptions.Command.Content != "" {
-		log.Debug("using the content field")
-		requestBuffer = bytes.NewBufferString(httpOptions.Command.Content)
-	} else if httpOptions.Command.ContentB64 != "" {
-		log.Debug("using base64 content")
-		byteString, err := base64.StdEncoding.DecodeString(httpOptions.Command.ContentB64)
-		if err != nil {
-			log.Error("error decoding base64", err)
-		} else {
-			log.Trace(string(byteString))
-			requestBuffer = bytes.NewBufferString(string(byteString))
-		}
-	}
+	// content_b64 is decoded into the content by the action executor
+	requestBuffer := bytes.NewBufferString(httpOptions.Command.Content)
 	log.Trace("request buffer is: ", requestBuffer)
-	request, err := http.NewRequest(method, parsedUrl, requestBuffer)
+	request, err := http.NewRequestWithContext(ctx, method, parsedUrl, requestBuffer)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -132,25 +156,11 @@ func (httpRequest *HttpOptions) handleResponse(response *http.Response) ([]byte,
 	log.Trace(fmt.Sprint(sc))
 	log.Trace(string(responseBytes))
 	if sc < 200 || sc > 299 {
-		return []byte{}, errors.New(string(responseBytes))
+		return []byte{}, ErrorResponseStatus{StatusCode: sc, Body: string(responseBytes)}
 	}
 	return responseBytes, nil
 }
 
-func verifyAuthInfoMatchesAgentTarget(
-	target *cacao.AgentTarget, authInfo *cacao.AuthenticationInformation,
-) error {
-	log.Trace("target id: ", target.AuthInfoIdentifier, " auth info object id: ", authInfo.ID)
-	if target.AuthInfoIdentifier == "" || authInfo.ID == "" {
-		return errors.New("target target.AuthInfoIdentifier or authInfo.ID is empty")
-	}
-
-	if target.AuthInfoIdentifier != authInfo.ID {
-		return errors.New("target auth info Id does not match auth info object's")
-	}
-	return nil
-}
-
 func (httpOptions *HttpOptions) addHeaderTo(request *http.Request) {
 	for headerKey, headerValues := range httpOptions.Command.Headers {
 		for _, headerValue := range headerValues {
@@ -163,13 +173,11 @@ func (httpOptions *HttpOptions) addAuthTo(request *http.Request) error {
 	if httpOptions.Auth == nil {
 		return nil
 	}
+	// The authentication can be the one of the step, the agent or the target,
+	// it is selected by the action executor
 	if (cacao.AuthenticationInformation{}) == *httpOptions.Auth {
 		return nil
 	}
-	if err := verifyAuthInfoMatchesAgentTarget(httpOptions.Target, httpOptions.Auth); err != nil {
-		log.Error(err)
-		return errors.New("auth info does not match target Id")
-	}
 
 	authInfoType := httpOptions.Auth.Type
 
@@ -1,7 +1,7 @@
 package http
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -50,7 +50,7 @@ func TestHttpGetConnection(t *testing.T) {
 		Command: &command,
 		Target:  &target,
 	}
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	t.Log(string(response))
 	if err != nil {
 		t.Error("http get request test has failed: ", err)
@@ -78,7 +78,7 @@ func TestHttpPostConnection(t *testing.T) {
 		Command: &command,
 		Target:  &target,
 	}
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	t.Log(string(response))
 	if err != nil {
 		t.Error("http post request test has failed: ", err)
@@ -105,7 +105,7 @@ func TestHttpPutConnection(t *testing.T) {
 		Command: &command,
 		Target:  &target,
 	}
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	if err != nil {
 		t.Error("http put request test has failed: ", err)
 	}
@@ -131,7 +131,7 @@ func TestHttpDeleteConnection(t *testing.T) {
 		Command: &command,
 		Target:  &target,
 	}
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	if err != nil {
 		t.Error("http delete request test has failed: ", err)
 	}
@@ -160,7 +160,7 @@ func TestHttpStatus200(t *testing.T) {
 		Target:  &target,
 	}
 	// error codes are handled internally by request, with throw error != in 200 range
-	_, err := httpRequest.Request(httpOptions)
+	_, err := httpRequest.Request(context.Background(), httpOptions)
 	t.Log(err)
 	if err != nil {
 		t.Error("http get request test has failed: ", err)
@@ -168,6 +168,30 @@ func TestHttpStatus200(t *testing.T) {
 	}
 }
 
+func TestHttpResponseHeaders(t *testing.T) {
+	httpRequest := HttpRequest{}
+	target := cacao.AgentTarget{
+		Address: map[cacao.NetAddressType][]string{
+			"url": {"https://httpbin.org/response-headers?X-Case-Id=42"},
+		},
+	}
+	command := cacao.Command{
+		Type:    "http-api",
+		Command: "GET / HTTP/1.1",
+	}
+	httpOptions := HttpOptions{
+		Command: &command,
+		Target:  &target,
+	}
+	response, err := httpRequest.RequestResponse(context.Background(), httpOptions)
+	if err != nil {
+		t.Error("http get request test has failed: ", err)
+		return
+	}
+	assert.Equal(t, response.StatusCode, 200)
+	assert.Equal(t, response.Header.Get("X-Case-Id"), "42")
+}
+
 func TestHttpBearerToken(t *testing.T) {
 	bearerToken := "test_token"
 	httpRequest := HttpRequest{}
@@ -193,7 +217,7 @@ func TestHttpBearerToken(t *testing.T) {
 		Target:  &target,
 		Auth:    &auth,
 	}
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	if err != nil {
 		t.Error("http request has failed: ", err, response)
 	}
@@ -241,7 +265,7 @@ func TestHttpBasicAuth(t *testing.T) {
 		Target:  &target,
 		Auth:    &auth,
 	}
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	if err != nil {
 		t.Error("http auth Request has failed: ", err)
 	}
@@ -288,7 +312,7 @@ func TestHttpPostWithContentConnection(t *testing.T) {
 		Command: &command,
 		Target:  &target,
 	}
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 
 	t.Log(string(response))
 	if err != nil {
@@ -305,57 +329,6 @@ func TestHttpPostWithContentConnection(t *testing.T) {
 	assert.Equal(t, httpBinReponse.Data, body)
 }
 
-func TestHttpPostWithBase64ContentConnection(t *testing.T) {
-	httpRequest := HttpRequest{}
-
-	testJsonObj := testJson{Id: "28818819", User: "test", Description: "very interesting description"}
-	requestBody, err := json.Marshal(testJsonObj)
-	base64EncodedBody := base64.StdEncoding.EncodeToString(requestBody)
-	if err != nil {
-		t.Error("error Marshall JSON: ", err)
-	}
-	if len(requestBody) == 0 {
-		t.Error("empty response")
-	}
-
-	target := cacao.AgentTarget{
-		Address: map[cacao.NetAddressType][]string{
-			"url": {"https://httpbin.org/anything"},
-		},
-	}
-
-	command := cacao.Command{
-		Type:       "http-api",
-		Command:    "POST / HTTP/1.1",
-		Headers:    map[string][]string{"accept": {"application/json"}},
-		ContentB64: base64EncodedBody,
-	}
-	httpOptions := HttpOptions{
-		Command: &command,
-		Target:  &target,
-	}
-	response, err := httpRequest.Request(httpOptions)
-
-	t.Log(string(response))
-	if err != nil {
-		t.Error("http post request with body content has failed: ", err)
-	}
-
-	// specific format used by httpbin.org
-	var httpBinReponse httpBinResponseBody
-	err = json.Unmarshal(response, &httpBinReponse)
-	fmt.Println(httpBinReponse)
-	if err != nil {
-		t.Error("Could not unmashall reponse token for bearer test,", err)
-	}
-	decodedObject, err := base64.StdEncoding.DecodeString(base64EncodedBody)
-	if err != nil {
-		t.Log(err)
-		t.Fail()
-	}
-	assert.Equal(t, httpBinReponse.Data, string(decodedObject))
-}
-
 func TestHttpPathDnameParser(t *testing.T) {
 	addresses := make(map[cacao.NetAddressType][]string, 1)
 	addresses["dname"] = []string{"soarca.tno.nl"}
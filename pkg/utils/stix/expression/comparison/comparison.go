@@ -48,9 +48,8 @@ func New() *Comparison {
 type Comparison struct{}
 
 func (s *Comparison) Evaluate(expression string, vars cacao.Variables) (bool, error) {
-	parts := strings.Split(expression, " ")
-	if len(parts) != 3 {
-		err := errors.New("comparisons can only contain 3 parts as per STIX specification")
+	parts, err := split(expression)
+	if err != nil {
 		return false, err
 	}
 
@@ -102,15 +101,64 @@ func (s *Comparison) Evaluate(expression string, vars cacao.Variables) (bool, er
 
 }
 
-func findVariable(variable string, vars cacao.Variables) (cacao.Variable, error) {
-	for key, value := range vars {
-		replacementKey := fmt.Sprint(key, ":value")
-		if strings.Contains(variable, replacementKey) {
-			return value, nil
+// Split an expression in its 3 parts. The right hand side can be a STIX string
+// literal in single quotes, which may contain spaces and escapes \' and \\.
+func split(expression string) ([]string, error) {
+	parts := strings.SplitN(expression, " ", 3)
+	if len(parts) == 3 && strings.HasPrefix(parts[2], "'") {
+		literal, err := unquote(parts[2])
+		if err != nil {
+			return nil, err
 		}
+		parts[2] = literal
+		return parts, nil
+	}
+
+	parts = strings.Split(expression, " ")
+	if len(parts) != 3 {
+		return nil, errors.New("comparisons can only contain 3 parts as per STIX specification")
+	}
+	return parts, nil
+}
 
+func unquote(literal string) (string, error) {
+	if len(literal) < 2 || !strings.HasSuffix(literal, "'") {
+		return "", fmt.Errorf("unterminated string literal %s", literal)
 	}
-	return cacao.Variable{}, nil
+	var value strings.Builder
+	escaped := false
+	for _, char := range literal[1 : len(literal)-1] {
+		switch {
+		case escaped:
+			if char != '\'' && char != '\\' {
+				return "", fmt.Errorf("invalid escape in string literal %s", literal)
+			}
+			value.WriteRune(char)
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == '\'':
+			return "", fmt.Errorf("unescaped quote in string literal %s", literal)
+		default:
+			value.WriteRune(char)
+		}
+	}
+	if escaped {
+		return "", fmt.Errorf("unterminated string literal %s", literal)
+	}
+	return value.String(), nil
+}
+
+// Quote a value as a STIX string literal, for the right hand side of a comparison
+func Quote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// References with a path into a dictionary or list variable are typed after
+// the value at the path
+func findVariable(variable string, vars cacao.Variables) (cacao.Variable, error) {
+	usedVariable, _, err := vars.Referenced(variable)
+	return usedVariable, err
 }
 
 func stringCompare(parts []string) (bool, error) {
@@ -339,3 +339,74 @@ func TestUuidEquals(t *testing.T) {
 	assert.Equal(t, result, false)
 
 }
+
+func TestDictionaryPathEquals(t *testing.T) {
+	stix := New()
+
+	var1 := cacao.Variable{Type: cacao.VariableTypeDictionary}
+	var1.Value = `{"src": {"ip": "10.0.0.1"}, "severity": 3, "score": 0.75, "closed": false}`
+	var1.Name = "__alert__"
+	var2 := cacao.Variable{Type: cacao.VariableTypeList}
+	var2.Value = `["pc1", "pc2"]`
+	var2.Name = "__hosts__"
+	vars := cacao.NewVariables(var1, var2)
+
+	result, err := stix.Evaluate("__alert__:value.src.ip = 10.0.0.1", vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, true)
+
+	result, err = stix.Evaluate("__alert__:value.severity >= 3", vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, true)
+
+	result, err = stix.Evaluate("__alert__:value.score < 0.5", vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, false)
+
+	result, err = stix.Evaluate("__alert__:value.closed = false", vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, true)
+
+	result, err = stix.Evaluate("__hosts__:value[1] = pc2", vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, true)
+
+	result, err = stix.Evaluate("__alert__:value.dst.ip = 10.0.0.1", vars)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, result, false)
+}
+
+func TestQuotedStringEquals(t *testing.T) {
+	stix := New()
+
+	var1 := cacao.Variable{Type: cacao.VariableTypeString}
+	var1.Value = "analyst's call"
+	var1.Name = "__var1__"
+	var2 := cacao.Variable{Type: cacao.VariableTypeString}
+	var2.Value = `C:\temp`
+	var2.Name = "__var2__"
+	vars := cacao.NewVariables(var1, var2)
+
+	result, err := stix.Evaluate(`__var1__:value = 'analyst\'s call'`, vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, true)
+
+	result, err = stix.Evaluate("__var1__:value = "+Quote("analyst's call"), vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, true)
+
+	result, err = stix.Evaluate("__var2__:value = "+Quote(`C:\temp`), vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, true)
+
+	result, err = stix.Evaluate("__var1__:value = 'analyst'", vars)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result, false)
+
+	_, err = stix.Evaluate("__var1__:value = 'analyst's call'", vars)
+	assert.NotEqual(t, err, nil)
+	_, err = stix.Evaluate("__var1__:value = 'analyst", vars)
+	assert.NotEqual(t, err, nil)
+	_, err = stix.Evaluate(`__var1__:value = 'analyst\'`, vars)
+	assert.NotEqual(t, err, nil)
+}
@@ -5,6 +5,8 @@ import "time"
 type ITime interface {
 	Now() time.Time
 	Sleep(duration time.Duration)
+	// Fires once the duration has elapsed, unlike Sleep the wait can be abandoned
+	After(duration time.Duration) <-chan time.Time
 }
 
 type Time struct {
@@ -17,3 +19,7 @@ func (t *Time) Now() time.Time {
 func (t *Time) Sleep(duration time.Duration) {
 	time.Sleep(duration)
 }
+
+func (t *Time) After(duration time.Duration) <-chan time.Time {
+	return time.After(duration)
+}
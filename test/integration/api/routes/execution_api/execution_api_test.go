@@ -0,0 +1,189 @@
+package execution_api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	api_routes "soarca/pkg/api"
+	execution_api "soarca/pkg/api/execution"
+	apiModel "soarca/pkg/models/api"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/models/execution"
+	"soarca/test/unittest/mocks/mock_execution_manager"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+)
+
+func TestCancelExecution(t *testing.T) {
+	mock_execution_manager := mock_execution_manager.MockExecutionManager{}
+	executionApiHandler := execution_api.NewExecutionHandler(&mock_execution_manager)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+
+	recorder := httptest.NewRecorder()
+	api_routes.ExecutionRoutes(app, executionApiHandler)
+
+	executionId := uuid.MustParse("50b6d52c-6efc-4516-a242-dfbc5c89d421")
+	mock_execution_manager.On("Cancel", executionId).Return(nil)
+
+	request, err := http.NewRequest("POST", "/execution/"+executionId.String()+"/cancel", nil)
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+
+	expected, _ := json.Marshal(apiModel.ExecutionStatus{ExecutionId: executionId, Status: "cancelled"})
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, string(expected), recorder.Body.String())
+	mock_execution_manager.AssertExpectations(t)
+}
+
+func TestCancelExecutionNotRunning(t *testing.T) {
+	mock_execution_manager := mock_execution_manager.MockExecutionManager{}
+	executionApiHandler := execution_api.NewExecutionHandler(&mock_execution_manager)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+
+	recorder := httptest.NewRecorder()
+	api_routes.ExecutionRoutes(app, executionApiHandler)
+
+	executionId := uuid.MustParse("50b6d52c-6efc-4516-a242-dfbc5c89d421")
+	mock_execution_manager.On("Cancel", executionId).Return(execution.ErrorExecutionNotFound{ExecutionId: executionId})
+
+	request, err := http.NewRequest("POST", "/execution/"+executionId.String()+"/cancel", nil)
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+	mock_execution_manager.AssertExpectations(t)
+}
+
+func TestCancelExecutionInvalidId(t *testing.T) {
+	mock_execution_manager := mock_execution_manager.MockExecutionManager{}
+	executionApiHandler := execution_api.NewExecutionHandler(&mock_execution_manager)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+
+	recorder := httptest.NewRecorder()
+	api_routes.ExecutionRoutes(app, executionApiHandler)
+
+	request, err := http.NewRequest("POST", "/execution/not-a-uuid/cancel", nil)
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+	mock_execution_manager.AssertNotCalled(t, "Cancel")
+}
+
+func TestPauseExecution(t *testing.T) {
+	mock_execution_manager := mock_execution_manager.MockExecutionManager{}
+	executionApiHandler := execution_api.NewExecutionHandler(&mock_execution_manager)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+
+	recorder := httptest.NewRecorder()
+	api_routes.ExecutionRoutes(app, executionApiHandler)
+
+	executionId := uuid.MustParse("50b6d52c-6efc-4516-a242-dfbc5c89d421")
+	mock_execution_manager.On("Pause", executionId).Return(nil)
+
+	request, err := http.NewRequest("POST", "/execution/"+executionId.String()+"/pause", nil)
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+
+	expected, _ := json.Marshal(apiModel.ExecutionStatus{ExecutionId: executionId, Status: "paused"})
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, string(expected), recorder.Body.String())
+	mock_execution_manager.AssertExpectations(t)
+}
+
+func TestResumeExecutionNotPaused(t *testing.T) {
+	mock_execution_manager := mock_execution_manager.MockExecutionManager{}
+	executionApiHandler := execution_api.NewExecutionHandler(&mock_execution_manager)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+
+	recorder := httptest.NewRecorder()
+	api_routes.ExecutionRoutes(app, executionApiHandler)
+
+	executionId := uuid.MustParse("50b6d52c-6efc-4516-a242-dfbc5c89d421")
+	mock_execution_manager.On("Resume", executionId).
+		Return(execution.ErrorExecutionState{ExecutionId: executionId, Paused: false})
+
+	request, err := http.NewRequest("POST", "/execution/"+executionId.String()+"/resume", nil)
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 409, recorder.Code)
+	mock_execution_manager.AssertExpectations(t)
+}
+
+func TestUpdateExecutionVariables(t *testing.T) {
+	mock_execution_manager := mock_execution_manager.MockExecutionManager{}
+	executionApiHandler := execution_api.NewExecutionHandler(&mock_execution_manager)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+
+	recorder := httptest.NewRecorder()
+	api_routes.ExecutionRoutes(app, executionApiHandler)
+
+	executionId := uuid.MustParse("50b6d52c-6efc-4516-a242-dfbc5c89d421")
+	update := cacao.NewVariables(cacao.Variable{Name: "__host__", Value: "10.0.0.2"})
+	updated := cacao.NewVariables(cacao.Variable{Type: cacao.VariableTypeString, Name: "__host__", Value: "10.0.0.2"})
+	mock_execution_manager.On("UpdateVariables", executionId, update).Return(updated, nil)
+
+	body, _ := json.Marshal(update)
+	request, err := http.NewRequest("PATCH", "/execution/"+executionId.String()+"/variables", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+
+	expected, _ := json.Marshal(updated)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, string(expected), recorder.Body.String())
+	mock_execution_manager.AssertExpectations(t)
+}
+
+func TestUpdateExecutionVariablesInvalidBody(t *testing.T) {
+	mock_execution_manager := mock_execution_manager.MockExecutionManager{}
+	executionApiHandler := execution_api.NewExecutionHandler(&mock_execution_manager)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+
+	recorder := httptest.NewRecorder()
+	api_routes.ExecutionRoutes(app, executionApiHandler)
+
+	executionId := uuid.MustParse("50b6d52c-6efc-4516-a242-dfbc5c89d421")
+	request, err := http.NewRequest("PATCH", "/execution/"+executionId.String()+"/variables",
+		bytes.NewBufferString("not json"))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+	mock_execution_manager.AssertNotCalled(t, "UpdateVariables")
+}
@@ -12,10 +12,13 @@ import (
 	"soarca/pkg/models/api"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/decoder"
+	timeUtil "soarca/pkg/utils/time"
 	"testing"
+	"time"
 
 	mock_database_controller "soarca/test/unittest/mocks/mock_controller/database"
 	mock_playbook "soarca/test/unittest/mocks/mock_playbook_database"
+	mock_time "soarca/test/unittest/mocks/mock_utils/time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -71,7 +74,7 @@ func TestGetPlaybookMetas(t *testing.T) {
 	mockPlaybook.On("GetPlaybookMetas").Return(dummyPlaybookMetas, nil)
 
 	w := httptest.NewRecorder()
-	api_routes.PlaybookRoutes(app, mockController)
+	api_routes.PlaybookRoutes(app, mockController, &timeUtil.Time{})
 	req, _ := http.NewRequest("GET", "/playbook/meta/", nil)
 	app.ServeHTTP(w, req)
 
@@ -115,7 +118,7 @@ func TestGetPlaybooks(t *testing.T) {
 	}
 	mockPlaybook.On("GetPlaybooks").Return(playbooks, nil)
 	w := httptest.NewRecorder()
-	api_routes.PlaybookRoutes(app, mockController)
+	api_routes.PlaybookRoutes(app, mockController, &timeUtil.Time{})
 	req, _ := http.NewRequest("GET", "/playbook/", nil)
 	app.ServeHTTP(w, req)
 
@@ -147,7 +150,7 @@ func TestGetPlaybookByID(t *testing.T) {
 	}
 
 	w := httptest.NewRecorder()
-	api_routes.PlaybookRoutes(app, mockController)
+	api_routes.PlaybookRoutes(app, mockController, &timeUtil.Time{})
 
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/playbook/%s", dummyPlaybook.ID), nil)
 	app.ServeHTTP(w, req)
@@ -186,7 +189,7 @@ func TestPostPlaybook(t *testing.T) {
 	mockPlaybook.On("Create", &pointerDummyObject).Return(*dummyPlaybook, nil)
 
 	w := httptest.NewRecorder()
-	api_routes.PlaybookRoutes(app, mockController)
+	api_routes.PlaybookRoutes(app, mockController, &timeUtil.Time{})
 	req, _ := http.NewRequest("POST", "/playbook/", bytes.NewBuffer(marshalledDummyPlaybook))
 	app.ServeHTTP(w, req)
 
@@ -217,7 +220,7 @@ func TestDeletePlaybook(t *testing.T) {
 	}
 	mockPlaybook.On("Delete", dummyPlaybook.ID).Return(nil)
 	w := httptest.NewRecorder()
-	api_routes.PlaybookRoutes(app, mockController)
+	api_routes.PlaybookRoutes(app, mockController, &timeUtil.Time{})
 	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/playbook/%s", dummyPlaybook.ID), nil)
 	app.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
@@ -253,7 +256,7 @@ func TestUpdatePlaybook(t *testing.T) {
 	mockPlaybook.On("Update", dummyPlaybook.ID, &pointerDummyObject).Return(*dummyPlaybook, nil)
 
 	w := httptest.NewRecorder()
-	api_routes.PlaybookRoutes(app, mockController)
+	api_routes.PlaybookRoutes(app, mockController, &timeUtil.Time{})
 	req, _ := http.NewRequest("PUT", fmt.Sprintf("/playbook/%s", dummyPlaybook.ID), bytes.NewBuffer(marshalledDummyPlaybook))
 	app.ServeHTTP(w, req)
 
@@ -261,3 +264,38 @@ func TestUpdatePlaybook(t *testing.T) {
 	assert.Equal(t, 200, w.Code)
 	assert.JSONEq(t, string(marshalledDummyPlaybook), w.Body.String())
 }
+
+func TestGetExpiringPlaybookMetas(t *testing.T) {
+	app := gin.New()
+
+	mockController := new(mock_database_controller.Mock_Controller)
+	mockPlaybook := new(mock_playbook.MockPlaybook)
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockTime := new(mock_time.MockTime)
+	mockTime.On("Now").Return(now)
+	expiringSoon := api.PlaybookMeta{ID: "playbook--expiring-soon", ValidUntil: now.Add(24 * time.Hour)}
+	expiringLater := api.PlaybookMeta{ID: "playbook--expiring-later", ValidUntil: now.AddDate(0, 1, 0)}
+	expired := api.PlaybookMeta{ID: "playbook--expired", ValidUntil: now.Add(-24 * time.Hour)}
+	unbounded := api.PlaybookMeta{ID: "playbook--unbounded"}
+
+	mockController.On("GetDatabaseInstance").Return(mockPlaybook)
+	mockPlaybook.On("GetPlaybookMetas").Return([]api.PlaybookMeta{expiringSoon, expiringLater, expired, unbounded}, nil)
+	api_routes.PlaybookRoutes(app, mockController, mockTime)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/playbook/meta/?expiring_within=168h", nil)
+	app.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	metas := []api.PlaybookMeta{}
+	err := json.Unmarshal(w.Body.Bytes(), &metas)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(metas))
+	assert.Equal(t, expiringSoon.ID, metas[0].ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/playbook/meta/?expiring_within=week", nil)
+	app.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
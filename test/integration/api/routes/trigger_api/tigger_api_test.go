@@ -9,10 +9,19 @@ import (
 	"net/http/httptest"
 	"os"
 	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/scheduler"
+	"soarca/pkg/models/api"
 	"soarca/pkg/models/cacao"
+	cache_model "soarca/pkg/models/cache"
+	"soarca/pkg/models/execution"
+	timeUtil "soarca/pkg/utils/time"
+	"soarca/test/unittest/mocks/mock_cache"
 	"soarca/test/unittest/mocks/mock_decomposer"
 	"soarca/test/unittest/mocks/mock_playbook_database"
+	"soarca/test/unittest/mocks/mock_scheduler"
+	mock_time "soarca/test/unittest/mocks/mock_utils/time"
 	"testing"
+	"time"
 
 	api_routes "soarca/pkg/api"
 
@@ -23,6 +32,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 )
 
 func close(file *os.File) {
@@ -32,6 +42,13 @@ func close(file *os.File) {
 	}
 }
 
+// Wait that has already expired
+func expired() <-chan time.Time {
+	fired := make(chan time.Time, 1)
+	fired <- time.Time{}
+	return fired
+}
+
 func TestTriggerExecutionOfPlaybook(t *testing.T) {
 	jsonFile, err := os.Open("../playbook.json")
 	if err != nil {
@@ -45,15 +62,16 @@ func TestTriggerExecutionOfPlaybook(t *testing.T) {
 	gin.SetMode(gin.DebugMode)
 	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
 	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
 	mock_database_controller := new(mock_database_controller.Mock_Controller)
 	mock_controller.On("NewDecomposer").Return(mock_decomposer)
 	playbook := cacao.Decode(byteValue)
 
 	recorder := httptest.NewRecorder()
-	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller)
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
 	api_routes.TriggerRoutes(app, triggerHandler)
 	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	mock_decomposer.On("ExecuteAsync", *playbook, triggerHandler.ExecutionsChannel).Return(&decomposer.ExecutionDetails{}, nil, executionId)
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, nil, nil)
 
 	request, err := http.NewRequest("POST", "/trigger/playbook", bytes.NewBuffer(byteValue))
 	if err != nil {
@@ -64,7 +82,7 @@ func TestTriggerExecutionOfPlaybook(t *testing.T) {
 	app.ServeHTTP(recorder, request)
 	assert.Equal(t, expected_return_string, recorder.Body.String())
 	assert.Equal(t, 200, recorder.Code)
-	mock_decomposer.AssertExpectations(t)
+	mock_scheduler.AssertExpectations(t)
 }
 
 func TestExecutionOfPlaybookById(t *testing.T) {
@@ -80,6 +98,7 @@ func TestExecutionOfPlaybookById(t *testing.T) {
 	app := gin.New()
 	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
 	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
 	mock_database := new(mock_playbook_database.MockPlaybook)
 	mock_database_controller := new(mock_database_controller.Mock_Controller)
 	mock_database_controller.On("GetDatabaseInstance").Return(mock_database)
@@ -89,9 +108,9 @@ func TestExecutionOfPlaybookById(t *testing.T) {
 	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 
 	recorder := httptest.NewRecorder()
-	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller)
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
 	api_routes.TriggerRoutes(app, triggerHandler)
-	mock_decomposer.On("ExecuteAsync", *playbook, triggerHandler.ExecutionsChannel).Return(&decomposer.ExecutionDetails{}, nil, executionId)
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, nil, nil)
 
 	request, err := http.NewRequest("POST", "/trigger/playbook/1", nil)
 	if err != nil {
@@ -99,7 +118,7 @@ func TestExecutionOfPlaybookById(t *testing.T) {
 	}
 	app.ServeHTTP(recorder, request)
 	assert.Equal(t, 200, recorder.Code)
-	mock_decomposer.AssertExpectations(t)
+	mock_scheduler.AssertExpectations(t)
 }
 
 func TestExecutionOfPlaybookByIdWithPayloadValidVariables(t *testing.T) {
@@ -116,6 +135,7 @@ func TestExecutionOfPlaybookByIdWithPayloadValidVariables(t *testing.T) {
 
 	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
 	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
 
 	mock_database := new(mock_playbook_database.MockPlaybook)
 	mock_database_controller := new(mock_database_controller.Mock_Controller)
@@ -137,10 +157,10 @@ func TestExecutionOfPlaybookByIdWithPayloadValidVariables(t *testing.T) {
 	assert.Equal(t, err, nil)
 
 	recorder := httptest.NewRecorder()
-	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller)
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
 	api_routes.TriggerRoutes(app, triggerHandler)
 
-	mock_decomposer.On("ExecuteAsync", *playbook, triggerHandler.ExecutionsChannel).Return(&decomposer.ExecutionDetails{}, nil, executionId)
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, nil, nil)
 
 	request, err := http.NewRequest("POST", "/trigger/playbook/1", bytes.NewReader(json))
 	if err != nil {
@@ -150,7 +170,7 @@ func TestExecutionOfPlaybookByIdWithPayloadValidVariables(t *testing.T) {
 	app.ServeHTTP(recorder, request)
 	assert.Equal(t, 200, recorder.Code)
 
-	mock_decomposer.AssertExpectations(t)
+	mock_scheduler.AssertExpectations(t)
 }
 
 func TestPlaybookByIdVariableNotInPlaybook(t *testing.T) {
@@ -166,6 +186,7 @@ func TestPlaybookByIdVariableNotInPlaybook(t *testing.T) {
 	app := gin.New()
 	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
 	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
 	mock_database := new(mock_playbook_database.MockPlaybook)
 	mock_database_controller := new(mock_database_controller.Mock_Controller)
 	mock_database_controller.On("GetDatabaseInstance").Return(mock_database)
@@ -174,7 +195,7 @@ func TestPlaybookByIdVariableNotInPlaybook(t *testing.T) {
 	mock_controller.On("NewDecomposer").Return(mock_decomposer)
 
 	recorder := httptest.NewRecorder()
-	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller)
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
 	api_routes.TriggerRoutes(app, triggerHandler)
 
 	var_not_in_playbook := cacao.Variable{
@@ -216,6 +237,7 @@ func TestPlaybookByIdVariableTypeMismatch(t *testing.T) {
 	app := gin.New()
 	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
 	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
 	mock_database := new(mock_playbook_database.MockPlaybook)
 	mock_database_controller := new(mock_database_controller.Mock_Controller)
 	mock_database_controller.On("GetDatabaseInstance").Return(mock_database)
@@ -224,7 +246,7 @@ func TestPlaybookByIdVariableTypeMismatch(t *testing.T) {
 	mock_controller.On("NewDecomposer").Return(mock_decomposer)
 
 	recorder := httptest.NewRecorder()
-	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller)
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
 	api_routes.TriggerRoutes(app, triggerHandler)
 
 	var_wrong_type := cacao.Variable{
@@ -254,6 +276,52 @@ func TestPlaybookByIdVariableTypeMismatch(t *testing.T) {
 	assert.Equal(t, expected_message_wrong_type, resultWrongType["message"].(string))
 }
 
+func TestPlaybookByIdVariableValueMismatch(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	gin.SetMode(gin.DebugMode)
+	app := gin.New()
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database := new(mock_playbook_database.MockPlaybook)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_database_controller.On("GetDatabaseInstance").Return(mock_database)
+	playbook := cacao.Decode(byteValue)
+	playbook.PlaybookVariables.Insert(cacao.Variable{Name: "__address__", Type: cacao.VariableTypeIpv4Address, External: true})
+	mock_database.On("Read", "1").Return(*playbook, nil)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+
+	variables := cacao.NewVariables(cacao.Variable{Name: "__address__", Type: cacao.VariableTypeIpv4Address, Value: "10.0.0.256"})
+	body, err := json.Marshal(variables)
+	assert.Equal(t, err, nil)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook/1", bytes.NewReader(body))
+	if err != nil {
+		t.Fail()
+	}
+	app.ServeHTTP(recorder, request)
+
+	var result map[string]interface{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	if err != nil {
+		t.Fatalf("Could not unmarshal response body: %v", err)
+	}
+	assert.Equal(t, 400, recorder.Code)
+	assert.Equal(t, "Cannot execute. reason: value of variable [ __address__ ] is not a valid ipv4-addr: not an IPv4 address or network", result["message"].(string))
+	mock_scheduler.AssertNotCalled(t, "Schedule", mock.Anything, mock.Anything)
+}
+
 func TestPlaybookByIdVariableIsNotExternal(t *testing.T) {
 	jsonFile, err := os.Open("../playbook.json")
 	if err != nil {
@@ -267,6 +335,7 @@ func TestPlaybookByIdVariableIsNotExternal(t *testing.T) {
 	app := gin.New()
 	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
 	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
 	mock_database := new(mock_playbook_database.MockPlaybook)
 	mock_database_controller := new(mock_database_controller.Mock_Controller)
 	mock_database_controller.On("GetDatabaseInstance").Return(mock_database)
@@ -275,7 +344,7 @@ func TestPlaybookByIdVariableIsNotExternal(t *testing.T) {
 	mock_controller.On("NewDecomposer").Return(mock_decomposer)
 
 	recorder := httptest.NewRecorder()
-	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller)
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
 	api_routes.TriggerRoutes(app, triggerHandler)
 
 	varNotExternal := cacao.Variable{
@@ -305,5 +374,391 @@ func TestPlaybookByIdVariableIsNotExternal(t *testing.T) {
 	assert.Equal(t, 400, recorder.Code)
 	assert.Equal(t, expectedError, resultNotExternal["message"].(string))
 
-	mock_decomposer.AssertExpectations(t)
+	mock_scheduler.AssertExpectations(t)
+}
+
+func TestSimulateExecutionOfPlaybook(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_controller.On("NewSimulationDecomposer").Return(mock_decomposer)
+	playbook := cacao.Decode(byteValue)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, nil, nil)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook?mode=simulate", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	expected_return_string := `{"execution_id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","payload":"playbook--61a6c41e-6efc-4516-a242-dfbc5c89d562"}`
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, expected_return_string, recorder.Body.String())
+	assert.Equal(t, 200, recorder.Code)
+	mock_scheduler.AssertExpectations(t)
+	mock_controller.AssertNotCalled(t, "NewDecomposer")
+}
+
+func TestTriggerWithUnknownMode(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook?mode=rehearse", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+	mock_controller.AssertExpectations(t)
+}
+
+func TestTriggerWithFullQueue(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+	playbook := cacao.Decode(byteValue)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+	queueFull := execution.ErrorQueueFull{PlaybookId: playbook.ID, Queued: 1000}
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(uuid.Nil, nil, queueFull)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 503, recorder.Code)
+	mock_scheduler.AssertExpectations(t)
+}
+
+func TestTriggerAndWaitForExecution(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+	playbook := cacao.Decode(byteValue)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	output := cacao.Variable{Type: cacao.VariableTypeString, Name: "__verdict__", Value: "malicious"}
+	result := make(chan scheduler.Result, 1)
+	result <- scheduler.Result{Details: decomposer.ExecutionDetails{ExecutionId: executionId,
+		PlaybookId: playbook.ID,
+		Variables:  cacao.NewVariables(output)}}
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, result, nil)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook?wait=30s", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	response := api.ExecutionResult{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, response, api.ExecutionResult{ExecutionId: executionId,
+		PlaybookId: playbook.ID,
+		Status:     api.SuccessfullyExecuted,
+		Variables:  cacao.NewVariables(output)})
+	mock_scheduler.AssertExpectations(t)
+}
+
+func TestTriggerAndWaitForFailedExecution(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+	playbook := cacao.Decode(byteValue)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	stepFailed := execution.ErrorStepFailed{StepId: "action--1"}
+	result := make(chan scheduler.Result, 1)
+	result <- scheduler.Result{Details: decomposer.ExecutionDetails{ExecutionId: executionId, PlaybookId: playbook.ID},
+		Err: stepFailed}
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, result, nil)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook?wait=30s", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	response := api.ExecutionResult{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, response.Status, api.Failed)
+	assert.Equal(t, response.Error, stepFailed.Error())
+}
+
+func TestTriggerWaitExpires(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_time := new(mock_time.MockTime)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+	playbook := cacao.Decode(byteValue)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, mock_time)
+	api_routes.TriggerRoutes(app, triggerHandler)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, make(chan scheduler.Result, 1), nil)
+
+	// The wait has expired before the execution ends
+	mock_time.On("Now").Return(time.Now())
+	mock_time.On("After", 10*time.Millisecond).Return(expired())
+
+	request, err := http.NewRequest("POST", "/trigger/playbook?wait=10ms", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	expected_return_string := `{"execution_id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","payload":"playbook--61a6c41e-6efc-4516-a242-dfbc5c89d562","status":"ongoing"}`
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 202, recorder.Code)
+	assert.Equal(t, expected_return_string, recorder.Body.String())
+}
+
+func TestTriggerWaitExpiresWhileQueued(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_time := new(mock_time.MockTime)
+	mock_cache := new(mock_cache.Mock_Cache)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+	playbook := cacao.Decode(byteValue)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, mock_time)
+	triggerHandler.SetInformer(mock_cache)
+	api_routes.TriggerRoutes(app, triggerHandler)
+	executionId, _ := uuid.Parse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	mock_scheduler.On("Schedule", mock_decomposer, *playbook).Return(executionId, make(chan scheduler.Result, 1), nil)
+	mock_cache.On("GetExecutionReport", executionId).Return(cache_model.ExecutionEntry{ExecutionId: executionId,
+		Status: cache_model.Queued}, nil)
+
+	// The wait has expired before the execution ends
+	mock_time.On("Now").Return(time.Now())
+	mock_time.On("After", 10*time.Millisecond).Return(expired())
+
+	request, err := http.NewRequest("POST", "/trigger/playbook?wait=10ms", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	expected_return_string := `{"execution_id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","payload":"playbook--61a6c41e-6efc-4516-a242-dfbc5c89d562","status":"queued"}`
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 202, recorder.Code)
+	assert.Equal(t, expected_return_string, recorder.Body.String())
+	mock_cache.AssertExpectations(t)
+}
+
+func TestTriggerWithInvalidWait(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook?wait=forever", bytes.NewBuffer(byteValue))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+	mock_scheduler.AssertNotCalled(t, "Schedule", mock_decomposer, mock.Anything)
+}
+
+func TestTriggerExpiredPlaybook(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_decomposer := new(mock_decomposer.Mock_Decomposer)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database := new(mock_playbook_database.MockPlaybook)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_time := new(mock_time.MockTime)
+	mock_database_controller.On("GetDatabaseInstance").Return(mock_database)
+	mock_controller.On("NewDecomposer").Return(mock_decomposer)
+
+	// Retired a second ago
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mock_time.On("Now").Return(now)
+	playbook := cacao.Decode(byteValue)
+	playbook.ValidUntil = now.Add(-time.Second)
+	mock_database.On("Read", "1").Return(*playbook, nil)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, mock_time)
+	api_routes.TriggerRoutes(app, triggerHandler)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook/1", nil)
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 422, recorder.Code)
+	mock_scheduler.AssertNotCalled(t, "Schedule", mock.Anything, mock.Anything)
+}
+
+func TestTriggerPlaybookNotValidYet(t *testing.T) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	// Valid from a second from now
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	playbook := cacao.Decode(byteValue)
+	playbook.ValidFrom = now.Add(time.Second)
+	playbook.ValidUntil = now.AddDate(1, 0, 0)
+	body, err := json.Marshal(playbook)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := gin.New()
+	gin.SetMode(gin.DebugMode)
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_scheduler := new(mock_scheduler.Mock_Scheduler)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_time := new(mock_time.MockTime)
+	mock_time.On("Now").Return(now)
+
+	recorder := httptest.NewRecorder()
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, mock_scheduler, mock_time)
+	api_routes.TriggerRoutes(app, triggerHandler)
+
+	request, err := http.NewRequest("POST", "/trigger/playbook", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fail()
+	}
+
+	app.ServeHTTP(recorder, request)
+	assert.Equal(t, 422, recorder.Code)
+	mock_scheduler.AssertNotCalled(t, "Schedule", mock.Anything, mock.Anything)
 }
@@ -0,0 +1,148 @@
+package trigger_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	api_routes "soarca/pkg/api"
+	trigger_handler "soarca/pkg/api/trigger"
+	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/scheduler"
+	"soarca/pkg/models/api"
+	"soarca/pkg/models/cacao"
+	"soarca/pkg/utils/guid"
+	timeUtil "soarca/pkg/utils/time"
+	mock_database_controller "soarca/test/unittest/mocks/mock_controller/database"
+	mock_decomposer_controller "soarca/test/unittest/mocks/mock_controller/decomposer"
+	"soarca/test/unittest/mocks/mock_playbook_database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+)
+
+const parallelTriggers = 200
+
+// Ends every execution after a short delay, with its execution id as output
+type echoDecomposer struct {
+	mutex    sync.Mutex
+	executed map[uuid.UUID]int
+}
+
+func (echo *echoDecomposer) Execute(ctx context.Context, playbook cacao.Playbook) (*decomposer.ExecutionDetails, error) {
+	return echo.ExecuteWithId(ctx, uuid.New(), playbook)
+}
+
+func (echo *echoDecomposer) ExecuteWithId(ctx context.Context, executionId uuid.UUID, playbook cacao.Playbook) (*decomposer.ExecutionDetails, error) {
+	time.Sleep(time.Millisecond)
+	echo.mutex.Lock()
+	echo.executed[executionId]++
+	echo.mutex.Unlock()
+
+	output := cacao.Variable{Type: cacao.VariableTypeString, Name: "__execution_id__", Value: executionId.String()}
+	return &decomposer.ExecutionDetails{ExecutionId: executionId,
+		PlaybookId: playbook.ID,
+		Variables:  cacao.NewVariables(output)}, nil
+}
+
+func setupLoadTest(t *testing.T) (*gin.Engine, *echoDecomposer, *scheduler.Scheduler) {
+	jsonFile, err := os.Open("../playbook.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(jsonFile)
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	gin.SetMode(gin.ReleaseMode)
+	app := gin.New()
+	echo := &echoDecomposer{executed: map[uuid.UUID]int{}}
+	mock_controller := new(mock_decomposer_controller.Mock_Controller)
+	mock_database := new(mock_playbook_database.MockPlaybook)
+	mock_database_controller := new(mock_database_controller.Mock_Controller)
+	mock_database_controller.On("GetDatabaseInstance").Return(mock_database)
+	mock_database.On("Read", "1").Return(*cacao.Decode(byteValue), nil)
+	mock_controller.On("NewDecomposer").Return(echo)
+
+	executionScheduler := scheduler.New(&guid.Guid{}, &timeUtil.Time{}, scheduler.Limits{MaxRunning: 8})
+	triggerHandler := trigger_handler.NewTriggerHandler(mock_controller, mock_database_controller, executionScheduler, &timeUtil.Time{})
+	api_routes.TriggerRoutes(app, triggerHandler)
+	return app, echo, executionScheduler
+}
+
+// Trigger the playbook from parallel requests and collect the responses
+func triggerInParallel(t *testing.T, app *gin.Engine, url string) []*httptest.ResponseRecorder {
+	recorders := make([]*httptest.ResponseRecorder, parallelTriggers)
+	// Released at once, so the requests hit the handler at the same time
+	var start sync.WaitGroup
+	start.Add(1)
+	var wg sync.WaitGroup
+	for index := range recorders {
+		recorders[index] = httptest.NewRecorder()
+		request, err := http.NewRequest("POST", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(recorder *httptest.ResponseRecorder) {
+			defer wg.Done()
+			start.Wait()
+			app.ServeHTTP(recorder, request)
+		}(recorders[index])
+	}
+	start.Done()
+	wg.Wait()
+	return recorders
+}
+
+func TestParallelTriggersOfSamePlaybook(t *testing.T) {
+	app, echo, executionScheduler := setupLoadTest(t)
+
+	recorders := triggerInParallel(t, app, "/trigger/playbook/1")
+
+	executionIds := map[uuid.UUID]bool{}
+	for _, recorder := range recorders {
+		assert.Equal(t, recorder.Code, 200)
+		response := api.Execution{}
+		err := json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, err, nil)
+		executionIds[response.ExecutionId] = true
+	}
+	assert.Equal(t, len(executionIds), parallelTriggers)
+
+	// Every execution id that was handed out is executed exactly once
+	deadline := time.Now().Add(10 * time.Second)
+	for executionScheduler.Status() != (scheduler.QueueStatus{MaxRunning: 8}) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	echo.mutex.Lock()
+	defer echo.mutex.Unlock()
+	assert.Equal(t, len(echo.executed), parallelTriggers)
+	for executionId := range executionIds {
+		assert.Equal(t, echo.executed[executionId], 1)
+	}
+}
+
+func TestParallelTriggersWaitForOwnExecution(t *testing.T) {
+	app, _, _ := setupLoadTest(t)
+
+	recorders := triggerInParallel(t, app, "/trigger/playbook/1?wait=10s")
+
+	executionIds := map[uuid.UUID]bool{}
+	for _, recorder := range recorders {
+		assert.Equal(t, recorder.Code, 200)
+		response := api.ExecutionResult{}
+		err := json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, response.Status, api.SuccessfullyExecuted)
+		assert.Equal(t, response.Variables["__execution_id__"].Value, response.ExecutionId.String())
+		executionIds[response.ExecutionId] = true
+	}
+	assert.Equal(t, len(executionIds), parallelTriggers)
+}
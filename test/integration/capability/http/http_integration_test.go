@@ -1,6 +1,7 @@
 package http_integrations_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -47,6 +48,7 @@ func TestHttpConnection(t *testing.T) {
 	}
 	// But what to do if there is no target and no AuthInfo?
 	results, err := httpCapability.Execute(
+		context.Background(),
 		metadata, data)
 	if err != nil {
 		fmt.Println(err)
@@ -90,6 +92,7 @@ func TestHttpOAuth2(t *testing.T) {
 		Variables:      cacao.NewVariables(),
 	}
 	results, err := httpCapability.Execute(
+		context.Background(),
 		metadata,
 		data)
 	if err != nil {
@@ -136,6 +139,7 @@ func TestHttpBasicAuth(t *testing.T) {
 		Variables:      cacao.NewVariables(),
 	}
 	results, err := httpCapability.Execute(
+		context.Background(),
 		metadata,
 		data)
 	if err != nil {
@@ -163,7 +167,7 @@ func TestInsecureHTTPConnection(t *testing.T) {
 		Target:  &target,
 	}
 	httpRequest.SkipCertificateValidation(true)
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	assert.Equal(t, err, nil)
 	t.Log(string(response))
 	if len(response) == 0 {
@@ -190,7 +194,7 @@ func TestInsecureHTTPConnectionWithFailure(t *testing.T) {
 		Target:  &target,
 	}
 
-	response, err := httpRequest.Request(httpOptions)
+	response, err := httpRequest.Request(context.Background(), httpOptions)
 	assert.NotEqual(t, err, nil)
 	t.Log(string(response))
 }
@@ -1,6 +1,7 @@
 package ssh_integration_test
 
 import (
+	"context"
 	"fmt"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/core/capability/ssh"
@@ -49,7 +50,8 @@ func TestSshConnection(t *testing.T) {
 		Authentication: expectedAuthenticationInformation,
 		Variables:      cacao.NewVariables(expectedVariables),
 	}
-	results, err := sshCapability.Execute(metadata,
+	results, err := sshCapability.Execute(context.Background(),
+		metadata,
 		data)
 	if err != nil {
 		fmt.Println(err)
@@ -97,7 +99,8 @@ func TestSshConnectionToNonExistingServer(t *testing.T) {
 		Authentication: expectedAuthenticationInformation,
 		Variables:      cacao.NewVariables(expectedVariables),
 	}
-	results, err := sshCapability.Execute(metadata,
+	results, err := sshCapability.Execute(context.Background(),
+		metadata,
 		data)
 	assert.NotEqual(t, err, nil)
 
@@ -1,6 +1,7 @@
 package mqtt_test
 
 import (
+	"context"
 	"fmt"
 	"soarca/pkg/core/capability/fin/protocol"
 	model "soarca/pkg/models/fin"
@@ -26,7 +27,7 @@ func TestConnect(t *testing.T) {
 	expectedCommand.CommandSubstructure.Context.PlaybookId = playbookId
 	expectedCommand.CommandSubstructure.Context.StepId = stepId
 
-	result, err := prot.SendCommand(expectedCommand)
+	result, err := prot.SendCommand(context.Background(), expectedCommand)
 	if err != nil {
 		t.Fail()
 	}
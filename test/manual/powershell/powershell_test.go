@@ -1,6 +1,7 @@
 package powershell_integration_test
 
 import (
+	"context"
 	"fmt"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/core/capability/powershell"
@@ -41,7 +42,8 @@ func TestPowershellConnection(t *testing.T) {
 		Authentication: expectedAuthenticationInformation,
 		Target:         expectedTarget,
 	}
-	results, err := powershell.Execute(metadata,
+	results, err := powershell.Execute(context.Background(),
+		metadata,
 		data)
 	if err != nil {
 		fmt.Println(err)
@@ -1,6 +1,7 @@
 package mock_capability
 
 import (
+	"context"
 	"soarca/pkg/core/capability"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
@@ -12,9 +13,10 @@ type Mock_Capability struct {
 	mock.Mock
 }
 
-func (capability *Mock_Capability) Execute(metadata execution.Metadata,
-	context capability.Context) (cacao.Variables, error) {
-	args := capability.Called(metadata, context)
+func (capability *Mock_Capability) Execute(ctx context.Context,
+	metadata execution.Metadata,
+	capabilityContext capability.Context) (cacao.Variables, error) {
+	args := capability.Called(ctx, metadata, capabilityContext)
 	return args.Get(0).(cacao.Variables), args.Error(1)
 }
 
@@ -14,3 +14,8 @@ func (mock *Mock_Controller) NewDecomposer() decomposer.IDecomposer {
 	args := mock.Called()
 	return args.Get(0).(decomposer.IDecomposer)
 }
+
+func (mock *Mock_Controller) NewSimulationDecomposer() decomposer.IDecomposer {
+	args := mock.Called()
+	return args.Get(0).(decomposer.IDecomposer)
+}
@@ -1,6 +1,7 @@
 package mock_decomposer
 
 import (
+	"context"
 	"soarca/pkg/core/decomposer"
 	"soarca/pkg/models/cacao"
 
@@ -12,14 +13,12 @@ type Mock_Decomposer struct {
 	mock.Mock
 }
 
-func (mock *Mock_Decomposer) ExecuteAsync(playbook cacao.Playbook, detailsch chan decomposer.ExecutionDetails) {
-	args := mock.Called(playbook, detailsch)
-	if detailsch != nil {
-		details := decomposer.ExecutionDetails{ExecutionId: args.Get(2).(uuid.UUID), PlaybookId: playbook.ID, Variables: cacao.NewVariables()}
-		detailsch <- details
-	}
+func (mock *Mock_Decomposer) Execute(ctx context.Context, playbook cacao.Playbook) (*decomposer.ExecutionDetails, error) {
+	args := mock.Called(ctx, playbook)
+	return args.Get(0).(*decomposer.ExecutionDetails), args.Error(1)
 }
-func (mock *Mock_Decomposer) Execute(playbook cacao.Playbook) (*decomposer.ExecutionDetails, error) {
-	args := mock.Called(playbook)
+
+func (mock *Mock_Decomposer) ExecuteWithId(ctx context.Context, executionId uuid.UUID, playbook cacao.Playbook) (*decomposer.ExecutionDetails, error) {
+	args := mock.Called(ctx, executionId, playbook)
 	return args.Get(0).(*decomposer.ExecutionDetails), args.Error(1)
 }
@@ -0,0 +1,57 @@
+package mock_execution_manager
+
+import (
+	"context"
+	"soarca/pkg/models/cacao"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockExecutionManager struct {
+	mock.Mock
+}
+
+func (manager *MockExecutionManager) Register(ctx context.Context,
+	executionId uuid.UUID,
+	cancel context.CancelFunc) context.Context {
+	args := manager.Called(ctx, executionId, cancel)
+	return args.Get(0).(context.Context)
+}
+
+func (manager *MockExecutionManager) Deregister(executionId uuid.UUID) {
+	manager.Called(executionId)
+}
+
+func (manager *MockExecutionManager) Cancel(executionId uuid.UUID) error {
+	args := manager.Called(executionId)
+	return args.Error(0)
+}
+
+func (manager *MockExecutionManager) Pause(executionId uuid.UUID) error {
+	args := manager.Called(executionId)
+	return args.Error(0)
+}
+
+func (manager *MockExecutionManager) Resume(executionId uuid.UUID) error {
+	args := manager.Called(executionId)
+	return args.Error(0)
+}
+
+func (manager *MockExecutionManager) GetVariables(executionId uuid.UUID) (cacao.Variables, error) {
+	args := manager.Called(executionId)
+	return args.Get(0).(cacao.Variables), args.Error(1)
+}
+
+func (manager *MockExecutionManager) UpdateVariables(executionId uuid.UUID,
+	variables cacao.Variables) (cacao.Variables, error) {
+	args := manager.Called(executionId, variables)
+	return args.Get(0).(cacao.Variables), args.Error(1)
+}
+
+func (manager *MockExecutionManager) AwaitResume(ctx context.Context,
+	executionId uuid.UUID,
+	scopeVariables cacao.Variables) error {
+	args := manager.Called(ctx, executionId, scopeVariables)
+	return args.Error(0)
+}
@@ -1,6 +1,7 @@
 package mock_executor
 
 import (
+	"context"
 	"soarca/pkg/core/executors"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
@@ -13,9 +14,10 @@ type Mock_Action_Executor struct {
 }
 
 func (executer *Mock_Action_Executor) Execute(
+	ctx context.Context,
 	metadata execution.Metadata,
 	details executors.PlaybookStepMetadata) (cacao.Variables,
 	error) {
-	args := executer.Called(metadata, details)
+	args := executer.Called(ctx, metadata, details)
 	return args.Get(0).(cacao.Variables), args.Error(1)
 }
@@ -1,6 +1,7 @@
 package mock_playbook_action_executor
 
 import (
+	"context"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/execution"
 
@@ -11,9 +12,10 @@ type Mock_PlaybookActionExecutor struct {
 	mock.Mock
 }
 
-func (executer *Mock_PlaybookActionExecutor) Execute(metadata execution.Metadata,
+func (executer *Mock_PlaybookActionExecutor) Execute(ctx context.Context,
+	metadata execution.Metadata,
 	step cacao.Step,
 	variables cacao.Variables) (cacao.Variables, error) {
-	args := executer.Called(metadata, step, variables)
+	args := executer.Called(ctx, metadata, step, variables)
 	return args.Get(0).(cacao.Variables), args.Error(1)
 }
@@ -1,6 +1,7 @@
 package mock_finprotocol
 
 import (
+	"context"
 	"soarca/pkg/models/cacao"
 	"soarca/pkg/models/fin"
 
@@ -11,7 +12,7 @@ type MockFinProtocol struct {
 	mock.Mock
 }
 
-func (finProtocol *MockFinProtocol) SendCommand(command fin.Command) (cacao.Variables, error) {
-	args := finProtocol.Called(command)
+func (finProtocol *MockFinProtocol) SendCommand(ctx context.Context, command fin.Command) (cacao.Variables, error) {
+	args := finProtocol.Called(ctx, command)
 	return args.Get(0).(cacao.Variables), args.Error(1)
 }
@@ -0,0 +1,19 @@
+package mock_journal
+
+import (
+	"soarca/pkg/models/cacao"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockJournal struct {
+	mock.Mock
+}
+
+func (journal *MockJournal) Checkpoint(executionId uuid.UUID,
+	playbook cacao.Playbook,
+	stepId string,
+	scopeVariables cacao.Variables) {
+	journal.Called(executionId, playbook, stepId, scopeVariables)
+}
@@ -35,3 +35,13 @@ func (ds_reporter *Mock_Downstream_Reporter) ReportStepEnd(executionId uuid.UUID
 	args := ds_reporter.Called(executionId, step, stepResults, stepError, at)
 	return args.Error(0)
 }
+func (ds_reporter *Mock_Downstream_Reporter) ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, stepError error, at time.Time) error {
+	defer ds_reporter.Wg.Done()
+	args := ds_reporter.Called(executionId, step, attempt, stepError, at)
+	return args.Error(0)
+}
+func (ds_reporter *Mock_Downstream_Reporter) ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) error {
+	defer ds_reporter.Wg.Done()
+	args := ds_reporter.Called(executionId, step, iteration, at)
+	return args.Error(0)
+}
@@ -25,3 +25,9 @@ func (reporter *Mock_Reporter) ReportStepStart(executionId uuid.UUID, step cacao
 func (reporter *Mock_Reporter) ReportStepEnd(executionId uuid.UUID, step cacao.Step, returnVars cacao.Variables, err error, at time.Time) {
 	_ = reporter.Called(executionId, step, returnVars, err, at)
 }
+func (reporter *Mock_Reporter) ReportStepAttempt(executionId uuid.UUID, step cacao.Step, attempt int, err error, at time.Time) {
+	_ = reporter.Called(executionId, step, attempt, err, at)
+}
+func (reporter *Mock_Reporter) ReportLoopIteration(executionId uuid.UUID, step cacao.Step, iteration int, at time.Time) {
+	_ = reporter.Called(executionId, step, iteration, at)
+}
@@ -0,0 +1,25 @@
+package mock_scheduler
+
+import (
+	"soarca/pkg/core/decomposer"
+	"soarca/pkg/core/scheduler"
+	"soarca/pkg/models/cacao"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type Mock_Scheduler struct {
+	mock.Mock
+}
+
+func (mock *Mock_Scheduler) Schedule(decomposer decomposer.IDecomposer, playbook cacao.Playbook) (uuid.UUID, <-chan scheduler.Result, error) {
+	args := mock.Called(decomposer, playbook)
+	result, _ := args.Get(1).(chan scheduler.Result)
+	return args.Get(0).(uuid.UUID), result, args.Error(2)
+}
+
+func (mock *Mock_Scheduler) Status() scheduler.QueueStatus {
+	args := mock.Called()
+	return args.Get(0).(scheduler.QueueStatus)
+}
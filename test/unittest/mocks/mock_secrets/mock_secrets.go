@@ -0,0 +1,16 @@
+package mock_secrets
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSecretProvider struct {
+	mock.Mock
+}
+
+func (provider *MockSecretProvider) Secret(ctx context.Context, name string) (string, error) {
+	args := provider.Called(ctx, name)
+	return args.String(0), args.Error(1)
+}
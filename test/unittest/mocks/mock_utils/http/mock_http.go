@@ -1,6 +1,7 @@
 package mock_executor
 
 import (
+	"context"
 	"soarca/pkg/utils/http"
 
 	"github.com/stretchr/testify/mock"
@@ -19,7 +20,12 @@ func (httpOptions *MockHttpOptions) ExtractUrl() (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (httpOptions *MockHttpRequest) Request(options http.HttpOptions) ([]byte, error) {
-	args := httpOptions.Called(options)
+func (httpOptions *MockHttpRequest) Request(ctx context.Context, options http.HttpOptions) ([]byte, error) {
+	args := httpOptions.Called(ctx, options)
 	return args.Get(0).([]byte), args.Error(1)
 }
+
+func (httpOptions *MockHttpRequest) RequestResponse(ctx context.Context, options http.HttpOptions) (http.Response, error) {
+	args := httpOptions.Called(ctx, options)
+	return args.Get(0).(http.Response), args.Error(1)
+}
@@ -18,3 +18,8 @@ func (t *MockTime) Now() time.Time {
 func (t *MockTime) Sleep(duration time.Duration) {
 	t.Called(duration)
 }
+
+func (t *MockTime) After(duration time.Duration) <-chan time.Time {
+	args := t.Called(duration)
+	return args.Get(0).(<-chan time.Time)
+}